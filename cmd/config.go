@@ -23,4 +23,9 @@ func init() {
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configUpdateCmd)
 	configCmd.AddCommand(configDefaultCmd)
+	configCmd.AddCommand(configEnvCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configDiscoverCmd)
 }
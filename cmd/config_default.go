@@ -10,7 +10,7 @@ import (
 var configDefaultCmd = &cobra.Command{
 	Use:   "default",
 	Short: "*Use with caution* Update config to default value",
-	Long: `Update config to default value, private keys will be copied to new config.
+	Long: `Update config to default value, private keys and profiles will be copied to new config.
 However, backup is still highly recommended before using this command`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.ReadConfigFromFile()
@@ -21,7 +21,9 @@ However, backup is still highly recommended before using this command`,
 
 		defaultCfg := config.DefaultConfig(false)
 		defaultCfg.PrivateKey = cfg.PrivateKey
-		defaultCfg.MasterPrivateKey = cfg.MasterPrivateKey
+		defaultCfg.Validators = cfg.Validators
+		defaultCfg.Profiles = cfg.Profiles
+		defaultCfg.ActiveProfile = cfg.ActiveProfile
 
 		if err = defaultCfg.SaveConfig(); err != nil {
 			fmt.Printf("Error saving config to the system: %s\n", err.Error())
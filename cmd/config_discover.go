@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fairyringclient/internal/fairyringclient"
+	"fairyringclient/pkg/cosmosClient"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// configDiscoverCmd represents the config discover command
+var configDiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Discover the chain ID, denom, address prefix & gas price from the node",
+	Long: `Discover the chain ID, bond denom, address prefix & minimum gas price from the gRPC endpoint of the node
+and compare them with the config. With --save they are written to the config, or the profile if --profile is provided`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			return
+		}
+
+		info, err := cosmosClient.DiscoverChain(cfg.GetGRPCEndpoint(), cfg.GetGRPCDialOptions()...)
+		if err != nil {
+			fmt.Printf("Error discovering chain from %s: %s\n", cfg.GetGRPCEndpoint(), err.Error())
+			return
+		}
+
+		fmt.Printf(`Chain ID: %s
+Denom: %s
+Address Prefix: %s
+Minimum Gas Prices: %s
+`, info.ChainID, info.Denom, info.Bech32Prefix, info.MinGasPrices.String())
+
+		if save, _ := cmd.Flags().GetBool("save"); save {
+			saveDiscoveredChain(info)
+			return
+		}
+
+		warnings, err := fairyringclient.CheckChainInfo(cfg.FairyRingNode, info)
+		if err != nil {
+			fmt.Printf("Config conflicts with the node: %s\n", err.Error())
+			return
+		}
+		for _, warning := range warnings {
+			fmt.Printf("Warning: %s\n", warning)
+		}
+		if len(warnings) == 0 {
+			fmt.Println("Config matches the node")
+		}
+	},
+}
+
+// saveDiscoveredChain writes the discovered chain to the node of the config or the selected profile,
+// the gas price is set to the minimum gas price of the node in the denom of the chain
+func saveDiscoveredChain(info *cosmosClient.ChainInfo) {
+	cfg, err := config.ReadConfigFromFile()
+	if err != nil {
+		fmt.Printf("Error loading config from file: %s\n", err.Error())
+		return
+	}
+
+	update := func(node *config.Node) {
+		node.ChainID = info.ChainID
+		if len(info.Denom) > 0 {
+			node.Denom = info.Denom
+		}
+		if len(info.Bech32Prefix) > 0 {
+			node.Bech32Prefix = info.Bech32Prefix
+		}
+		if minGasPrice := info.MinGasPrices.AmountOf(node.Denom); minGasPrice.IsPositive() {
+			node.GasPrice = minGasPrice.String() + node.Denom
+		}
+	}
+
+	if profileName := config.SelectedProfile(); len(profileName) > 0 {
+		profile, found := cfg.GetProfile(profileName)
+		if !found {
+			fmt.Printf("Profile %s not found in config\n", profileName)
+			return
+		}
+		update(&profile.FairyRingNode)
+		cfg.SetProfile(profile)
+	} else {
+		update(&cfg.FairyRingNode)
+	}
+
+	if err = cfg.Validate(); err != nil {
+		fmt.Printf("Error updating config: %s\n", err.Error())
+		return
+	}
+
+	if err = cfg.SaveConfig(); err != nil {
+		fmt.Printf("Error saving updated config to system: %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Successfully saved the discovered chain to config!")
+}
+
+func init() {
+	configDiscoverCmd.Flags().Bool("save", false, "Write the discovered chain ID, denom, address prefix & minimum gas price to the config")
+}
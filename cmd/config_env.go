@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// configEnvCmd represents the config env command
+var configEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List the env vars overriding the config",
+	Long: `List the env vars overriding the config fields and whether they are set, values are not shown as they can be secrets.
+Every env var has a _FILE variant reading the value from a file, slices such as Validators are set in JSON`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ENV\tFIELD\tSET")
+		for _, v := range config.EnvVars() {
+			set := ""
+			if _, found := os.LookupEnv(v.Name); found {
+				set = "env"
+			} else if _, found = os.LookupEnv(v.Name + config.EnvFileSuffix); found {
+				set = "file"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, v.Field, set)
+		}
+		_ = w.Flush()
+	},
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Show a single config field",
+	Long: `Show a single config field of the config file by its dotted key, such as FairyRingNode.GRPCPort.
+Keys are case-insensitive, slices such as Validators are shown in JSON. Without key, all the keys are listed`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			for _, key := range config.Keys() {
+				fmt.Println(key)
+			}
+			return
+		}
+
+		cfg, err := config.ReadConfigFromFile()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			return
+		}
+
+		value, err := cfg.Get(args[0])
+		if err != nil {
+			fmt.Printf("Error getting config: %s\n", err.Error())
+			return
+		}
+
+		fmt.Println(value)
+	},
+}
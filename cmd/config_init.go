@@ -9,7 +9,7 @@ import (
 var configInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Create default config file for FairyRing Client",
-	Long:  `Create default config & keys folder in your Home directory`,
+	Long:  `Create default config & keys folder in the home directory, or at the path of --config`,
 	Run: func(cmd *cobra.Command, args []string) {
 		withCosmosKey, _ := cmd.Flags().GetBool("with-cosmos-key")
 
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// configMigrateCmd represents the config migrate command
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the config file to the current version",
+	Long: `Upgrade the config file to the current version step by step,
+the original config file is backed up next to it before being upgraded`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := config.ConfigFilePath()
+		if err != nil {
+			fmt.Printf("Error resolving config file: %s\n", err.Error())
+			return
+		}
+
+		result, err := config.MigrateConfigFile(configPath)
+		if err != nil {
+			fmt.Printf("Error migrating config: %s\n", err.Error())
+			return
+		}
+
+		if result.From == result.To {
+			fmt.Printf("Config is up to date, version: %d\n", result.To)
+			return
+		}
+
+		for _, applied := range result.Applied {
+			fmt.Printf("Applied migration %s\n", applied)
+		}
+		fmt.Printf("Successfully migrated config from version %d to %d, the original config is backed up at: %s\n", result.From, result.To, result.BackupPath)
+	},
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// configProfileCmd represents the config profile command
+var configProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage the network profiles of the config",
+	Long: `Manage the network profiles of the config, a profile overrides the node endpoints, chain ID, denom, gas price and key of the config.
+The profile is selected with --profile or the active profile`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			_ = cmd.Help()
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configProfileCmd)
+
+	configProfileCmd.AddCommand(configProfileAddCmd)
+	configProfileCmd.AddCommand(configProfileListCmd)
+	configProfileCmd.AddCommand(configProfileUseCmd)
+	configProfileCmd.AddCommand(configProfileRemoveCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fairyringclient/config"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// configProfileAddCmd represents the config profile add command
+var configProfileAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add or update a network profile",
+	Long: `Add or update a network profile, only the flags passed are updated.
+A new profile starts from the node of the config`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.ReadConfigFromFile()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			return
+		}
+
+		profile, found := cfg.GetProfile(args[0])
+		if !found {
+			profile = config.Profile{Name: args[0], FairyRingNode: cfg.FairyRingNode}
+		}
+
+		updateNodeFromFlags(cmd.Flags(), &profile.FairyRingNode)
+
+		if cmd.Flags().Changed("private-key") {
+			privateKey, _ := cmd.Flags().GetString("private-key")
+			if len(privateKey) != 64 {
+				fmt.Printf("Got invalid cosmos private key length, expected 64, got: %d\n", len(privateKey))
+				return
+			}
+			if _, err = hex.DecodeString(privateKey); err != nil {
+				fmt.Printf("Got invalid cosmos private key: %s\n", err.Error())
+				return
+			}
+			profile.PrivateKey = privateKey
+		}
+
+		cfg.SetProfile(profile)
+
+		if err = cfg.SaveConfig(); err != nil {
+			fmt.Printf("Error saving updated config to system: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("Successfully saved profile %s!\n", profile.Name)
+	},
+}
+
+func init() {
+	addNodeFlags(configProfileAddCmd.Flags(), config.DefaultConfig(false).FairyRingNode)
+	configProfileAddCmd.Flags().String("private-key", "", "Cosmos private key in hex of the profile, the key of the config is used if the profile has none")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// configProfileListCmd represents the config profile list command
+var configProfileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the network profiles",
+	Long:  `List the network profiles of the config, the active profile is marked with *`,
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.ReadConfigFromFile()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tNAME\tCHAIN ID\tGRPC ENDPOINT\tDENOM\tGAS PRICE\tKEY")
+		for _, p := range cfg.ListProfiles() {
+			active := ""
+			if p.Name == cfg.ActiveProfile {
+				active = "*"
+			}
+
+			key := "config"
+			if len(p.PrivateKey) > 0 {
+				key = "profile"
+			}
+
+			node := config.Config{FairyRingNode: p.FairyRingNode}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				active, p.Name, p.FairyRingNode.ChainID, node.GetGRPCEndpoint(),
+				p.FairyRingNode.Denom, p.FairyRingNode.GasPrice, key)
+		}
+		_ = w.Flush()
+	},
+}
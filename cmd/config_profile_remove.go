@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// configProfileRemoveCmd represents the config profile remove command
+var configProfileRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Remove a network profile",
+	Long:  `Remove a network profile from the config, the active profile is cleared if it is the one removed`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.ReadConfigFromFile()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			return
+		}
+
+		if !cfg.RemoveProfile(args[0]) {
+			fmt.Printf("Profile %s not found in config\n", args[0])
+			return
+		}
+
+		if err = cfg.SaveConfig(); err != nil {
+			fmt.Printf("Error saving updated config to system: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("Successfully removed profile %s!\n", args[0])
+	},
+}
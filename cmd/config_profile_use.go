@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// configProfileUseCmd represents the config profile use command
+var configProfileUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Set the active network profile",
+	Long:  `Set the profile used when --profile is not provided, without name the node & key of the config are used`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.ReadConfigFromFile()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			return
+		}
+
+		cfg.ActiveProfile = ""
+		if len(args) > 0 {
+			if _, found := cfg.GetProfile(args[0]); !found {
+				fmt.Printf("Profile %s not found in config\n", args[0])
+				return
+			}
+			cfg.ActiveProfile = args[0]
+		}
+
+		if err = cfg.SaveConfig(); err != nil {
+			fmt.Printf("Error saving updated config to system: %s\n", err.Error())
+			return
+		}
+
+		if len(cfg.ActiveProfile) == 0 {
+			fmt.Println("Successfully cleared the active profile!")
+			return
+		}
+		fmt.Printf("Successfully set the active profile to %s!\n", cfg.ActiveProfile)
+	},
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a single config field",
+	Long: `Set a single config field by its dotted key, such as FairyRingNode.GRPCPort or BalanceMonitor.LowBalanceThreshold.
+Keys are case-insensitive, slices such as Validators are set in JSON. Run config get without key to list the keys`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.ReadConfigFromFile()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			return
+		}
+
+		if err = cfg.Set(args[0], args[1]); err != nil {
+			fmt.Printf("Error setting config: %s\n", err.Error())
+			return
+		}
+
+		if err = cfg.Validate(); err != nil {
+			fmt.Printf("Error setting config: %s\n", err.Error())
+			return
+		}
+
+		if err = cfg.SaveConfig(); err != nil {
+			fmt.Printf("Error saving updated config to system: %s\n", err.Error())
+			return
+		}
+
+		fmt.Printf("Successfully set %s!\n", args[0])
+	},
+}
@@ -12,18 +12,40 @@ var configShowCmd = &cobra.Command{
 	Short: "Show the current config",
 	Long:  `Show the current config`,
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.ReadConfigFromFile()
+		cfg, err := config.LoadConfig()
 		if err != nil {
 			fmt.Printf("Error loading config from file: %s\n", err.Error())
 			return
 		}
 
-		fmt.Printf(`GRPC Endpoint: %s
+		profileName := config.SelectedProfile()
+		if len(profileName) == 0 {
+			profileName = cfg.ActiveProfile
+		}
+
+		fmt.Printf(`Config Version: %d
+Profile: %s
+GRPC Endpoint: %s
+GRPC TLS: %t
 FairyRing Node Endpoint: %s
 Chain ID: %s
 Chain Denom: %s
+Gas Price: %s
+Address Prefix: %s
 InvalidSharePauseThreshold: %d
 MetricsPort: %d
-`, cfg.GetGRPCEndpoint(), cfg.GetFairyRingNodeURI(), cfg.FairyRingNode.ChainID, cfg.FairyRingNode.Denom, cfg.InvalidSharePauseThreshold, cfg.MetricsPort)
+LogLevel: %s
+BalanceCheckInterval: %d
+LowBalanceThreshold: %d
+PauseOptionalDutiesOnLowBalance: %t
+Notifier Webhook URL: %s
+Notifier Format: %s
+HA Enabled: %t
+HA Backend: %s
+HA Lease Blocks: %d
+`, cfg.ConfigVersion, profileName, cfg.GetGRPCEndpoint(), cfg.FairyRingNode.GRPCTLS, cfg.GetFairyRingNodeURI(), cfg.FairyRingNode.ChainID, cfg.FairyRingNode.Denom, cfg.FairyRingNode.GasPrice, cfg.FairyRingNode.Bech32Prefix, cfg.InvalidSharePauseThreshold, cfg.MetricsPort, cfg.LogLevel,
+			cfg.BalanceMonitor.CheckInterval, cfg.BalanceMonitor.LowBalanceThreshold, cfg.BalanceMonitor.PauseOptionalDuties,
+			cfg.Notifier.WebhookURL, cfg.Notifier.Format,
+			cfg.HA.Enabled, cfg.HA.Backend, cfg.HA.LeaseBlocks)
 	},
 }
@@ -4,13 +4,15 @@ import (
 	"fairyringclient/config"
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // configUpdateCmd represents the config update command
 var configUpdateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update FairyRing Client config file",
-	Long:  `Update FairyRing Client config file`,
+	Long: `Update FairyRing Client config file,
+the node flags update the profile instead if --profile is provided`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.ReadConfigFromFile()
 		if err != nil {
@@ -18,26 +20,30 @@ var configUpdateCmd = &cobra.Command{
 			return
 		}
 
-		chainID, _ := cmd.Flags().GetString("chain-id")
-		chainDenom, _ := cmd.Flags().GetString("denom")
-		chainIP, _ := cmd.Flags().GetString("ip")
-		chainProtocol, _ := cmd.Flags().GetString("protocol")
-		chainGrpcPort, _ := cmd.Flags().GetUint64("grpc-port")
-		chainPort, _ := cmd.Flags().GetUint64("port")
-		pauseThreshold, _ := cmd.Flags().GetUint64("pause-threshold")
-		metricsPort, _ := cmd.Flags().GetUint64("metrics-port")
+		// Only the flags passed are updated, the flags are read after the config file is resolved from --config & --home
+		if profileName := config.SelectedProfile(); len(profileName) > 0 {
+			profile, found := cfg.GetProfile(profileName)
+			if !found {
+				fmt.Printf("Profile %s not found in config\n", profileName)
+				return
+			}
+			updateNodeFromFlags(cmd.Flags(), &profile.FairyRingNode)
+			cfg.SetProfile(profile)
+		} else {
+			updateNodeFromFlags(cmd.Flags(), &cfg.FairyRingNode)
+		}
 
-		cfg.FairyRingNode = config.Node{
-			Protocol: chainProtocol,
-			IP:       chainIP,
-			Port:     chainPort,
-			GRPCPort: chainGrpcPort,
-			Denom:    chainDenom,
-			ChainID:  chainID,
+		if cmd.Flags().Changed("pause-threshold") {
+			cfg.InvalidSharePauseThreshold, _ = cmd.Flags().GetUint64("pause-threshold")
+		}
+		if cmd.Flags().Changed("metrics-port") {
+			cfg.MetricsPort, _ = cmd.Flags().GetUint64("metrics-port")
 		}
 
-		cfg.InvalidSharePauseThreshold = pauseThreshold
-		cfg.MetricsPort = metricsPort
+		if err = cfg.Validate(); err != nil {
+			fmt.Printf("Error updating config: %s\n", err.Error())
+			return
+		}
 
 		if err = cfg.SaveConfig(); err != nil {
 			fmt.Printf("Error saving updated config to system: %s\n", err.Error())
@@ -48,19 +54,58 @@ var configUpdateCmd = &cobra.Command{
 	},
 }
 
-func init() {
-	cfg, err := config.ReadConfigFromFile()
-	if err != nil {
-		fmt.Printf("Error loading config from file: %s\n", err.Error())
-		return
+// addNodeFlags adds the flags of the node config to the command
+func addNodeFlags(flags *pflag.FlagSet, node config.Node) {
+	flags.String("chain-id", node.ChainID, "Update config chain id")
+	flags.String("denom", node.Denom, "Update config denom")
+	flags.Uint64("grpc-port", node.GRPCPort, "Update config grpc-port")
+	flags.String("grpc-host", node.GRPCHost, "Update the host of the gRPC endpoint, empty to use the node ip address")
+	flags.Bool("grpc-tls", node.GRPCTLS, "Update whether the gRPC endpoint is dialed over TLS")
+	flags.String("ip", node.IP, "Update config node ip address")
+	flags.Uint64("port", node.Port, "Update config node port")
+	flags.String("protocol", node.Protocol, "Update config node protocol")
+	flags.String("gas-price", node.GasPrice, "Update the gas price of txs, for example 0.1ufairy, empty to submit txs without fee")
+	flags.String("bech32-prefix", node.Bech32Prefix, "Update the address prefix of the chain, for example fairy")
+}
+
+// updateNodeFromFlags updates the node with the node flags passed
+func updateNodeFromFlags(flags *pflag.FlagSet, node *config.Node) {
+	if flags.Changed("chain-id") {
+		node.ChainID, _ = flags.GetString("chain-id")
+	}
+	if flags.Changed("denom") {
+		node.Denom, _ = flags.GetString("denom")
+	}
+	if flags.Changed("ip") {
+		node.IP, _ = flags.GetString("ip")
+	}
+	if flags.Changed("protocol") {
+		node.Protocol, _ = flags.GetString("protocol")
 	}
+	if flags.Changed("grpc-port") {
+		node.GRPCPort, _ = flags.GetUint64("grpc-port")
+	}
+	if flags.Changed("grpc-host") {
+		node.GRPCHost, _ = flags.GetString("grpc-host")
+	}
+	if flags.Changed("grpc-tls") {
+		node.GRPCTLS, _ = flags.GetBool("grpc-tls")
+	}
+	if flags.Changed("port") {
+		node.Port, _ = flags.GetUint64("port")
+	}
+	if flags.Changed("gas-price") {
+		node.GasPrice, _ = flags.GetString("gas-price")
+	}
+	if flags.Changed("bech32-prefix") {
+		node.Bech32Prefix, _ = flags.GetString("bech32-prefix")
+	}
+}
+
+func init() {
+	cfg := config.DefaultConfig(false)
 
-	configUpdateCmd.Flags().String("chain-id", cfg.FairyRingNode.ChainID, "Update config chain id")
-	configUpdateCmd.Flags().String("denom", cfg.FairyRingNode.Denom, "Update config denom")
-	configUpdateCmd.Flags().Uint64("grpc-port", cfg.FairyRingNode.GRPCPort, "Update config grpc-port")
-	configUpdateCmd.Flags().String("ip", cfg.FairyRingNode.IP, "Update config node ip address")
-	configUpdateCmd.Flags().Uint64("port", cfg.FairyRingNode.Port, "Update config node port")
-	configUpdateCmd.Flags().String("protocol", cfg.FairyRingNode.Protocol, "Update config node protocol")
+	addNodeFlags(configUpdateCmd.Flags(), cfg.FairyRingNode)
 	configUpdateCmd.Flags().Uint64("pause-threshold", cfg.InvalidSharePauseThreshold, "Update the threshold of when the client pause if number of invalid share in a row reaches threshold")
 	configUpdateCmd.Flags().Uint64("metrics-port", cfg.MetricsPort, "Update the port of metrics listen to")
 }
@@ -17,7 +17,7 @@ var delegateAdd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 
-		cfg, err := config.ReadConfigFromFile()
+		cfg, err := config.LoadConfig()
 		if err != nil {
 			fmt.Printf("Error loading config from file: %s\n", err.Error())
 			return
@@ -29,16 +29,22 @@ var delegateAdd = &cobra.Command{
 			log.Fatal("Private Key is empty in config file, please add a valid cosmos account private key before starting")
 		}
 
+		cosmosClient.SetBech32Prefix(cfg.FairyRingNode.Bech32Prefix)
 		eachClient, err := cosmosClient.NewCosmosClient(
 			gRPCEndpoint,
 			cfg.PrivateKey,
 			cfg.FairyRingNode.ChainID,
+			cfg.GetGRPCDialOptions()...,
 		)
 
 		if err != nil {
 			log.Fatalf("Error creating custom cosmos client, make sure provided account is activated: %v\n", err)
 		}
 
+		if err = eachClient.SetGasPrice(cfg.FairyRingNode.GasPrice); err != nil {
+			log.Fatal(err)
+		}
+
 		msg := types.MsgCreateAuthorizedAddress{
 			Target:  args[0],
 			Creator: eachClient.GetAddress(),
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fairyringclient/internal/doctor"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the config, node & accounts before starting the client",
+	Long: `Check the connectivity to the RPC, websocket & gRPC of the node, the chain ID, tx indexing,
+the account, balance, authorization & share of every validator and the permissions of the config file.
+Exits with a non-zero code if any check fails`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath, err := config.ConfigFilePath()
+		if err != nil {
+			fmt.Printf("Error resolving config file: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		report := doctor.Run(*cfg, configPath)
+		report.Print(os.Stdout)
+
+		if report.Failed() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fairyringclient/internal/audit"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the submission history of the client",
+	Long: `Show the keyshare, general keyshare & encrypted keyshare submissions recorded by the client,
+filter by height range, type and outcome, and export the result as CSV or JSON`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fromHeight, _ := cmd.Flags().GetUint64("from-height")
+		toHeight, _ := cmd.Flags().GetUint64("to-height")
+		submissionType, _ := cmd.Flags().GetString("type")
+		outcome, _ := cmd.Flags().GetString("outcome")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		switch audit.SubmissionType(submissionType) {
+		case "", audit.TypeKeyshare, audit.TypeGeneralKeyshare, audit.TypeEncryptedKeyshare:
+		default:
+			fmt.Printf("Invalid type: %s, expected one of %s, %s, %s\n", submissionType, audit.TypeKeyshare, audit.TypeGeneralKeyshare, audit.TypeEncryptedKeyshare)
+			return
+		}
+
+		switch audit.Outcome(outcome) {
+		case "", audit.OutcomeConfirmed, audit.OutcomeFailed, audit.OutcomeSlashed, audit.OutcomeError:
+		default:
+			fmt.Printf("Invalid outcome: %s, expected one of %s, %s, %s, %s\n", outcome, audit.OutcomeConfirmed, audit.OutcomeFailed, audit.OutcomeSlashed, audit.OutcomeError)
+			return
+		}
+
+		homeDir, err := config.HomeDir()
+		if err != nil {
+			fmt.Printf("Error getting home directory: %s\n", err.Error())
+			return
+		}
+
+		records, err := audit.ReadRecords(filepath.Join(homeDir, audit.DefaultFileName), audit.Filter{
+			FromHeight: fromHeight,
+			ToHeight:   toHeight,
+			Type:       audit.SubmissionType(submissionType),
+			Outcome:    audit.Outcome(outcome),
+		})
+		if os.IsNotExist(err) {
+			fmt.Println("No submission recorded yet")
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error reading submission history: %s\n", err.Error())
+			return
+		}
+
+		out := os.Stdout
+		if len(output) > 0 {
+			out, err = os.Create(output)
+			if err != nil {
+				fmt.Printf("Error creating output file: %s\n", err.Error())
+				return
+			}
+			defer out.Close()
+		}
+
+		switch format {
+		case "csv":
+			err = audit.WriteCSV(out, records)
+		case "json":
+			err = audit.WriteJSON(out, records)
+		case "table":
+			err = writeHistoryTable(out, records)
+		default:
+			fmt.Printf("Invalid format: %s, expected one of table, csv, json\n", format)
+			return
+		}
+
+		if err != nil {
+			fmt.Printf("Error exporting submission history: %s\n", err.Error())
+			return
+		}
+
+		if len(output) > 0 {
+			fmt.Printf("Exported %d records to: %s\n", len(records), output)
+		}
+	},
+}
+
+func writeHistoryTable(out *os.File, records []audit.Record) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUBMITTED AT\tTYPE\tHEIGHT / IDENTITY\tINDEX\tOUTCOME\tCODE\tGAS USED\tTX HASH")
+	for _, r := range records {
+		target := r.Identity
+		if r.Height != 0 {
+			target = fmt.Sprintf("%d", r.Height)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%d\t%d\t%s\n",
+			r.SubmittedAt.Format(time.RFC3339), r.Type, target, r.ShareIndex, r.Outcome, r.Code, r.GasUsed, r.TxHash)
+	}
+	return w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().Uint64("from-height", 0, "Only show keyshare submissions for heights greater than or equal to this height")
+	historyCmd.Flags().Uint64("to-height", 0, "Only show keyshare submissions for heights lower than or equal to this height")
+	historyCmd.Flags().String("type", "", "Only show submissions of this type: keyshare, general_keyshare, encrypted_keyshare")
+	historyCmd.Flags().String("outcome", "", "Only show submissions with this outcome: confirmed, failed, slashed, error")
+	historyCmd.Flags().String("format", "table", "Output format: table, csv, json")
+	historyCmd.Flags().String("output", "", "Write the result to this file instead of stdout")
+}
@@ -20,7 +20,17 @@ var keysCosmosRemove = &cobra.Command{
 			return
 		}
 
-		cfg.PrivateKey = ""
+		if profileName := config.SelectedProfile(); len(profileName) > 0 {
+			profile, found := cfg.GetProfile(profileName)
+			if !found {
+				fmt.Printf("Profile %s not found in config\n", profileName)
+				return
+			}
+			profile.PrivateKey = ""
+			cfg.SetProfile(profile)
+		} else {
+			cfg.PrivateKey = ""
+		}
 
 		if err = cfg.SaveConfig(); err != nil {
 			fmt.Printf("Error saving updated config to system: %s\n", err.Error())
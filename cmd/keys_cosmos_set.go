@@ -31,7 +31,17 @@ var keysCosmosSet = &cobra.Command{
 			return
 		}
 
-		cfg.PrivateKey = args[0]
+		if profileName := config.SelectedProfile(); len(profileName) > 0 {
+			profile, found := cfg.GetProfile(profileName)
+			if !found {
+				fmt.Printf("Profile %s not found in config\n", profileName)
+				return
+			}
+			profile.PrivateKey = args[0]
+			cfg.SetProfile(profile)
+		} else {
+			cfg.PrivateKey = args[0]
+		}
 
 		if err = cfg.SaveConfig(); err != nil {
 			fmt.Printf("Error saving updated config to system: %s\n", err.Error())
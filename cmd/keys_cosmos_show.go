@@ -13,7 +13,7 @@ var keysCosmosShow = &cobra.Command{
 	Long:  `Show cosmos private key in config file`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		cfg, err := config.ReadConfigFromFile()
+		cfg, err := config.LoadConfig()
 		if err != nil {
 			fmt.Printf("Error loading config from file: %s\n", err.Error())
 			return
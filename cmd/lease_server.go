@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fairyringclient/internal/election"
+	"log"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+// leaseServerCmd represents the lease-server command
+var leaseServerCmd = &cobra.Command{
+	Use:   "lease-server",
+	Short: "Run an in-memory HA lease server",
+	Long: `Run an in-memory lease server implementing the lease over HTTP protocol used by the http HA backend,
+the lease is lost when the server restarts, use it for local setups and testing`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listen, _ := cmd.Flags().GetString("listen")
+		log.Printf("Lease server is listening on: %s\n", listen)
+		log.Fatal(http.ListenAndServe(listen, election.NewLeaseServer()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(leaseServerCmd)
+
+	leaseServerCmd.Flags().String("listen", ":7777", "Address the lease server listens on")
+}
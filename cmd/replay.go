@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fairyringclient/config"
+	"fairyringclient/internal/fairyringclient"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay [event log]",
+	Short: "Replay recorded events in dry run mode",
+	Long: `Replay the block & tx events recorded by 'start --record-events' through the client in dry run mode,
+key shares are derived & verified against the chain state of the node in config but never submitted`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		speed, _ := cmd.Flags().GetFloat64("speed")
+		if speed < 0 {
+			fmt.Println("Invalid speed, expected 0 or a positive number")
+			return
+		}
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Printf("Error loading config from file: %s\n", err.Error())
+			return
+		}
+		fairyringclient.ReplayEvents(*cfg, args[0], speed)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+
+	replayCmd.Flags().Float64("speed", 1, "Replay speed relative to the recorded intervals, 0 to replay without waiting")
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"fairyringclient/config"
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
 )
 
-var cfgFile string
+var (
+	cfgFile string
+	homeDir string
+	profile string
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -27,25 +32,21 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.fairyringclient/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is config.yml in the home directory)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "profile of the network to use (default is the active profile of the config)")
+	rootCmd.PersistentFlags().StringVar(&homeDir, "home", "", "directory of the config & client data (default is $"+config.HomeEnvVar+" or $HOME/.fairyringclient)")
 }
 
 func initConfig() {
-	home, err := os.UserHomeDir()
-	cobra.CheckErr(err)
+	config.SetHomeDir(homeDir)
+	config.SetConfigFile(cfgFile)
+	config.SelectProfile(profile)
 
-	// Search config in home directory with name ".fairyringclient" (without extension).
-	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		viper.AddConfigPath(home)
-		viper.AddConfigPath(".fairyringclient")
-		viper.SetConfigType("yaml")
-		viper.SetConfigName("config")
-	}
+	configPath, err := config.ConfigFilePath()
+	cobra.CheckErr(err)
 
-	viper.AutomaticEnv() // read in environment variables that match
+	viper.SetConfigFile(configPath)
+	viper.SetConfigType("yml")
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
@@ -13,15 +13,23 @@ var startCmd = &cobra.Command{
 	Short: "Start the client",
 	Long:  `Start the client`,
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := config.ReadConfigFromFile()
+		cfg, err := config.LoadConfig()
 		if err != nil {
 			fmt.Printf("Error loading config from file: %s\n", err.Error())
 			return
 		}
-		fairyringclient.StartFairyRingClient(*cfg)
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		recordEvents, _ := cmd.Flags().GetString("record-events")
+		fairyringclient.StartFairyRingClient(*cfg, fairyringclient.StartOptions{
+			DryRun:       dryRun,
+			RecordEvents: recordEvents,
+		})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(startCmd)
+
+	startCmd.Flags().Bool("dry-run", false, "Derive & verify key shares without submitting them or registering the account")
+	startCmd.Flags().String("record-events", "", "Record the received block & tx events to the JSONL file at the given path for replaying")
 }
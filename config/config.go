@@ -1,9 +1,12 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
 	"github.com/cometbft/cometbft/crypto"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"log"
 	"os"
 	"path/filepath"
@@ -12,10 +15,27 @@ import (
 
 const (
 	DefaultMetricsPort    = 2222
+	DefaultLogLevel       = "info"
 	DefaultPauseThreshold = 5
 	DefaultFolderName     = ".fairyringclient"
 	DefaultChainID        = "fairyring-testnet-3"
 	DefaultDenom          = "ufairy"
+	DefaultBech32Prefix   = "fairy"
+	DefaultValidatorName  = "default"
+
+	DefaultBalanceCheckInterval = 10
+	DefaultLowBalanceThreshold  = 1000000
+
+	DefaultNotifierFormat            = "json"
+	DefaultNotifierRateLimit         = 60
+	DefaultNotifierMaxRetries        = 3
+	DefaultSubmissionFailuresToAlert = 3
+
+	DefaultPrecomputeHeights = 5
+	DefaultPrecomputeWorkers = 2
+
+	DefaultHABackend     = "file"
+	DefaultHALeaseBlocks = 3
 )
 
 type Node struct {
@@ -23,76 +43,258 @@ type Node struct {
 	IP       string
 	Port     uint64
 	GRPCPort uint64
-	Denom    string
-	ChainID  string
+	// GRPCHost of the gRPC endpoint if it is served on another host than IP, empty to use IP
+	GRPCHost string
+	// GRPCTLS dials the gRPC endpoint over TLS, the public endpoints are only served over TLS
+	GRPCTLS bool
+	Denom   string
+	ChainID string
+	// GasPrice of the txs in the format of 0.1ufairy, empty to submit txs without fee
+	GasPrice string
+	// Bech32Prefix of the account addresses, the validator & consensus prefixes are derived from it. Empty to use fairy
+	Bech32Prefix string
+}
+
+type BalanceMonitor struct {
+	CheckInterval       uint64
+	LowBalanceThreshold uint64
+	PauseOptionalDuties bool
+}
+
+type Notifier struct {
+	WebhookURL                string
+	Format                    string
+	RateLimit                 uint64
+	MaxRetries                uint64
+	SubmissionFailuresToAlert uint64
+	InvalidShare              bool
+	ClientPaused              bool
+	ShareRotation             bool
+	ShareMissing              bool
+	LowBalance                bool
+	SubmissionFailures        bool
+}
+
+type Precompute struct {
+	Heights uint64
+	Workers uint64
+}
+
+// Validator is an additional validator account submitting key shares in the same process
+type Validator struct {
+	Name       string
+	PrivateKey string
+}
+
+// HA elects a single submitting replica between the clients sharing the same lock
+type HA struct {
+	Enabled bool
+	// Backend is either "file" for a lock file shared by the replicas on the same host or volume,
+	// or "http" for a lease server reachable by all replicas
+	Backend  string
+	LockPath string
+	LeaseURL string
+	// LeaseBlocks is the number of blocks without renewal before a standby takes over the lease
+	LeaseBlocks uint64
+	ReplicaID   string
 }
 
 type Config struct {
+	// ConfigVersion is the version of the config file schema, outdated config files are upgraded with `config migrate`
+	ConfigVersion              uint64
 	FairyRingNode              Node
 	PrivateKey                 string
-	TotalValidatorNum          uint64
-	MasterPrivateKey           string
+	Validators                 []Validator
 	InvalidSharePauseThreshold uint64
 	MetricsPort                uint64
+	LogLevel                   string
+	BalanceMonitor             BalanceMonitor
+	Notifier                   Notifier
+	Policies                   []Policy
+	Precompute                 Precompute
+	HA                         HA
+	Profiles                   []Profile
+	ActiveProfile              string
+}
+
+// HomeEnvVar overrides the default home directory, the --home flag takes precedence over it
+const HomeEnvVar = "FAIRYRINGCLIENT_HOME"
+
+const configFileName = "config.yml"
+
+var (
+	homeDirOverride    string
+	configFileOverride string
+)
+
+// SetHomeDir overrides the home directory, empty to use FAIRYRINGCLIENT_HOME or the default
+func SetHomeDir(dir string) {
+	homeDirOverride = dir
+}
+
+// SetConfigFile overrides the path of the config file, empty to use config.yml in the home directory
+func SetConfigFile(path string) {
+	configFileOverride = path
+}
+
+// HomeDir returns the directory where the config and client data are stored,
+// resolved from the --home flag, the FAIRYRINGCLIENT_HOME env var, then $HOME/.fairyringclient
+func HomeDir() (string, error) {
+	if len(homeDirOverride) > 0 {
+		return homeDirOverride, nil
+	}
+	if dir := os.Getenv(HomeEnvVar); len(dir) > 0 {
+		return dir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, DefaultFolderName), nil
+}
+
+// ConfigFilePath returns the path of the config file, from the --config flag or config.yml in the home directory
+func ConfigFilePath() (string, error) {
+	if len(configFileOverride) > 0 {
+		return configFileOverride, nil
+	}
+	homeDir, err := HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, configFileName), nil
 }
 
 func ReadConfigFromFile() (*Config, error) {
 	var cfg Config
-	homeDir, err := os.UserHomeDir()
+	configPath, err := ConfigFilePath()
 	if err != nil {
 		return nil, err
 	}
 
-	viper.SetConfigName("config")
-	viper.AddConfigPath(homeDir + "/" + DefaultFolderName)
+	viper.SetConfigFile(configPath)
 	viper.SetConfigType("yml")
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
 	}
 
-	err = viper.Unmarshal(&cfg)
+	settings := viper.AllSettings()
+	version, err := checkConfigVersion(settings)
 	if err != nil {
 		return nil, err
 	}
+	if version < CurrentConfigVersion {
+		return readOutdatedConfig(configPath, settings, version)
+	}
+
+	// Unknown keys are rejected instead of being ignored, so a typo is not silently replaced by the zero value
+	if err = viper.UnmarshalExact(&cfg); err != nil {
+		return nil, formatUnknownKeys(configPath, err)
+	}
 
 	return &cfg, nil
 }
 
+// LoadConfig reads the config file and applies the overrides, from the lowest to the highest precedence:
+// the profile selected with the --profile flag or the ActiveProfile, then the FAIRYRINGCLIENT_ env vars.
+// The config file is optional when the client is configured by env
+func LoadConfig() (*Config, error) {
+	configPath, err := ConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *Config
+	if _, err = os.Stat(configPath); os.IsNotExist(err) {
+		log.Printf("Config file not found at %s, using the default config with env overrides\n", configPath)
+		defaultCfg := DefaultConfig(false)
+		cfg = &defaultCfg
+	} else if cfg, err = ReadConfigFromFile(); err != nil {
+		return nil, err
+	}
+
+	// The env is applied before selecting the profile so the active profile can be set by env,
+	// and applied again after so it takes precedence over the profile
+	if err = cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+
+	name := profileOverride
+	if len(name) == 0 {
+		name = cfg.ActiveProfile
+	}
+	if err = cfg.ApplyProfile(name); err != nil {
+		return nil, err
+	}
+
+	if err = cfg.ApplyEnv(); err != nil {
+		return nil, err
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// GetValidators returns the validators run by the client, the validator of PrivateKey named "default"
+// followed by the additional validators in Validators
+func (c *Config) GetValidators() []Validator {
+	validators := make([]Validator, 0, len(c.Validators)+1)
+	if len(c.PrivateKey) > 0 {
+		validators = append(validators, Validator{Name: DefaultValidatorName, PrivateKey: c.PrivateKey})
+	}
+	return append(validators, c.Validators...)
+}
+
 func (c *Config) GetFairyRingNodeURI() string {
 	nodeURI := c.FairyRingNode.Protocol + "://" + c.FairyRingNode.IP + ":" + strconv.FormatUint(c.FairyRingNode.Port, 10)
 	return nodeURI
 }
 
 func (c *Config) GetGRPCEndpoint() string {
-	ep := c.FairyRingNode.IP + ":" + strconv.FormatUint(c.FairyRingNode.GRPCPort, 10)
+	host := c.FairyRingNode.IP
+	if len(c.FairyRingNode.GRPCHost) > 0 {
+		host = c.FairyRingNode.GRPCHost
+	}
+	ep := host + ":" + strconv.FormatUint(c.FairyRingNode.GRPCPort, 10)
 	return ep
 }
 
+// GetGRPCDialOptions returns the dial options of the gRPC endpoint, the TLS credentials if GRPCTLS is set
+func (c *Config) GetGRPCDialOptions() []grpc.DialOption {
+	if !c.FairyRingNode.GRPCTLS {
+		return nil
+	}
+	return []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))}
+}
+
 func (c *Config) SaveConfig() error {
 	updateConfig(*c)
 
 	if err := viper.WriteConfig(); err != nil {
-		fmt.Errorf("failed to write config as : %s", err.Error())
+		return fmt.Errorf("failed to write config as : %s", err.Error())
 	}
 
 	return nil
 }
 
 func (c *Config) ExportConfig() error {
-	homeDir, err := os.UserHomeDir()
+	filePath, err := ConfigFilePath()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if _, err := os.Stat(homeDir + "/" + DefaultFolderName); os.IsNotExist(err) {
-		err = os.MkdirAll(homeDir+"/"+DefaultFolderName, 0755)
+	configDir := filepath.Dir(filePath)
+	if _, err := os.Stat(configDir); os.IsNotExist(err) {
+		err = os.MkdirAll(configDir, 0755)
 		if err != nil {
 			return fmt.Errorf("failed to create directory: %v", err)
 		}
 	}
 
-	filePath := filepath.Join(homeDir+"/"+DefaultFolderName, "config.yml")
 	_, err = os.Stat(filePath)
 	if os.IsNotExist(err) {
 		// File does not exist, create it
@@ -108,14 +310,13 @@ func (c *Config) ExportConfig() error {
 		log.Printf("Config file already exists: %s\n", filePath)
 	}
 
-	viper.SetConfigName("config")
-	viper.AddConfigPath(homeDir + "/" + DefaultFolderName)
+	viper.SetConfigFile(filePath)
 	viper.SetConfigType("yml")
 
 	setInitialConfig(*c)
 
-	if err = viper.WriteConfigAs(homeDir + "/" + DefaultFolderName + "/config.yml"); err != nil {
-		fmt.Errorf("failed to write config as : %s", err.Error())
+	if err = viper.WriteConfigAs(filePath); err != nil {
+		return fmt.Errorf("failed to write config as : %s", err.Error())
 	}
 
 	return nil
@@ -129,46 +330,122 @@ func DefaultConfig(withCosmosKey bool) Config {
 	}
 
 	return Config{
+		ConfigVersion: CurrentConfigVersion,
 		FairyRingNode: Node{
-			Protocol: "http",
-			IP:       "127.0.0.1",
-			Port:     26657,
-			GRPCPort: 9090,
-			Denom:    DefaultDenom,
-			ChainID:  DefaultChainID,
+			Protocol:     "http",
+			IP:           "127.0.0.1",
+			Port:         26657,
+			GRPCPort:     9090,
+			Denom:        DefaultDenom,
+			ChainID:      DefaultChainID,
+			GasPrice:     "",
+			Bech32Prefix: DefaultBech32Prefix,
 		},
 		PrivateKey:                 privateKey,
-		TotalValidatorNum:          0,
-		MasterPrivateKey:           "",
+		Validators:                 []Validator{},
 		InvalidSharePauseThreshold: DefaultPauseThreshold,
 		MetricsPort:                DefaultMetricsPort,
+		LogLevel:                   DefaultLogLevel,
+		BalanceMonitor: BalanceMonitor{
+			CheckInterval:       DefaultBalanceCheckInterval,
+			LowBalanceThreshold: DefaultLowBalanceThreshold,
+			PauseOptionalDuties: false,
+		},
+		Notifier: Notifier{
+			WebhookURL:                "",
+			Format:                    DefaultNotifierFormat,
+			RateLimit:                 DefaultNotifierRateLimit,
+			MaxRetries:                DefaultNotifierMaxRetries,
+			SubmissionFailuresToAlert: DefaultSubmissionFailuresToAlert,
+			InvalidShare:              true,
+			ClientPaused:              true,
+			ShareRotation:             true,
+			ShareMissing:              true,
+			LowBalance:                true,
+			SubmissionFailures:        true,
+		},
+		Policies: []Policy{},
+		Precompute: Precompute{
+			Heights: DefaultPrecomputeHeights,
+			Workers: DefaultPrecomputeWorkers,
+		},
+		HA: HA{
+			Enabled:     false,
+			Backend:     DefaultHABackend,
+			LockPath:    "",
+			LeaseURL:    "",
+			LeaseBlocks: DefaultHALeaseBlocks,
+			ReplicaID:   "",
+		},
+		Profiles:      []Profile{},
+		ActiveProfile: "",
 	}
 }
 
-func updateConfig(c Config) {
-	viper.Set("FairyRingNode.ip", c.FairyRingNode.IP)
-	viper.Set("FairyRingNode.port", c.FairyRingNode.Port)
-	viper.Set("FairyRingNode.protocol", c.FairyRingNode.Protocol)
-	viper.Set("FairyRingNode.grpcPort", c.FairyRingNode.GRPCPort)
-	viper.Set("FairyRingNode.denom", c.FairyRingNode.Denom)
-	viper.Set("FairyRingNode.chainID", c.FairyRingNode.ChainID)
+// configSettings returns the keys written to the config file with their values
+func configSettings(c Config) map[string]interface{} {
+	return map[string]interface{}{
+		"ConfigVersion": c.ConfigVersion,
 
-	viper.Set("PrivateKey", c.PrivateKey)
+		"FairyRingNode.ip":           c.FairyRingNode.IP,
+		"FairyRingNode.port":         c.FairyRingNode.Port,
+		"FairyRingNode.protocol":     c.FairyRingNode.Protocol,
+		"FairyRingNode.grpcPort":     c.FairyRingNode.GRPCPort,
+		"FairyRingNode.grpcHost":     c.FairyRingNode.GRPCHost,
+		"FairyRingNode.grpcTLS":      c.FairyRingNode.GRPCTLS,
+		"FairyRingNode.denom":        c.FairyRingNode.Denom,
+		"FairyRingNode.chainID":      c.FairyRingNode.ChainID,
+		"FairyRingNode.gasPrice":     c.FairyRingNode.GasPrice,
+		"FairyRingNode.bech32Prefix": c.FairyRingNode.Bech32Prefix,
 
-	viper.Set("InvalidSharePauseThreshold", c.InvalidSharePauseThreshold)
-	viper.Set("MetricsPort", c.MetricsPort)
-}
+		"PrivateKey": c.PrivateKey,
+		"Validators": c.Validators,
 
-func setInitialConfig(c Config) {
-	viper.SetDefault("FairyRingNode.ip", c.FairyRingNode.IP)
-	viper.SetDefault("FairyRingNode.port", c.FairyRingNode.Port)
-	viper.SetDefault("FairyRingNode.protocol", c.FairyRingNode.Protocol)
-	viper.SetDefault("FairyRingNode.grpcPort", c.FairyRingNode.GRPCPort)
-	viper.SetDefault("FairyRingNode.denom", c.FairyRingNode.Denom)
-	viper.SetDefault("FairyRingNode.chainID", c.FairyRingNode.ChainID)
+		"InvalidSharePauseThreshold": c.InvalidSharePauseThreshold,
+		"MetricsPort":                c.MetricsPort,
+		"LogLevel":                   c.LogLevel,
+
+		"BalanceMonitor.checkInterval":       c.BalanceMonitor.CheckInterval,
+		"BalanceMonitor.lowBalanceThreshold": c.BalanceMonitor.LowBalanceThreshold,
+		"BalanceMonitor.pauseOptionalDuties": c.BalanceMonitor.PauseOptionalDuties,
+
+		"Notifier.webhookURL":                c.Notifier.WebhookURL,
+		"Notifier.format":                    c.Notifier.Format,
+		"Notifier.rateLimit":                 c.Notifier.RateLimit,
+		"Notifier.maxRetries":                c.Notifier.MaxRetries,
+		"Notifier.submissionFailuresToAlert": c.Notifier.SubmissionFailuresToAlert,
+		"Notifier.invalidShare":              c.Notifier.InvalidShare,
+		"Notifier.clientPaused":              c.Notifier.ClientPaused,
+		"Notifier.shareRotation":             c.Notifier.ShareRotation,
+		"Notifier.shareMissing":              c.Notifier.ShareMissing,
+		"Notifier.lowBalance":                c.Notifier.LowBalance,
+		"Notifier.submissionFailures":        c.Notifier.SubmissionFailures,
 
-	viper.SetDefault("PrivateKey", c.PrivateKey)
+		"Policies": c.Policies,
 
-	viper.SetDefault("InvalidSharePauseThreshold", c.InvalidSharePauseThreshold)
-	viper.SetDefault("MetricsPort", c.MetricsPort)
+		"Precompute.heights": c.Precompute.Heights,
+		"Precompute.workers": c.Precompute.Workers,
+
+		"HA.enabled":     c.HA.Enabled,
+		"HA.backend":     c.HA.Backend,
+		"HA.lockPath":    c.HA.LockPath,
+		"HA.leaseURL":    c.HA.LeaseURL,
+		"HA.leaseBlocks": c.HA.LeaseBlocks,
+		"HA.replicaID":   c.HA.ReplicaID,
+
+		"Profiles":      c.Profiles,
+		"ActiveProfile": c.ActiveProfile,
+	}
+}
+
+func updateConfig(c Config) {
+	for key, value := range configSettings(c) {
+		viper.Set(key, value)
+	}
+}
+
+func setInitialConfig(c Config) {
+	for key, value := range configSettings(c) {
+		viper.SetDefault(key, value)
+	}
 }
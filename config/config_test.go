@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestConfigWriteErrors(t *testing.T) {
+	defer viper.Reset()
+
+	// The config path is a directory, so the config can not be written to it
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	SetConfigFile(path)
+	defer SetConfigFile("")
+
+	cfg := DefaultConfig(false)
+	if err := cfg.ExportConfig(); err == nil {
+		t.Fatal("expected error exporting the config to a directory")
+	}
+	if err := cfg.SaveConfig(); err == nil {
+		t.Fatal("expected error saving the config to a directory")
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is the prefix of the env vars overriding the config fields, such as FAIRYRINGCLIENT_PRIVATE_KEY.
+// Every env var has a _FILE variant reading the value from a file, such as FAIRYRINGCLIENT_PRIVATE_KEY_FILE
+const EnvPrefix = "FAIRYRINGCLIENT_"
+
+// EnvFileSuffix is the suffix of the env vars reading the value from a file, for secrets mounted as files
+const EnvFileSuffix = "_FILE"
+
+// EnvVar is a config field that can be overridden by env
+type EnvVar struct {
+	Name  string
+	Field string
+}
+
+// EnvVars returns the env vars of every config field, in the order of the fields
+func EnvVars() []EnvVar {
+	var vars []EnvVar
+	walkFields(reflect.ValueOf(&Config{}).Elem(), nil, func(path []string, _ reflect.Value) {
+		vars = append(vars, EnvVar{Name: EnvPrefix + envName(path), Field: strings.Join(path, ".")})
+	})
+	return vars
+}
+
+// ApplyEnv overrides the config fields with the env vars set, the value of NAME_FILE is read from the file
+// when NAME is not set. Slices such as Validators are set in JSON
+func (c *Config) ApplyEnv() error {
+	var err error
+	walkFields(reflect.ValueOf(c).Elem(), nil, func(path []string, field reflect.Value) {
+		if err != nil {
+			return
+		}
+
+		name := EnvPrefix + envName(path)
+		value, found, lookupErr := lookupEnv(name)
+		if lookupErr != nil {
+			err = lookupErr
+			return
+		}
+		if !found {
+			return
+		}
+
+		if setErr := setField(field, value); setErr != nil {
+			err = fmt.Errorf("invalid value of %s: %v", name, setErr)
+		}
+	})
+	return err
+}
+
+// walkFields calls fn with the path of every field that is not a struct, nested structs are walked
+func walkFields(v reflect.Value, path []string, fn func(path []string, field reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+
+		fieldPath := append(append([]string{}, path...), t.Field(i).Name)
+		if v.Field(i).Kind() == reflect.Struct {
+			walkFields(v.Field(i), fieldPath, fn)
+			continue
+		}
+		fn(fieldPath, v.Field(i))
+	}
+}
+
+func lookupEnv(name string) (string, bool, error) {
+	if value, found := os.LookupEnv(name); found {
+		return value, true, nil
+	}
+
+	path, found := os.LookupEnv(name + EnvFileSuffix)
+	if !found {
+		return "", false, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %v", name+EnvFileSuffix, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Slice:
+		parsed := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(value), parsed.Interface()); err != nil {
+			return err
+		}
+		field.Set(parsed.Elem())
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// envName converts the path of the field to the env var name without prefix, such as FAIRY_RING_NODE_GRPC_PORT
+func envName(path []string) string {
+	parts := make([]string, len(path))
+	for i, name := range path {
+		parts[i] = toScreamingSnake(name)
+	}
+	return strings.Join(parts, "_")
+}
+
+func toScreamingSnake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestEnvName(t *testing.T) {
+	for path, expected := range map[string]string{
+		"FairyRingNode.GRPCPort": "FAIRY_RING_NODE_GRPC_PORT",
+		"FairyRingNode.ChainID":  "FAIRY_RING_NODE_CHAIN_ID",
+		"Notifier.WebhookURL":    "NOTIFIER_WEBHOOK_URL",
+		"PrivateKey":             "PRIVATE_KEY",
+		"HA.LeaseBlocks":         "HA_LEASE_BLOCKS",
+	} {
+		found := false
+		for _, v := range EnvVars() {
+			if v.Field == path {
+				found = true
+				if v.Name != EnvPrefix+expected {
+					t.Fatalf("expected env %s for %s, got: %s", EnvPrefix+expected, path, v.Name)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("expected env var for %s", path)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "private-key")
+	if err := os.WriteFile(keyFile, []byte("secret-key\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	t.Setenv(EnvPrefix+"PRIVATE_KEY_FILE", keyFile)
+	t.Setenv(EnvPrefix+"FAIRY_RING_NODE_IP", "10.0.0.1")
+	t.Setenv(EnvPrefix+"FAIRY_RING_NODE_GRPC_PORT", "9191")
+	t.Setenv(EnvPrefix+"HA_ENABLED", "true")
+	t.Setenv(EnvPrefix+"VALIDATORS", `[{"name": "second", "privateKey": "second-key"}]`)
+
+	cfg := DefaultConfig(false)
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if cfg.PrivateKey != "secret-key" {
+		t.Fatalf("expected private key read from file, got: %s", cfg.PrivateKey)
+	}
+	if cfg.FairyRingNode.IP != "10.0.0.1" || cfg.FairyRingNode.GRPCPort != 9191 || !cfg.HA.Enabled {
+		t.Fatalf("expected node & HA overridden by env, got: %+v, %+v", cfg.FairyRingNode, cfg.HA)
+	}
+	if len(cfg.Validators) != 1 || cfg.Validators[0].Name != "second" || cfg.Validators[0].PrivateKey != "second-key" {
+		t.Fatalf("expected validators overridden by env, got: %+v", cfg.Validators)
+	}
+
+	// The value takes precedence over the file
+	t.Setenv(EnvPrefix+"PRIVATE_KEY", "env-key")
+	if err := cfg.ApplyEnv(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if cfg.PrivateKey != "env-key" {
+		t.Fatalf("expected private key from env, got: %s", cfg.PrivateKey)
+	}
+
+	t.Setenv(EnvPrefix+"METRICS_PORT", "not-a-port")
+	if err := cfg.ApplyEnv(); err == nil {
+		t.Fatal("expected error for invalid metrics port")
+	}
+}
+
+func TestLoadConfigEnvOverProfile(t *testing.T) {
+	SetConfigFile(filepath.Join(t.TempDir(), "config.yml"))
+	defer SetConfigFile("")
+	defer viper.Reset()
+
+	saved := DefaultConfig(false)
+	local := saved.FairyRingNode
+	local.ChainID = "local-1"
+	saved.SetProfile(Profile{Name: "local", FairyRingNode: local})
+	if err := saved.ExportConfig(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	// ExportConfig sets the values on the global viper, so they would override the config file read
+	viper.Reset()
+
+	t.Setenv(EnvPrefix+"ACTIVE_PROFILE", "local")
+	t.Setenv(EnvPrefix+"FAIRY_RING_NODE_IP", "10.0.0.1")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if cfg.FairyRingNode.ChainID != "local-1" {
+		t.Fatalf("expected local profile selected by env, got: %+v", cfg.FairyRingNode)
+	}
+	if cfg.FairyRingNode.IP != "10.0.0.1" {
+		t.Fatalf("expected env to take precedence over profile, got: %+v", cfg.FairyRingNode)
+	}
+}
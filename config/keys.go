@@ -0,0 +1,68 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Keys returns the dotted keys of every config field that can be read & set, such as FairyRingNode.GRPCPort
+func Keys() []string {
+	var keys []string
+	walkFields(reflect.ValueOf(&Config{}).Elem(), nil, func(path []string, _ reflect.Value) {
+		keys = append(keys, strings.Join(path, "."))
+	})
+	return keys
+}
+
+// findField returns the field of the dotted key, keys are case-insensitive so the keys of the config file can be used
+func (c *Config) findField(key string) (string, reflect.Value, error) {
+	var (
+		name  string
+		found reflect.Value
+	)
+	walkFields(reflect.ValueOf(c).Elem(), nil, func(path []string, field reflect.Value) {
+		if joined := strings.Join(path, "."); strings.EqualFold(joined, key) {
+			name, found = joined, field
+		}
+	})
+	if !found.IsValid() {
+		return "", reflect.Value{}, fmt.Errorf("unknown config key %s", key)
+	}
+	return name, found, nil
+}
+
+// Get returns the value of the dotted key, slices such as Validators are returned in JSON
+func (c *Config) Get(key string) (string, error) {
+	_, field, err := c.findField(key)
+	if err != nil {
+		return "", err
+	}
+
+	if field.Kind() == reflect.Slice {
+		value, err := json.Marshal(field.Interface())
+		if err != nil {
+			return "", err
+		}
+		return string(value), nil
+	}
+	return fmt.Sprintf("%v", field.Interface()), nil
+}
+
+// Set parses the value to the type of the dotted key and sets it, slices such as Validators are set in JSON.
+// The config is not validated, call Validate before saving it
+func (c *Config) Set(key string, value string) error {
+	name, field, err := c.findField(key)
+	if err != nil {
+		return err
+	}
+	if name == "ConfigVersion" {
+		return fmt.Errorf("ConfigVersion is managed by `fairyringclient config migrate`")
+	}
+
+	if err = setField(field, value); err != nil {
+		return fmt.Errorf("invalid value of %s (%s): %v", name, field.Type(), err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetAndGet(t *testing.T) {
+	cfg := DefaultConfig(false)
+
+	if err := cfg.Set("fairyringnode.grpcport", "9191"); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if cfg.FairyRingNode.GRPCPort != 9191 {
+		t.Fatalf("expected grpc port set to 9191, got: %d", cfg.FairyRingNode.GRPCPort)
+	}
+	if value, err := cfg.Get("FairyRingNode.GRPCPort"); err != nil || value != "9191" {
+		t.Fatalf("expected 9191, got: %s, %v", value, err)
+	}
+
+	if err := cfg.Set("HA.Enabled", "true"); err != nil || !cfg.HA.Enabled {
+		t.Fatalf("expected HA enabled, got: %v", err)
+	}
+
+	if err := cfg.Set("Validators", `[{"Name":"val1","PrivateKey":"`+strings.Repeat("a", 64)+`"}]`); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if value, err := cfg.Get("validators"); err != nil || !strings.Contains(value, `"Name":"val1"`) {
+		t.Fatalf("expected validators in JSON, got: %s, %v", value, err)
+	}
+}
+
+func TestSetRejectsInvalidValues(t *testing.T) {
+	cfg := DefaultConfig(false)
+
+	if err := cfg.Set("MetricsPort", "abc"); err == nil || !strings.Contains(err.Error(), "MetricsPort") {
+		t.Fatalf("expected invalid number rejected, got: %v", err)
+	}
+	if cfg.MetricsPort != DefaultMetricsPort {
+		t.Fatalf("expected metrics port unchanged, got: %d", cfg.MetricsPort)
+	}
+
+	if err := cfg.Set("MetricsPrt", "2223"); err == nil || !strings.Contains(err.Error(), "unknown config key") {
+		t.Fatalf("expected unknown key rejected, got: %v", err)
+	}
+	if _, err := cfg.Get("FairyRingNode"); err == nil {
+		t.Fatal("expected the key of a struct rejected, only fields can be read")
+	}
+	if err := cfg.Set("ConfigVersion", "3"); err == nil {
+		t.Fatal("expected ConfigVersion rejected")
+	}
+}
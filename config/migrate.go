@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// CurrentConfigVersion is the version of the config written by this client, config files without ConfigVersion are version 1
+const CurrentConfigVersion = 2
+
+const configVersionKey = "configversion"
+
+// migration upgrades the settings of the config file from the previous version to version
+type migration struct {
+	version     uint64
+	description string
+	migrate     func(settings map[string]interface{})
+}
+
+// migrations are applied in order, a config file is upgraded step by step from its version to CurrentConfigVersion
+var migrations = []migration{
+	{
+		version:     2,
+		description: "remove the unused TotalValidatorNum & MasterPrivateKey",
+		migrate: func(settings map[string]interface{}) {
+			delete(settings, "totalvalidatornum")
+			delete(settings, "masterprivatekey")
+		},
+	},
+}
+
+// MigrationResult describes the upgrade of a config file
+type MigrationResult struct {
+	From       uint64
+	To         uint64
+	BackupPath string
+	Applied    []string
+}
+
+// configFileVersion returns the version of the settings read from a config file
+func configFileVersion(settings map[string]interface{}) (uint64, error) {
+	raw, found := settings[configVersionKey]
+	if !found {
+		return 1, nil
+	}
+
+	switch version := raw.(type) {
+	case int:
+		if version > 0 {
+			return uint64(version), nil
+		}
+	case uint64:
+		if version > 0 {
+			return version, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid ConfigVersion: %v", raw)
+}
+
+// MigrateConfigFile upgrades the config file at path to CurrentConfigVersion, the original file is backed up next to it.
+// Nothing is written if the config file is up to date
+func MigrateConfigFile(path string) (*MigrationResult, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	settings := v.AllSettings()
+	from, err := configFileVersion(settings)
+	if err != nil {
+		return nil, err
+	}
+	if from > CurrentConfigVersion {
+		return nil, fmt.Errorf("config version %d is newer than the version %d supported by this client, upgrade the client", from, CurrentConfigVersion)
+	}
+
+	result := &MigrationResult{From: from, To: from}
+	if from == CurrentConfigVersion {
+		return result, nil
+	}
+
+	result.Applied = migrateSettings(settings, from)
+	result.To = CurrentConfigVersion
+
+	// Fields added since the config file was created are set to the default values
+	cfg, err := decodeSettings(settings, DefaultConfig(false))
+	if err != nil {
+		return nil, formatUnknownKeys(path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result.BackupPath = fmt.Sprintf("%s.v%d-%s.bak", path, from, time.Now().UTC().Format("20060102T150405Z"))
+	if err = os.WriteFile(result.BackupPath, original, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to back up config: %v", err)
+	}
+
+	// The migrated config is written from the typed config, the same way as SaveConfig
+	migrated := viper.New()
+	migrated.SetConfigType("yml")
+	for key, value := range configSettings(cfg) {
+		migrated.Set(key, value)
+	}
+	if err = migrated.WriteConfigAs(path); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config, the original is backed up at %s: %v", result.BackupPath, err)
+	}
+	if err = os.Chmod(path, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// migrateSettings upgrades the settings of a config file of version from to CurrentConfigVersion in place,
+// it returns the descriptions of the migrations applied
+func migrateSettings(settings map[string]interface{}, from uint64) []string {
+	var applied []string
+	for _, m := range migrations {
+		if m.version <= from {
+			continue
+		}
+		m.migrate(settings)
+		settings[configVersionKey] = m.version
+		applied = append(applied, fmt.Sprintf("v%d: %s", m.version, m.description))
+	}
+	return applied
+}
+
+// decodeSettings decodes the settings over the defaults, unknown keys are rejected
+func decodeSettings(settings map[string]interface{}, defaults Config) (Config, error) {
+	v := viper.New()
+	for key, value := range configSettings(defaults) {
+		v.SetDefault(key, value)
+	}
+	if err := v.MergeConfigMap(settings); err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	if err := v.UnmarshalExact(&cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// checkConfigVersion returns the version of the config file read, or an error if the config file is newer than the client
+func checkConfigVersion(settings map[string]interface{}) (uint64, error) {
+	version, err := configFileVersion(settings)
+	if err != nil {
+		return 0, err
+	}
+	if version > CurrentConfigVersion {
+		return 0, fmt.Errorf("config version %d is newer than the version %d supported by this client, upgrade the client", version, CurrentConfigVersion)
+	}
+	return version, nil
+}
+
+// formatUnknownKeys makes the error of unknown keys from viper.UnmarshalExact readable
+func formatUnknownKeys(path string, err error) error {
+	return fmt.Errorf("invalid config file %s: %s", path, strings.ReplaceAll(err.Error(), "\n", " "))
+}
+
+// readOutdatedConfig migrates the settings of an outdated config file in memory, the config file is left as is.
+// The settings in use are replaced by the migrated ones, so saving the config writes the current version
+func readOutdatedConfig(path string, settings map[string]interface{}, version uint64) (*Config, error) {
+	applied := migrateSettings(settings, version)
+	log.Printf("WARNING: config version %d is outdated, migrated to version %d in memory (%s), run `fairyringclient config migrate` to upgrade the config file\n",
+		version, CurrentConfigVersion, strings.Join(applied, ", "))
+
+	// Fields added since the config file was created are set to the default values, the same way as MigrateConfigFile
+	defaults := DefaultConfig(false)
+	cfg, err := decodeSettings(settings, defaults)
+	if err != nil {
+		return nil, formatUnknownKeys(path, err)
+	}
+
+	viper.Reset()
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yml")
+	for key, value := range configSettings(defaults) {
+		viper.SetDefault(key, value)
+	}
+	if err = viper.MergeConfigMap(settings); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const legacyConfig = `fairyringnode:
+    chainid: fairyring-testnet-3
+    denom: ufairy
+    grpcport: 9090
+    ip: 192.168.1.100
+    port: 26657
+    protocol: http
+invalidsharepausethreshold: 7
+masterprivatekey: ""
+metricsport: 2222
+privatekey: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+totalvalidatornum: 0
+`
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	SetConfigFile(path)
+	t.Cleanup(func() {
+		SetConfigFile("")
+		// SaveConfig sets the values on the global viper, so they would override the next config files read
+		viper.Reset()
+	})
+	return path
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	path := writeConfigFile(t, legacyConfig)
+
+	// An outdated config is migrated in memory, the config file is left as is
+	cfg, err := ReadConfigFromFile()
+	if err != nil {
+		t.Fatalf("unexpected error reading outdated config: %s", err.Error())
+	}
+	if cfg.ConfigVersion != CurrentConfigVersion || cfg.InvalidSharePauseThreshold != 7 || cfg.FairyRingNode.IP != "192.168.1.100" {
+		t.Fatalf("expected outdated config migrated in memory, got: %+v", cfg)
+	}
+	if content, _ := os.ReadFile(path); string(content) != legacyConfig {
+		t.Fatalf("expected config file unchanged, got: %s", string(content))
+	}
+
+	result, err := MigrateConfigFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if result.From != 1 || result.To != CurrentConfigVersion || len(result.Applied) != 1 {
+		t.Fatalf("unexpected migration result: %+v", result)
+	}
+
+	backup, err := os.ReadFile(result.BackupPath)
+	if err != nil || string(backup) != legacyConfig {
+		t.Fatalf("expected original config backed up, got: %s, %v", string(backup), err)
+	}
+
+	cfg, err = ReadConfigFromFile()
+	if err != nil {
+		t.Fatalf("unexpected error reading migrated config: %s", err.Error())
+	}
+	if cfg.ConfigVersion != CurrentConfigVersion || cfg.InvalidSharePauseThreshold != 7 || cfg.FairyRingNode.IP != "192.168.1.100" {
+		t.Fatalf("expected settings kept by the migration, got: %+v", cfg)
+	}
+	if cfg.Precompute.Workers != DefaultPrecomputeWorkers || cfg.HA.LeaseBlocks != DefaultHALeaseBlocks {
+		t.Fatalf("expected fields added since the legacy config set to defaults, got: %+v, %+v", cfg.Precompute, cfg.HA)
+	}
+
+	if result, err = MigrateConfigFile(path); err != nil || result.From != result.To || len(result.BackupPath) != 0 {
+		t.Fatalf("expected up to date config not migrated again, got: %+v, %v", result, err)
+	}
+}
+
+func TestOutdatedConfigMatchesMigrated(t *testing.T) {
+	path := writeConfigFile(t, legacyConfig)
+
+	inMemory, err := ReadConfigFromFile()
+	if err != nil {
+		t.Fatalf("unexpected error reading outdated config: %s", err.Error())
+	}
+	if _, err = MigrateConfigFile(path); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	migrated, err := ReadConfigFromFile()
+	if err != nil {
+		t.Fatalf("unexpected error reading migrated config: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(inMemory, migrated) {
+		t.Fatalf("expected the config migrated in memory to match the migrated config file\nin memory: %+v\nmigrated:  %+v", inMemory, migrated)
+	}
+}
+
+func TestSaveOutdatedConfig(t *testing.T) {
+	path := writeConfigFile(t, legacyConfig)
+
+	cfg, err := ReadConfigFromFile()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if err = cfg.SaveConfig(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if strings.Contains(string(content), "masterprivatekey") {
+		t.Fatalf("expected the legacy keys not saved, got: %s", string(content))
+	}
+	if cfg, err = ReadConfigFromFile(); err != nil || cfg.ConfigVersion != CurrentConfigVersion || cfg.FairyRingNode.IP != "192.168.1.100" {
+		t.Fatalf("expected saved config at the current version, got: %+v, %v", cfg, err)
+	}
+}
+
+func TestReadConfigRejectsUnknownKeys(t *testing.T) {
+	writeConfigFile(t, "configversion: 2\nmetricsprot: 2222\n")
+	if _, err := ReadConfigFromFile(); err == nil || !strings.Contains(err.Error(), "metricsprot") {
+		t.Fatalf("expected unknown key rejected, got: %v", err)
+	}
+
+	writeConfigFile(t, "configversion: 3\n")
+	if _, err := ReadConfigFromFile(); err == nil || !strings.Contains(err.Error(), "upgrade the client") {
+		t.Fatalf("expected newer config rejected, got: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := DefaultConfig(true)
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected default config valid, got: %s", err.Error())
+	}
+
+	cfg.FairyRingNode.Protocol = "tcp"
+	cfg.FairyRingNode.GasPrice = "cheap"
+	cfg.Validators = []Validator{{Name: DefaultValidatorName, PrivateKey: "short"}}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected invalid config")
+	}
+	for _, expected := range []string{"protocol", "gasPrice", "used more than once", "64 hex characters"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error to contain %q, got: %s", expected, err.Error())
+		}
+	}
+}
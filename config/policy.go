@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conditions of the policies, evaluated for each validator on each block
+const (
+	// PolicyInvalidSharesInARow holds when the number of invalid keyshares submitted in a row reaches Threshold
+	PolicyInvalidSharesInARow = "invalid_shares_in_a_row"
+	// PolicyInvalidSharesInWindow holds when the number of invalid keyshares submitted in the last Window blocks reaches Threshold
+	PolicyInvalidSharesInWindow = "invalid_shares_in_window"
+	// PolicyFailedTxRatio holds when the ratio of failed txs over the txs submitted in the last Window blocks reaches Threshold, between 0 and 1
+	PolicyFailedTxRatio = "failed_tx_ratio"
+	// PolicyBalanceBelow holds when the last balance checked is below Threshold, in the denom of the node
+	PolicyBalanceBelow = "balance_below"
+	// PolicyNoNewBlock holds when no new block is received for Threshold seconds
+	PolicyNoNewBlock = "no_new_block"
+	// PolicyShareVerificationFailed holds when the share or the keyshares derived from it failed verification Threshold times in a row
+	PolicyShareVerificationFailed = "share_verification_failed"
+)
+
+// Actions of the policies, run when the condition starts to hold. The pauses are lifted once the condition no longer holds
+const (
+	PolicyActionPauseKeyshares      = "pause_keyshares"
+	PolicyActionPauseOptionalDuties = "pause_optional_duties"
+	PolicyActionNotify              = "notify"
+	PolicyActionExit                = "exit"
+)
+
+// Names of the built-in policies, the policies of the config cannot use them
+const (
+	PolicyInvalidSharePauseThreshold    = "invalid-share-pause-threshold"
+	PolicyLowBalancePauseOptionalDuties = "low-balance-pause-optional-duties"
+)
+
+var (
+	builtInPolicyNames = []string{PolicyInvalidSharePauseThreshold, PolicyLowBalancePauseOptionalDuties}
+	policyConditions   = []string{PolicyInvalidSharesInARow, PolicyInvalidSharesInWindow, PolicyFailedTxRatio, PolicyBalanceBelow, PolicyNoNewBlock, PolicyShareVerificationFailed}
+	policyActions      = []string{PolicyActionPauseKeyshares, PolicyActionPauseOptionalDuties, PolicyActionNotify, PolicyActionExit}
+)
+
+// Policy is a rule running the actions when the condition reaches the threshold
+type Policy struct {
+	Name      string
+	Condition string
+	Threshold float64
+	// Window is the number of blocks invalid_shares_in_window & failed_tx_ratio are counted over
+	Window  uint64
+	Actions []string
+}
+
+// HasAction returns true if the action is run when the policy is triggered
+func (p Policy) HasAction(action string) bool {
+	return contains(p.Actions, action)
+}
+
+// PolicyRules returns the policies of the config preceded by the built-in ones of InvalidSharePauseThreshold
+// & BalanceMonitor.PauseOptionalDuties, so the existing settings keep working as policies
+func (c *Config) PolicyRules() []Policy {
+	threshold := c.InvalidSharePauseThreshold
+	if threshold == 0 {
+		threshold = 1
+	}
+
+	rules := []Policy{{
+		Name:      PolicyInvalidSharePauseThreshold,
+		Condition: PolicyInvalidSharesInARow,
+		Threshold: float64(threshold),
+		Actions:   []string{PolicyActionPauseKeyshares},
+	}}
+
+	if c.BalanceMonitor.PauseOptionalDuties && c.BalanceMonitor.LowBalanceThreshold > 0 {
+		rules = append(rules, Policy{
+			Name:      PolicyLowBalancePauseOptionalDuties,
+			Condition: PolicyBalanceBelow,
+			Threshold: float64(c.BalanceMonitor.LowBalanceThreshold),
+			Actions:   []string{PolicyActionPauseOptionalDuties},
+		})
+	}
+
+	return append(rules, c.Policies...)
+}
+
+// validatePolicies returns the problems of the policies of the config
+func (c *Config) validatePolicies() []string {
+	var problems []string
+	names := make(map[string]bool)
+	for i, p := range c.Policies {
+		name := p.Name
+		if len(name) == 0 {
+			problems = append(problems, fmt.Sprintf("name of policy %d is empty", i))
+			name = fmt.Sprintf("%d", i)
+		} else if names[name] {
+			problems = append(problems, fmt.Sprintf("policy name %q is used more than once", name))
+		} else if contains(builtInPolicyNames, name) {
+			problems = append(problems, fmt.Sprintf("policy name %q is reserved for a built-in policy", name))
+		}
+		names[name] = true
+
+		if !contains(policyConditions, p.Condition) {
+			problems = append(problems, fmt.Sprintf("condition of policy %q must be one of %s, got: %q", name, strings.Join(policyConditions, ", "), p.Condition))
+		}
+		if p.Threshold <= 0 {
+			problems = append(problems, fmt.Sprintf("threshold of policy %q must be greater than 0", name))
+		}
+		if p.Condition == PolicyFailedTxRatio && p.Threshold > 1 {
+			problems = append(problems, fmt.Sprintf("threshold of policy %q is a ratio, it must be between 0 and 1, got: %v", name, p.Threshold))
+		}
+		if (p.Condition == PolicyInvalidSharesInWindow || p.Condition == PolicyFailedTxRatio) && p.Window == 0 {
+			problems = append(problems, fmt.Sprintf("window of policy %q must be greater than 0", name))
+		}
+
+		if len(p.Actions) == 0 {
+			problems = append(problems, fmt.Sprintf("policy %q has no action", name))
+		}
+		for _, action := range p.Actions {
+			if !contains(policyActions, action) {
+				problems = append(problems, fmt.Sprintf("action of policy %q must be one of %s, got: %q", name, strings.Join(policyActions, ", "), action))
+			}
+		}
+	}
+	return problems
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
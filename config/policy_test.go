@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyRules(t *testing.T) {
+	cfg := DefaultConfig(false)
+	cfg.Policies = []Policy{{Name: "halt", Condition: PolicyNoNewBlock, Threshold: 60, Actions: []string{PolicyActionExit}}}
+
+	rules := cfg.PolicyRules()
+	if len(rules) != 2 || rules[0].Threshold != DefaultPauseThreshold || rules[1].Name != "halt" {
+		t.Fatalf("expected the built-in invalid share policy followed by the config ones, got: %+v", rules)
+	}
+
+	cfg.BalanceMonitor.PauseOptionalDuties = true
+	if rules = cfg.PolicyRules(); len(rules) != 3 || rules[1].Condition != PolicyBalanceBelow {
+		t.Fatalf("expected the built-in low balance policy, got: %+v", rules)
+	}
+}
+
+func TestValidatePolicies(t *testing.T) {
+	cfg := DefaultConfig(false)
+	cfg.Policies = []Policy{
+		{Name: "ratio", Condition: PolicyFailedTxRatio, Threshold: 2, Actions: []string{PolicyActionNotify}},
+		{Name: "ratio", Condition: "unknown", Threshold: 1, Actions: []string{"reboot"}},
+		{Name: PolicyLowBalancePauseOptionalDuties, Condition: PolicyBalanceBelow, Threshold: 1, Actions: []string{PolicyActionNotify}},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected invalid policies")
+	}
+	for _, expected := range []string{"between 0 and 1", "window of policy", "used more than once", "condition of policy", "action of policy", "reserved for a built-in policy"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error to contain %q, got: %s", expected, err.Error())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Profile is a named network the client can be run against,
+// it overrides the node, chain ID, denom, gas price, address prefix and key of the config when selected
+type Profile struct {
+	Name          string
+	FairyRingNode Node
+	// PrivateKey of the validator on the network, empty to use the PrivateKey of the config
+	PrivateKey string
+}
+
+var profileOverride string
+
+// SelectProfile overrides the profile the config is loaded with, empty to use the ActiveProfile of the config
+func SelectProfile(name string) {
+	profileOverride = name
+}
+
+// SelectedProfile returns the name of the profile selected with the --profile flag
+func SelectedProfile() string {
+	return profileOverride
+}
+
+// ApplyProfile overrides the node & key of the config with the ones of the profile, nothing is done if name is empty
+func (c *Config) ApplyProfile(name string) error {
+	if len(name) == 0 {
+		return nil
+	}
+
+	profile, found := c.GetProfile(name)
+	if !found {
+		return fmt.Errorf("profile %s not found in config", name)
+	}
+
+	c.FairyRingNode = profile.FairyRingNode
+	if len(profile.PrivateKey) > 0 {
+		c.PrivateKey = profile.PrivateKey
+	}
+
+	return nil
+}
+
+// GetProfile returns the profile of the config of the name
+func (c *Config) GetProfile(name string) (Profile, bool) {
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// SetProfile adds the profile to the config, or replaces the one of the same name
+func (c *Config) SetProfile(profile Profile) {
+	for i, p := range c.Profiles {
+		if p.Name == profile.Name {
+			c.Profiles[i] = profile
+			return
+		}
+	}
+	c.Profiles = append(c.Profiles, profile)
+}
+
+// RemoveProfile removes the profile from the config, returns false if the config has no profile of the name.
+// The active profile is cleared if it is the one removed
+func (c *Config) RemoveProfile(name string) bool {
+	for i, p := range c.Profiles {
+		if p.Name == name {
+			c.Profiles = append(c.Profiles[:i], c.Profiles[i+1:]...)
+			if c.ActiveProfile == name {
+				c.ActiveProfile = ""
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// ListProfiles returns the profiles of the config sorted by name
+func (c *Config) ListProfiles() []Profile {
+	profiles := make([]Profile, len(c.Profiles))
+	copy(profiles, c.Profiles)
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
@@ -0,0 +1,64 @@
+package config
+
+import "testing"
+
+func TestApplyProfile(t *testing.T) {
+	cfg := DefaultConfig(false)
+	cfg.PrivateKey = "base-key"
+
+	if err := cfg.ApplyProfile("unknown"); err == nil {
+		t.Fatal("expected error applying unknown profile")
+	}
+
+	// A profile without key uses the key of the config
+	local := cfg.FairyRingNode
+	local.ChainID = "local-1"
+	cfg.SetProfile(Profile{Name: "local", FairyRingNode: local})
+
+	applied := cfg
+	if err := applied.ApplyProfile("local"); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if applied.FairyRingNode.ChainID != "local-1" || applied.PrivateKey != "base-key" {
+		t.Fatalf("expected local profile with base key, got: %+v, key: %s", applied.FairyRingNode, applied.PrivateKey)
+	}
+
+	// Adding a profile of the same name replaces it
+	remote := local
+	remote.IP = "10.0.0.1"
+	remote.GRPCHost = "grpc.example.com"
+	remote.GRPCPort = 443
+	remote.GRPCTLS = true
+	cfg.SetProfile(Profile{Name: "local", FairyRingNode: remote, PrivateKey: "local-key"})
+	cfg.SetProfile(Profile{Name: "devnet", FairyRingNode: Node{ChainID: "devnet-1", GasPrice: "0.1ufairy"}})
+
+	applied = cfg
+	if err := applied.ApplyProfile("local"); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if applied.FairyRingNode.IP != "10.0.0.1" || applied.PrivateKey != "local-key" {
+		t.Fatalf("expected updated local profile, got: %+v, key: %s", applied.FairyRingNode, applied.PrivateKey)
+	}
+	if applied.GetGRPCEndpoint() != "grpc.example.com:443" || len(applied.GetGRPCDialOptions()) != 1 {
+		t.Fatalf("expected gRPC endpoint of the profile over TLS, got: %s", applied.GetGRPCEndpoint())
+	}
+
+	names := make([]string, 0)
+	for _, p := range cfg.ListProfiles() {
+		names = append(names, p.Name)
+	}
+	if len(names) != 2 || names[0] != "devnet" || names[1] != "local" {
+		t.Fatalf("expected devnet & local profiles, got: %v", names)
+	}
+
+	cfg.ActiveProfile = "local"
+	if !cfg.RemoveProfile("devnet") || cfg.ActiveProfile != "local" {
+		t.Fatal("expected devnet removed and active profile kept")
+	}
+	if !cfg.RemoveProfile("local") || len(cfg.ActiveProfile) != 0 {
+		t.Fatal("expected local removed and active profile cleared")
+	}
+	if cfg.RemoveProfile("local") {
+		t.Fatal("expected removed profile not found")
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+const maxPort = 65535
+
+// Validate checks the values of the config the client is run with, the errors of all the fields are returned together
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	node := c.FairyRingNode
+	check(node.Protocol == "http" || node.Protocol == "https", "FairyRingNode.protocol must be http or https, got: %q", node.Protocol)
+	check(len(node.IP) > 0, "FairyRingNode.ip is empty")
+	check(node.Port > 0 && node.Port <= maxPort, "FairyRingNode.port must be between 1 and %d, got: %d", maxPort, node.Port)
+	check(node.GRPCPort > 0 && node.GRPCPort <= maxPort, "FairyRingNode.grpcPort must be between 1 and %d, got: %d", maxPort, node.GRPCPort)
+	check(len(node.ChainID) > 0, "FairyRingNode.chainID is empty")
+	check(len(node.Denom) > 0, "FairyRingNode.denom is empty")
+	if len(node.GasPrice) > 0 {
+		_, err := cosmostypes.ParseDecCoin(node.GasPrice)
+		check(err == nil, "FairyRingNode.gasPrice must be an amount & denom such as 0.1ufairy, got: %q", node.GasPrice)
+	}
+
+	check(isBech32Prefix(node.Bech32Prefix), "FairyRingNode.bech32Prefix must be lowercase letters & digits starting with a letter, got: %q", node.Bech32Prefix)
+
+	check(c.MetricsPort > 0 && c.MetricsPort <= maxPort, "MetricsPort must be between 1 and %d, got: %d", maxPort, c.MetricsPort)
+	check(len(c.LogLevel) == 0 || c.LogLevel == "debug" || c.LogLevel == "info" || c.LogLevel == "warn",
+		"LogLevel must be debug, info or warn, got: %q", c.LogLevel)
+
+	names := make(map[string]bool)
+	for _, v := range c.GetValidators() {
+		check(!names[v.Name], "validator name %q is used more than once", v.Name)
+		names[v.Name] = true
+		check(isPrivateKeyHex(v.PrivateKey), "private key of validator %q must be 64 hex characters", v.Name)
+	}
+
+	problems = append(problems, c.validatePolicies()...)
+
+	if c.HA.Enabled {
+		check(c.HA.Backend == "file" || c.HA.Backend == "http", "HA.backend must be file or http, got: %q", c.HA.Backend)
+		check(c.HA.Backend != "http" || len(c.HA.LeaseURL) > 0, "HA.leaseURL is required by the http backend")
+		check(c.HA.LeaseBlocks > 0, "HA.leaseBlocks must be greater than 0")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func isPrivateKeyHex(key string) bool {
+	if len(key) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
+
+// isBech32Prefix accepts the prefixes used by cosmos chains, such as fairy or cosmos. Empty uses the default prefix
+func isBech32Prefix(prefix string) bool {
+	for i, r := range prefix {
+		if !(r >= 'a' && r <= 'z') && !(i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const DefaultFileName = "history.jsonl"
+
+type SubmissionType string
+
+const (
+	TypeKeyshare          SubmissionType = "keyshare"
+	TypeGeneralKeyshare   SubmissionType = "general_keyshare"
+	TypeEncryptedKeyshare SubmissionType = "encrypted_keyshare"
+)
+
+type Outcome string
+
+const (
+	OutcomeConfirmed Outcome = "confirmed"
+	OutcomeFailed    Outcome = "failed"
+	OutcomeSlashed   Outcome = "slashed"
+	OutcomeError     Outcome = "error"
+)
+
+// Record is a single submission written to the audit log
+type Record struct {
+	Type        SubmissionType `json:"type"`
+	Height      uint64         `json:"height,omitempty"`
+	Identity    string         `json:"identity,omitempty"`
+	Requester   string         `json:"requester,omitempty"`
+	ShareIndex  uint64         `json:"share_index"`
+	Address     string         `json:"address"`
+	TxHash      string         `json:"tx_hash,omitempty"`
+	GasWanted   int64          `json:"gas_wanted,omitempty"`
+	GasUsed     int64          `json:"gas_used,omitempty"`
+	Code        uint32         `json:"code"`
+	Outcome     Outcome        `json:"outcome"`
+	Slashed     bool           `json:"slashed"`
+	RootCause   string         `json:"root_cause,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	SubmittedAt time.Time      `json:"submitted_at"`
+	ResultAt    time.Time      `json:"result_at"`
+}
+
+// Log is an append-only store of submission records, one JSON object per line
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+}
+
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+
+	return &Log{file: file, path: path}, nil
+}
+
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes the record to the end of the log, calling Append on a nil Log is a no-op
+func (l *Log) Append(r Record) error {
+	if l == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err = l.file.Write(line); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Filter selects records from the log, zero values match everything
+type Filter struct {
+	FromHeight uint64
+	ToHeight   uint64
+	Type       SubmissionType
+	Outcome    Outcome
+}
+
+func (f Filter) Match(r Record) bool {
+	if len(f.Type) > 0 && r.Type != f.Type {
+		return false
+	}
+	if len(f.Outcome) > 0 && r.Outcome != f.Outcome {
+		return false
+	}
+	// Records without target height (general & encrypted keyshares) are excluded when filtering by height
+	if f.FromHeight > 0 && r.Height < f.FromHeight {
+		return false
+	}
+	if f.ToHeight > 0 && (r.Height == 0 || r.Height > f.ToHeight) {
+		return false
+	}
+	return true
+}
+
+// ReadRecords reads all the records in the log at path that match the filter.
+// A truncated last line, left by a crash while appending, is skipped
+func ReadRecords(path string, filter Filter) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make([]Record, 0)
+	reader := bufio.NewReader(file)
+	lineNum := 0
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+		truncated := readErr == io.EOF
+
+		lineNum++
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var r Record
+			if err = json.Unmarshal(line, &r); err != nil {
+				if truncated {
+					break
+				}
+				return nil, fmt.Errorf("invalid record at line %d: %v", lineNum, err)
+			}
+			if filter.Match(r) {
+				records = append(records, r)
+			}
+		}
+
+		if truncated {
+			break
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	keyshare := Record{Type: TypeKeyshare, Height: 100, Outcome: OutcomeConfirmed}
+	slashed := Record{Type: TypeKeyshare, Height: 200, Outcome: OutcomeSlashed}
+	general := Record{Type: TypeGeneralKeyshare, Identity: "gov-1", Outcome: OutcomeConfirmed}
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		record  Record
+		matched bool
+	}{
+		{name: "empty filter", filter: Filter{}, record: general, matched: true},
+		{name: "type matched", filter: Filter{Type: TypeKeyshare}, record: keyshare, matched: true},
+		{name: "type not matched", filter: Filter{Type: TypeKeyshare}, record: general, matched: false},
+		{name: "outcome matched", filter: Filter{Outcome: OutcomeSlashed}, record: slashed, matched: true},
+		{name: "outcome not matched", filter: Filter{Outcome: OutcomeSlashed}, record: keyshare, matched: false},
+		{name: "from height inclusive", filter: Filter{FromHeight: 100}, record: keyshare, matched: true},
+		{name: "below from height", filter: Filter{FromHeight: 101}, record: keyshare, matched: false},
+		{name: "to height inclusive", filter: Filter{ToHeight: 200}, record: slashed, matched: true},
+		{name: "above to height", filter: Filter{ToHeight: 199}, record: slashed, matched: false},
+		{name: "from height excludes records without height", filter: Filter{FromHeight: 1}, record: general, matched: false},
+		{name: "to height excludes records without height", filter: Filter{ToHeight: 1000}, record: general, matched: false},
+		{name: "every field matched", filter: Filter{FromHeight: 150, ToHeight: 250, Type: TypeKeyshare, Outcome: OutcomeSlashed}, record: slashed, matched: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if matched := tc.filter.Match(tc.record); matched != tc.matched {
+				t.Fatalf("expected matched %t, got: %t", tc.matched, matched)
+			}
+		})
+	}
+}
+
+func TestAppendAndReadRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", DefaultFileName)
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("error opening log: %s", err.Error())
+	}
+	submittedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []Record{
+		{Type: TypeKeyshare, Height: 10, ShareIndex: 1, Outcome: OutcomeConfirmed, SubmittedAt: submittedAt},
+		{Type: TypeKeyshare, Height: 11, ShareIndex: 1, Outcome: OutcomeSlashed, Slashed: true, RootCause: "stale_share", SubmittedAt: submittedAt},
+		{Type: TypeGeneralKeyshare, Identity: "gov-1", ShareIndex: 1, Outcome: OutcomeError, Error: "timeout", SubmittedAt: submittedAt},
+	}
+	for _, r := range records {
+		if err = l.Append(r); err != nil {
+			t.Fatalf("error appending: %s", err.Error())
+		}
+	}
+	if err = l.Close(); err != nil {
+		t.Fatalf("error closing: %s", err.Error())
+	}
+
+	read, err := ReadRecords(path, Filter{})
+	if err != nil {
+		t.Fatalf("error reading: %s", err.Error())
+	}
+	if len(read) != len(records) {
+		t.Fatalf("expected %d records, got: %d", len(records), len(read))
+	}
+	for i := range records {
+		if read[i] != records[i] {
+			t.Fatalf("expected record %+v, got: %+v", records[i], read[i])
+		}
+	}
+
+	slashed, err := ReadRecords(path, Filter{Outcome: OutcomeSlashed})
+	if err != nil {
+		t.Fatalf("error reading: %s", err.Error())
+	}
+	if len(slashed) != 1 || slashed[0].Height != 11 || slashed[0].RootCause != "stale_share" {
+		t.Fatalf("expected the slashed record, got: %+v", slashed)
+	}
+
+	var nilLog *Log
+	if err = nilLog.Append(records[0]); err != nil {
+		t.Fatalf("expected append on nil log to be a no-op, got: %s", err.Error())
+	}
+}
+
+func TestReadRecordsInvalidLines(t *testing.T) {
+	valid := `{"type":"keyshare","height":10,"share_index":1,"address":"fairy1","code":0,"outcome":"confirmed","slashed":false,"submitted_at":"2024-01-02T03:04:05Z","result_at":"2024-01-02T03:04:06Z"}`
+
+	tests := []struct {
+		name    string
+		content string
+		records int
+		invalid bool
+	}{
+		{name: "empty log", content: "", records: 0},
+		{name: "blank lines skipped", content: valid + "\n\n" + valid + "\n", records: 2},
+		{name: "last line without newline", content: valid + "\n" + valid, records: 2},
+		{name: "truncated last line skipped", content: valid + "\n" + valid[:40], records: 1},
+		{name: "corrupt line", content: valid + "\n" + "not json\n" + valid + "\n", invalid: true},
+		{name: "truncated line followed by records", content: valid[:40] + "\n" + valid + "\n", invalid: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultFileName)
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("error writing log: %s", err.Error())
+			}
+
+			records, err := ReadRecords(path, Filter{})
+			if tc.invalid {
+				if err == nil {
+					t.Fatalf("expected error, got: %d records", len(records))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error reading: %s", err.Error())
+			}
+			if len(records) != tc.records {
+				t.Fatalf("expected %d records, got: %d", tc.records, len(records))
+			}
+		})
+	}
+
+	if _, err := ReadRecords(filepath.Join(t.TempDir(), "missing.jsonl"), Filter{}); err == nil {
+		t.Fatal("expected error for missing log")
+	}
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"type", "height", "identity", "requester", "share_index", "address", "tx_hash",
+	"gas_wanted", "gas_used", "code", "outcome", "slashed", "root_cause", "error", "submitted_at", "result_at",
+}
+
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if err := cw.Write([]string{
+			string(r.Type),
+			strconv.FormatUint(r.Height, 10),
+			r.Identity,
+			r.Requester,
+			strconv.FormatUint(r.ShareIndex, 10),
+			r.Address,
+			r.TxHash,
+			strconv.FormatInt(r.GasWanted, 10),
+			strconv.FormatInt(r.GasUsed, 10),
+			strconv.FormatUint(uint64(r.Code), 10),
+			string(r.Outcome),
+			strconv.FormatBool(r.Slashed),
+			r.RootCause,
+			r.Error,
+			r.SubmittedAt.Format(time.RFC3339),
+			r.ResultAt.Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func WriteJSON(w io.Writer, records []Record) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
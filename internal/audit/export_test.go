@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var exportRecords = []Record{
+	{
+		Type:        TypeKeyshare,
+		Height:      10,
+		ShareIndex:  1,
+		Address:     "fairy1validator",
+		TxHash:      "ABC",
+		GasWanted:   200000,
+		GasUsed:     150000,
+		Outcome:     OutcomeSlashed,
+		Slashed:     true,
+		RootCause:   "stale_share",
+		SubmittedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ResultAt:    time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC),
+	},
+	{
+		Type:        TypeEncryptedKeyshare,
+		Identity:    "identity, with comma",
+		Requester:   "fairy1requester",
+		ShareIndex:  1,
+		Address:     "fairy1validator",
+		Code:        5,
+		Outcome:     OutcomeError,
+		Error:       "insufficient funds",
+		SubmittedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ResultAt:    time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC),
+	},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, exportRecords); err != nil {
+		t.Fatalf("error writing csv: %s", err.Error())
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %s", err.Error())
+	}
+	expected := [][]string{
+		csvHeader,
+		{"keyshare", "10", "", "", "1", "fairy1validator", "ABC", "200000", "150000", "0", "slashed", "true", "stale_share", "", "2024-01-02T03:04:05Z", "2024-01-02T03:04:06Z"},
+		{"encrypted_keyshare", "0", "identity, with comma", "fairy1requester", "1", "fairy1validator", "", "0", "0", "5", "error", "false", "", "insufficient funds", "2024-01-02T03:04:05Z", "2024-01-02T03:04:06Z"},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("expected %d rows, got: %d", len(expected), len(rows))
+	}
+	for i := range expected {
+		if len(rows[i]) != len(csvHeader) {
+			t.Fatalf("expected %d columns in row %d, got: %d", len(csvHeader), i, len(rows[i]))
+		}
+		for j := range expected[i] {
+			if rows[i][j] != expected[i][j] {
+				t.Fatalf("expected %s column %q in row %d, got: %q", csvHeader[j], expected[i][j], i, rows[i][j])
+			}
+		}
+	}
+
+	buf.Reset()
+	if err = WriteCSV(&buf, nil); err != nil {
+		t.Fatalf("error writing csv: %s", err.Error())
+	}
+	if rows, _ = csv.NewReader(&buf).ReadAll(); len(rows) != 1 {
+		t.Fatalf("expected only the header without records, got: %d rows", len(rows))
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, exportRecords); err != nil {
+		t.Fatalf("error writing json: %s", err.Error())
+	}
+
+	var records []Record
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("invalid json: %s", err.Error())
+	}
+	if len(records) != len(exportRecords) {
+		t.Fatalf("expected %d records, got: %d", len(exportRecords), len(records))
+	}
+	for i := range records {
+		if records[i] != exportRecords[i] {
+			t.Fatalf("expected record %+v, got: %+v", exportRecords[i], records[i])
+		}
+	}
+}
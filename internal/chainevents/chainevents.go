@@ -0,0 +1,270 @@
+package chainevents
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+
+	keysharetypes "github.com/Fairblock/fairyring/x/keyshare/types"
+	abciTypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownType is returned when decoding an event of a type the client does not act on
+var ErrUnknownType = errors.New("unknown event type")
+
+// Event is a keyshare or pep event decoded & validated from the attributes of a chain event
+type Event interface {
+	Type() string
+}
+
+// Raw is a chain event before decoding, the last value is kept if an attribute is repeated
+type Raw struct {
+	Type       string
+	Attributes map[string]string
+}
+
+// QueuedPubkeyCreated is emitted when the pubkey of the next round is created, the pending share can be fetched
+type QueuedPubkeyCreated struct {
+	Pubkey                   string
+	Creator                  string
+	ExpiryHeight             uint64
+	ActivePubkeyExpiryHeight uint64
+	NumberOfValidators       uint64
+}
+
+func (QueuedPubkeyCreated) Type() string { return keysharetypes.QueuedPubkeyCreatedEventType }
+
+// PubkeyOverrode is emitted when the active pubkey is replaced in the middle of a round, the current share is stale
+type PubkeyOverrode struct {
+	Pubkey                   string
+	Creator                  string
+	ExpiryHeight             uint64
+	ActivePubkeyExpiryHeight uint64
+	NumberOfValidators       uint64
+}
+
+func (PubkeyOverrode) Type() string { return keysharetypes.PubkeyOverrodeEventType }
+
+// StartSendGeneralKeyshare requests the keyshare of a private governance identity
+type StartSendGeneralKeyshare struct {
+	Identity string
+}
+
+func (StartSendGeneralKeyshare) Type() string {
+	return keysharetypes.StartSendGeneralKeyshareEventType
+}
+
+// StartSendEncryptedKeyshare requests the keyshare of an identity, encrypted for the secp256k1 pubkey of the requester
+type StartSendEncryptedKeyshare struct {
+	Identity  string
+	Requester string
+	// Pubkey is the base64 encoded secp256k1 pubkey the keyshare is encrypted for
+	Pubkey string
+}
+
+func (StartSendEncryptedKeyshare) Type() string {
+	return keysharetypes.StartSendEncryptedKeyshareEventType
+}
+
+// KeyshareAggregated is emitted when the decryption key of a height is aggregated from the keyshares
+type KeyshareAggregated struct {
+	Height uint64
+	Data   string
+	Pubkey string
+}
+
+func (KeyshareAggregated) Type() string { return keysharetypes.KeyshareAggregatedEventType }
+
+// GeneralKeyshareAggregated is emitted when the decryption key of an identity is aggregated from the general keyshares
+type GeneralKeyshareAggregated struct {
+	IDType  string
+	IDValue string
+	Data    string
+	Pubkey  string
+}
+
+func (GeneralKeyshareAggregated) Type() string {
+	return keysharetypes.GeneralKeyshareAggregatedEventType
+}
+
+// decoders decode & validate the attributes of each event type the client acts on
+var decoders = map[string]func(attrs attributes) (Event, error){
+	keysharetypes.QueuedPubkeyCreatedEventType: func(attrs attributes) (Event, error) {
+		e := QueuedPubkeyCreated{
+			Pubkey:  attrs[keysharetypes.QueuedPubkeyCreatedEventPubkey],
+			Creator: attrs[keysharetypes.QueuedPubkeyCreatedEventCreator],
+		}
+		return e, firstError(
+			attrs.hex(keysharetypes.QueuedPubkeyCreatedEventPubkey),
+			attrs.uint(keysharetypes.QueuedPubkeyCreatedEventExpiryHeight, &e.ExpiryHeight),
+			attrs.uint(keysharetypes.QueuedPubkeyCreatedEventActivePubkeyExpiryHeight, &e.ActivePubkeyExpiryHeight),
+			attrs.uint(keysharetypes.QueuedPubkeyCreatedEventNumberOfValidators, &e.NumberOfValidators),
+		)
+	},
+	keysharetypes.PubkeyOverrodeEventType: func(attrs attributes) (Event, error) {
+		e := PubkeyOverrode{
+			Pubkey:  attrs[keysharetypes.PubkeyOverrodeEventPubkey],
+			Creator: attrs[keysharetypes.PubkeyOverrodeEventCreator],
+		}
+		return e, firstError(
+			attrs.hex(keysharetypes.PubkeyOverrodeEventPubkey),
+			attrs.uint(keysharetypes.PubkeyOverrodeEventExpiryHeight, &e.ExpiryHeight),
+			attrs.uint(keysharetypes.PubkeyOverrodeEventActivePubkeyExpiryHeight, &e.ActivePubkeyExpiryHeight),
+			attrs.uint(keysharetypes.PubkeyOverrodeEventNumberOfValidators, &e.NumberOfValidators),
+		)
+	},
+	keysharetypes.StartSendGeneralKeyshareEventType: func(attrs attributes) (Event, error) {
+		e := StartSendGeneralKeyshare{Identity: attrs[keysharetypes.StartSendGeneralKeyshareEventIdentity]}
+		return e, attrs.required(keysharetypes.StartSendGeneralKeyshareEventIdentity)
+	},
+	keysharetypes.StartSendEncryptedKeyshareEventType: func(attrs attributes) (Event, error) {
+		e := StartSendEncryptedKeyshare{
+			Identity:  attrs[keysharetypes.StartSendGeneralKeyshareEventIdentity],
+			Requester: attrs[keysharetypes.StartSendEncryptedKeyshareEventRequester],
+			Pubkey:    attrs[keysharetypes.StartSendEncryptedKeyshareEventPubkey],
+		}
+		return e, firstError(
+			attrs.required(keysharetypes.StartSendGeneralKeyshareEventIdentity),
+			attrs.required(keysharetypes.StartSendEncryptedKeyshareEventRequester),
+			attrs.base64(keysharetypes.StartSendEncryptedKeyshareEventPubkey),
+		)
+	},
+	keysharetypes.KeyshareAggregatedEventType: func(attrs attributes) (Event, error) {
+		e := KeyshareAggregated{
+			Data:   attrs[keysharetypes.KeyshareAggregatedEventData],
+			Pubkey: attrs[keysharetypes.KeyshareAggregatedEventPubkey],
+		}
+		return e, firstError(
+			attrs.required(keysharetypes.KeyshareAggregatedEventBlockHeight),
+			attrs.uint(keysharetypes.KeyshareAggregatedEventBlockHeight, &e.Height),
+			attrs.hex(keysharetypes.KeyshareAggregatedEventData),
+		)
+	},
+	keysharetypes.GeneralKeyshareAggregatedEventType: func(attrs attributes) (Event, error) {
+		e := GeneralKeyshareAggregated{
+			IDType:  attrs[keysharetypes.GeneralKeyshareAggregatedEventIDType],
+			IDValue: attrs[keysharetypes.GeneralKeyshareAggregatedEventIDValue],
+			Data:    attrs[keysharetypes.GeneralKeyshareAggregatedEventData],
+			Pubkey:  attrs[keysharetypes.GeneralKeyshareAggregatedEventPubkey],
+		}
+		return e, firstError(
+			attrs.required(keysharetypes.GeneralKeyshareAggregatedEventIDValue),
+			attrs.hex(keysharetypes.GeneralKeyshareAggregatedEventData),
+		)
+	},
+}
+
+// Decode decodes & validates the raw event, ErrUnknownType is returned for the types the client does not act on
+func Decode(raw Raw) (Event, error) {
+	decode, found := decoders[raw.Type]
+	if !found {
+		return nil, errors.Wrap(ErrUnknownType, raw.Type)
+	}
+
+	e, err := decode(raw.Attributes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s event", raw.Type)
+	}
+	return e, nil
+}
+
+// FromABCI converts the events of a block or a tx result
+func FromABCI(events []abciTypes.Event) []Raw {
+	raws := make([]Raw, 0, len(events))
+	for _, e := range events {
+		attrs := make(map[string]string, len(e.Attributes))
+		for _, a := range e.Attributes {
+			attrs[a.Key] = a.Value
+		}
+		raws = append(raws, Raw{Type: e.Type, Attributes: attrs})
+	}
+	return raws
+}
+
+// FromTxEvents converts the events of a tx subscription, keyed by "type.attribute". The values of each key are in
+// the order of the events of the type, so the nth value of every attribute of a type belongs to the nth event.
+// The events are returned sorted by type
+func FromTxEvents(events map[string][]string) []Raw {
+	byType := make(map[string][]Raw)
+	for key, values := range events {
+		eventType, attr, found := strings.Cut(key, ".")
+		if !found {
+			continue
+		}
+
+		for len(byType[eventType]) < len(values) {
+			byType[eventType] = append(byType[eventType], Raw{Type: eventType, Attributes: make(map[string]string)})
+		}
+		for i, value := range values {
+			byType[eventType][i].Attributes[attr] = value
+		}
+	}
+
+	types := make([]string, 0, len(byType))
+	for eventType := range byType {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+
+	var raws []Raw
+	for _, eventType := range types {
+		raws = append(raws, byType[eventType]...)
+	}
+	return raws
+}
+
+type attributes map[string]string
+
+func (a attributes) required(key string) error {
+	if len(a[key]) == 0 {
+		return errors.Errorf("%s is empty", key)
+	}
+	return nil
+}
+
+func (a attributes) hex(key string) error {
+	if err := a.required(key); err != nil {
+		return err
+	}
+	if _, err := hex.DecodeString(a[key]); err != nil {
+		return errors.Wrapf(err, "%s is not hex encoded", key)
+	}
+	return nil
+}
+
+func (a attributes) base64(key string) error {
+	if err := a.required(key); err != nil {
+		return err
+	}
+	if _, err := base64.StdEncoding.DecodeString(a[key]); err != nil {
+		return errors.Wrapf(err, "%s is not base64 encoded", key)
+	}
+	return nil
+}
+
+// uint parses the attribute into out if it is set
+func (a attributes) uint(key string, out *uint64) error {
+	value, found := a[key]
+	if !found || len(value) == 0 {
+		return nil
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s", key)
+	}
+	*out = parsed
+	return nil
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package chainevents
+
+import (
+	"reflect"
+	"testing"
+
+	abciTypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/pkg/errors"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      Raw
+		expected Event
+		invalid  bool
+	}{
+		{
+			name: "queued pubkey",
+			raw: Raw{Type: "queued-pubkey-created", Attributes: map[string]string{
+				"pubkey": "a1b2", "creator": "fairy1creator", "expiry-height": "200", "active-pubkey-expiry-height": "100", "number-of-validators": "4",
+			}},
+			expected: QueuedPubkeyCreated{Pubkey: "a1b2", Creator: "fairy1creator", ExpiryHeight: 200, ActivePubkeyExpiryHeight: 100, NumberOfValidators: 4},
+		},
+		{
+			name:     "overrode pubkey without optional attributes",
+			raw:      Raw{Type: "pubkey-overrode", Attributes: map[string]string{"pubkey": "a1b2"}},
+			expected: PubkeyOverrode{Pubkey: "a1b2"},
+		},
+		{
+			name:    "overrode pubkey not hex",
+			raw:     Raw{Type: "pubkey-overrode", Attributes: map[string]string{"pubkey": "not-hex"}},
+			invalid: true,
+		},
+		{
+			name:    "queued pubkey invalid expiry",
+			raw:     Raw{Type: "queued-pubkey-created", Attributes: map[string]string{"pubkey": "a1b2", "expiry-height": "soon"}},
+			invalid: true,
+		},
+		{
+			name:     "general keyshare",
+			raw:      Raw{Type: "start-send-general-keyshare", Attributes: map[string]string{"identity": "gov-1"}},
+			expected: StartSendGeneralKeyshare{Identity: "gov-1"},
+		},
+		{
+			name:    "general keyshare empty identity",
+			raw:     Raw{Type: "start-send-general-keyshare", Attributes: map[string]string{"identity": ""}},
+			invalid: true,
+		},
+		{
+			name: "encrypted keyshare",
+			raw: Raw{Type: "start-send-encrypted-keyshare", Attributes: map[string]string{
+				"identity": "id-1", "requester": "fairy1requester", "secp256k1-pubkey": "AQID",
+			}},
+			expected: StartSendEncryptedKeyshare{Identity: "id-1", Requester: "fairy1requester", Pubkey: "AQID"},
+		},
+		{
+			name: "encrypted keyshare pubkey not base64",
+			raw: Raw{Type: "start-send-encrypted-keyshare", Attributes: map[string]string{
+				"identity": "id-1", "requester": "fairy1requester", "secp256k1-pubkey": "%%",
+			}},
+			invalid: true,
+		},
+		{
+			name:     "keyshare aggregated",
+			raw:      Raw{Type: "keyshare-aggregated", Attributes: map[string]string{"height": "10", "data": "ff", "pubkey": "a1b2"}},
+			expected: KeyshareAggregated{Height: 10, Data: "ff", Pubkey: "a1b2"},
+		},
+		{
+			name:    "keyshare aggregated without height",
+			raw:     Raw{Type: "keyshare-aggregated", Attributes: map[string]string{"data": "ff"}},
+			invalid: true,
+		},
+		{
+			name: "general keyshare aggregated",
+			raw: Raw{Type: "general-keyshare-aggregated", Attributes: map[string]string{
+				"id-type": "private-gov-identity", "id-value": "gov-1", "data": "ff", "pubkey": "a1b2",
+			}},
+			expected: GeneralKeyshareAggregated{IDType: "private-gov-identity", IDValue: "gov-1", Data: "ff", Pubkey: "a1b2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := Decode(tc.raw)
+			if tc.invalid {
+				if err == nil {
+					t.Fatalf("expected error, got: %#v", e)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error decoding: %s", err.Error())
+			}
+			if !reflect.DeepEqual(e, tc.expected) {
+				t.Fatalf("expected %#v, got: %#v", tc.expected, e)
+			}
+		})
+	}
+
+	if _, err := Decode(Raw{Type: "coin_spent"}); !errors.Is(err, ErrUnknownType) {
+		t.Fatalf("expected ErrUnknownType, got: %v", err)
+	}
+}
+
+func TestFromTxEvents(t *testing.T) {
+	raws := FromTxEvents(map[string][]string{
+		"tm.event":                             {"Tx"},
+		"start-send-general-keyshare.identity": {"gov-1", "gov-2"},
+		"pubkey-overrode.pubkey":               {"a1b2"},
+		"pubkey-overrode.expiry-height":        {"200"},
+	})
+
+	expected := []Raw{
+		{Type: "pubkey-overrode", Attributes: map[string]string{"pubkey": "a1b2", "expiry-height": "200"}},
+		{Type: "start-send-general-keyshare", Attributes: map[string]string{"identity": "gov-1"}},
+		{Type: "start-send-general-keyshare", Attributes: map[string]string{"identity": "gov-2"}},
+		{Type: "tm", Attributes: map[string]string{"event": "Tx"}},
+	}
+	if !reflect.DeepEqual(raws, expected) {
+		t.Fatalf("expected %v, got: %v", expected, raws)
+	}
+}
+
+func TestRegistryDispatch(t *testing.T) {
+	r := NewRegistry()
+
+	var identities []string
+	On(r, func(e StartSendGeneralKeyshare) {
+		identities = append(identities, e.Identity)
+	})
+	var pubkeys []string
+	On(r, func(e PubkeyOverrode) {
+		pubkeys = append(pubkeys, e.Pubkey)
+	})
+
+	errs := r.Dispatch(FromABCI([]abciTypes.Event{
+		{Type: "start-send-general-keyshare", Attributes: []abciTypes.EventAttribute{{Key: "identity", Value: "gov-1"}}},
+		{Type: "start-send-general-keyshare", Attributes: []abciTypes.EventAttribute{{Key: "identity", Value: ""}}},
+		{Type: "coin_spent", Attributes: []abciTypes.EventAttribute{{Key: "amount", Value: "1ufairy"}}},
+		{Type: "start-send-encrypted-keyshare", Attributes: []abciTypes.EventAttribute{{Key: "identity", Value: "id-1"}}},
+		{Type: "pubkey-overrode", Attributes: []abciTypes.EventAttribute{{Key: "pubkey", Value: "a1b2"}}},
+		{Type: "start-send-general-keyshare", Attributes: []abciTypes.EventAttribute{{Key: "identity", Value: "gov-2"}}},
+	}))
+
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 invalid event, got: %v", errs)
+	}
+	if !reflect.DeepEqual(identities, []string{"gov-1", "gov-2"}) {
+		t.Fatalf("unexpected identities handled: %v", identities)
+	}
+	if !reflect.DeepEqual(pubkeys, []string{"a1b2"}) {
+		t.Fatalf("unexpected pubkeys handled: %v", pubkeys)
+	}
+}
@@ -0,0 +1,48 @@
+package chainevents
+
+import "sync"
+
+// Registry dispatches the decoded events to the handlers registered for their type
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string][]func(Event)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string][]func(Event))}
+}
+
+// On registers handler for the events of type E, the handlers of a type are called in the order they are registered
+func On[E Event](r *Registry, handler func(E)) {
+	var zero E
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[zero.Type()] = append(r.handlers[zero.Type()], func(e Event) {
+		handler(e.(E))
+	})
+}
+
+// Dispatch decodes the events and calls their handlers in order, the events without handler are skipped without decoding.
+// Returns the errors of the events failing validation, their handlers are not called
+func (r *Registry) Dispatch(events []Raw) []error {
+	var errs []error
+	for _, raw := range events {
+		r.mu.RLock()
+		handlers := r.handlers[raw.Type]
+		r.mu.RUnlock()
+		if len(handlers) == 0 {
+			continue
+		}
+
+		e, err := Decode(raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, handle := range handlers {
+			handle(e)
+		}
+	}
+	return errs
+}
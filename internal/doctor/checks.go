@@ -0,0 +1,253 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"fairyringclient/config"
+	"fairyringclient/internal/fairyringclient"
+	"fairyringclient/pkg/cosmosClient"
+
+	"cosmossdk.io/math"
+	peptypes "github.com/Fairblock/fairyring/x/pep/types"
+	tmclient "github.com/cometbft/cometbft/rpc/client/http"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	queryTimeout    = 5 * time.Second
+	newBlockTimeout = 15 * time.Second
+)
+
+// StatusClient queries the status of the node, it is satisfied by *tmclient.HTTP
+type StatusClient interface {
+	Status(ctx context.Context) (*coretypes.ResultStatus, error)
+}
+
+// Run checks the config file, the node and every validator of the config
+func Run(cfg config.Config, configPath string) *Report {
+	r := &Report{}
+
+	CheckConfigFile(r, configPath)
+
+	client, err := tmclient.New(cfg.GetFairyRingNodeURI(), "/websocket")
+	if err != nil {
+		r.Fail("rpc", "error creating client for %s: %s", cfg.GetFairyRingNodeURI(), err.Error())
+	} else if CheckNodeStatus(r, client, cfg.FairyRingNode.ChainID) {
+		CheckWebsocket(r, client)
+	}
+
+	if !CheckGRPC(r, cfg.GetGRPCEndpoint(), cfg.GetGRPCDialOptions()...) {
+		return r
+	}
+
+	cosmosClient.SetBech32Prefix(cfg.FairyRingNode.Bech32Prefix)
+	CheckChain(r, cfg.FairyRingNode, cfg.GetGRPCEndpoint(), cfg.GetGRPCDialOptions()...)
+
+	validators := cfg.GetValidators()
+	if len(validators) == 0 {
+		r.Fail("validators", "no private key in config, set one with `keys set`")
+		return r
+	}
+	for _, validator := range validators {
+		CheckValidator(r, cfg, validator, cfg.GetGRPCDialOptions()...)
+	}
+
+	return r
+}
+
+// CheckConfigFile checks the config file exists and is not readable by others, since it contains the private keys
+func CheckConfigFile(r *Report, path string) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		r.Warn("config file", "%s not found, using the default config with env overrides", path)
+		return
+	}
+	if err != nil {
+		r.Fail("config file", "error reading %s: %s", path, err.Error())
+		return
+	}
+
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		r.Warn("config file", "%s is accessible by group or others (mode %04o), run `chmod 600 %s`", path, perm, path)
+		return
+	}
+	r.Pass("config file", "%s", path)
+}
+
+// CheckNodeStatus checks the RPC of the node is reachable, runs the chain of the config and indexes txs.
+// Returns false if the RPC is unreachable
+func CheckNodeStatus(r *Report, client StatusClient, chainID string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	nodeStatus, err := client.Status(ctx)
+	if err != nil {
+		r.Fail("rpc", "node unreachable: %s", err.Error())
+		return false
+	}
+
+	if nodeStatus.SyncInfo.CatchingUp {
+		r.Warn("rpc", "node is catching up, latest height: %d", nodeStatus.SyncInfo.LatestBlockHeight)
+	} else {
+		r.Pass("rpc", "latest height: %d", nodeStatus.SyncInfo.LatestBlockHeight)
+	}
+
+	if nodeStatus.NodeInfo.Network != chainID {
+		r.Fail("chain id", "node runs %s but config has %s", nodeStatus.NodeInfo.Network, chainID)
+	} else {
+		r.Pass("chain id", "%s", chainID)
+	}
+
+	if nodeStatus.NodeInfo.Other.TxIndex != "on" {
+		r.Fail("tx indexing", "tx indexing is %s on the node, the client queries the txs it submits, set `indexer = \"kv\"` in config.toml of the node", nodeStatus.NodeInfo.Other.TxIndex)
+	} else {
+		r.Pass("tx indexing", "on")
+	}
+
+	return true
+}
+
+// CheckWebsocket subscribes to the new blocks over websocket and waits for the next block
+func CheckWebsocket(r *Report, client *tmclient.HTTP) {
+	if err := client.Start(); err != nil {
+		r.Fail("websocket", "error connecting: %s", err.Error())
+		return
+	}
+	defer client.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), newBlockTimeout)
+	defer cancel()
+
+	out, err := client.Subscribe(ctx, "doctor", "tm.event = 'NewBlock'")
+	if err != nil {
+		r.Fail("websocket", "error subscribing to new blocks: %s", err.Error())
+		return
+	}
+
+	select {
+	case <-out:
+		r.Pass("websocket", "received new block")
+	case <-ctx.Done():
+		r.Warn("websocket", "subscribed but no new block in %s, the node may be halted or syncing", newBlockTimeout)
+	}
+}
+
+// CheckGRPC checks the gRPC endpoint of the node is reachable. Returns false if it is unreachable
+func CheckGRPC(r *Report, endpoint string, dialOpts ...grpc.DialOption) bool {
+	conn, err := grpc.Dial(endpoint, append([]grpc.DialOption{grpc.WithInsecure()}, dialOpts...)...)
+	if err != nil {
+		r.Fail("grpc", "error dialing %s: %s", endpoint, err.Error())
+		return false
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+
+	resp, err := peptypes.NewQueryClient(conn).LatestHeight(ctx, &peptypes.QueryLatestHeightRequest{})
+	if err != nil {
+		r.Fail("grpc", "error querying %s, make sure it is the gRPC port of the node: %s", endpoint, err.Error())
+		return false
+	}
+
+	r.Pass("grpc", "%s, latest height: %d", endpoint, resp.Height)
+	return true
+}
+
+// CheckChain checks the chain ID, denom, address prefix & gas price of the config match the chain discovered from the node
+func CheckChain(r *Report, node config.Node, endpoint string, dialOpts ...grpc.DialOption) {
+	info, err := cosmosClient.DiscoverChain(endpoint, dialOpts...)
+	if err != nil {
+		r.Fail("chain", "error discovering chain: %s", err.Error())
+		return
+	}
+
+	warnings, err := fairyringclient.CheckChainInfo(node, info)
+	if err != nil {
+		r.Fail("chain", "%s", err.Error())
+		return
+	}
+	for _, warning := range warnings {
+		r.Warn("chain", "%s", warning)
+	}
+	if len(warnings) == 0 {
+		r.Pass("chain", "denom: %s, address prefix: %s, minimum gas prices: %s", info.Denom, info.Bech32Prefix, info.MinGasPrices.String())
+	}
+}
+
+// CheckValidator checks the account of the validator exists, is funded, is authorized
+// and the share of the validator can be decrypted & verified against the commitments
+func CheckValidator(r *Report, cfg config.Config, validator config.Validator, dialOpts ...grpc.DialOption) {
+	name := func(check string) string {
+		return fmt.Sprintf("%s: %s", validator.Name, check)
+	}
+
+	address, err := cosmosClient.AddressFromPrivateKey(validator.PrivateKey)
+	if err != nil {
+		r.Fail(name("account"), "invalid private key: %s", err.Error())
+		return
+	}
+
+	client, err := cosmosClient.NewCosmosClient(cfg.GetGRPCEndpoint(), validator.PrivateKey, cfg.FairyRingNode.ChainID, dialOpts...)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			r.Fail(name("account"), "%s not found on chain, send funds to the address to create it", address)
+		} else {
+			r.Fail(name("account"), "error querying %s: %s", address, err.Error())
+		}
+		return
+	}
+	r.Pass(name("account"), "%s", address)
+
+	checkBalance(r, name("balance"), client, cfg)
+
+	if client.IsAddrAuthorized(address) {
+		r.Pass(name("authorization"), "authorized to submit key shares")
+	} else {
+		r.Warn(name("authorization"), "not registered, `start` registers the validator, or authorize %s with `delegate add` from the validator", address)
+	}
+
+	_, index, _, err := client.GetKeyShare(false)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "empty") {
+			r.Warn(name("share"), "no share in the active round: %s", err.Error())
+		} else {
+			r.Fail(name("share"), "error decrypting share: %s", err.Error())
+		}
+		return
+	}
+	r.Pass(name("share"), "decrypted share of index %d", index)
+
+	v := fairyringclient.NewValidatorClients(cfg, client, client, nil)
+	if err = v.UpdateKeyShareFromChain(false); err != nil {
+		r.Fail(name("commitment"), "share failed verification against the active commitments: %s", err.Error())
+		return
+	}
+	r.Pass(name("commitment"), "share matches the active commitment")
+}
+
+func checkBalance(r *Report, check string, client *cosmosClient.CosmosClient, cfg config.Config) {
+	denom := cfg.FairyRingNode.Denom
+
+	balance, err := client.GetBalance(denom)
+	if err != nil {
+		r.Fail(check, "error querying balance: %s", err.Error())
+		return
+	}
+
+	switch {
+	case balance.IsZero():
+		r.Fail(check, "0 %s, the account cannot pay for txs", denom)
+	case balance.LT(math.NewIntFromUint64(cfg.BalanceMonitor.LowBalanceThreshold)):
+		r.Warn(check, "%s %s is below the low balance threshold of %d", balance.String(), denom, cfg.BalanceMonitor.LowBalanceThreshold)
+	default:
+		r.Pass(check, "%s %s", balance.String(), denom)
+	}
+}
@@ -0,0 +1,71 @@
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "PASS"
+	StatusWarn Status = "WARN"
+	StatusFail Status = "FAIL"
+)
+
+// Result is the outcome of a single pre-flight check
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+}
+
+// Report collects the results of the checks in the order they are run
+type Report struct {
+	Results []Result
+}
+
+func (r *Report) Pass(name string, format string, args ...interface{}) {
+	r.add(name, StatusPass, format, args...)
+}
+
+func (r *Report) Warn(name string, format string, args ...interface{}) {
+	r.add(name, StatusWarn, format, args...)
+}
+
+func (r *Report) Fail(name string, format string, args ...interface{}) {
+	r.add(name, StatusFail, format, args...)
+}
+
+func (r *Report) add(name string, status Status, format string, args ...interface{}) {
+	r.Results = append(r.Results, Result{Name: name, Status: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// Get returns the result of the check of the name
+func (r *Report) Get(name string) (Result, bool) {
+	for _, result := range r.Results {
+		if result.Name == name {
+			return result, true
+		}
+	}
+	return Result{}, false
+}
+
+// Failed returns true if any of the checks failed, warnings are not failures
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, result := range r.Results {
+		fmt.Fprintf(tw, "[%s]\t%s\t%s\n", result.Status, result.Name, result.Message)
+	}
+	_ = tw.Flush()
+}
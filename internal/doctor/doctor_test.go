@@ -0,0 +1,153 @@
+package doctor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fairyringclient/config"
+	"fairyringclient/pkg/cosmosClient/fakechain"
+
+	"cosmossdk.io/math"
+	"github.com/cometbft/cometbft/p2p"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+type fakeStatusClient struct {
+	status *coretypes.ResultStatus
+}
+
+func (c fakeStatusClient) Status(context.Context) (*coretypes.ResultStatus, error) {
+	return c.status, nil
+}
+
+func expectStatus(t *testing.T, r *Report, name string, expected Status) {
+	t.Helper()
+
+	result, found := r.Get(name)
+	if !found {
+		t.Fatalf("expected result of %s, got: %+v", name, r.Results)
+	}
+	if result.Status != expected {
+		t.Fatalf("expected %s to be %s, got: %+v", name, expected, result)
+	}
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	r := &Report{}
+	CheckConfigFile(r, path)
+	expectStatus(t, r, "config file", StatusWarn)
+
+	if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	r = &Report{}
+	CheckConfigFile(r, path)
+	expectStatus(t, r, "config file", StatusWarn)
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	r = &Report{}
+	CheckConfigFile(r, path)
+	expectStatus(t, r, "config file", StatusPass)
+}
+
+func TestCheckNodeStatus(t *testing.T) {
+	client := fakeStatusClient{status: &coretypes.ResultStatus{
+		NodeInfo: p2p.DefaultNodeInfo{
+			Network: "fairyring-test",
+			Other:   p2p.DefaultNodeInfoOther{TxIndex: "on"},
+		},
+	}}
+
+	r := &Report{}
+	CheckNodeStatus(r, client, "fairyring-test")
+	if r.Failed() {
+		t.Fatalf("expected all checks to pass, got: %+v", r.Results)
+	}
+
+	client.status.NodeInfo.Other.TxIndex = "off"
+	r = &Report{}
+	CheckNodeStatus(r, client, "fairyring-testnet-3")
+	expectStatus(t, r, "chain id", StatusFail)
+	expectStatus(t, r, "tx indexing", StatusFail)
+}
+
+func TestCheckValidator(t *testing.T) {
+	cosmostypes.GetConfig().SetBech32PrefixForAccount("fairy", "fairypub")
+
+	validators := make([]fakechain.Validator, 4)
+	for i := range validators {
+		validators[i] = fakechain.NewValidator()
+	}
+	round, err := fakechain.NewRound(validators[:3], 2)
+	if err != nil {
+		t.Fatalf("error creating round: %s", err.Error())
+	}
+
+	chain := fakechain.New()
+	t.Cleanup(chain.Close)
+	chain.SetRound(round, 100, false)
+
+	cfg := config.DefaultConfig(false)
+
+	r := &Report{}
+	if !CheckGRPC(r, fakechain.Endpoint, chain.DialOption()) {
+		t.Fatalf("expected gRPC reachable, got: %+v", r.Results)
+	}
+
+	// Funded, authorized & in the round
+	chain.AddAccount(validators[0].Address, 0, 0)
+	chain.SetBalance(validators[0].Address, cfg.FairyRingNode.Denom, math.NewIntFromUint64(cfg.BalanceMonitor.LowBalanceThreshold))
+	chain.SetAuthorized(validators[0].Address, true)
+	CheckValidator(r, cfg, config.Validator{Name: "a", PrivateKey: validators[0].PrivateKeyHex}, chain.DialOption())
+	if r.Failed() || len(r.Results) != 6 {
+		t.Fatalf("expected all checks to pass, got: %+v", r.Results)
+	}
+
+	// Unfunded & unauthorized
+	chain.AddAccount(validators[1].Address, 1, 0)
+	r = &Report{}
+	CheckValidator(r, cfg, config.Validator{Name: "b", PrivateKey: validators[1].PrivateKeyHex}, chain.DialOption())
+	expectStatus(t, r, "b: balance", StatusFail)
+	expectStatus(t, r, "b: authorization", StatusWarn)
+	expectStatus(t, r, "b: commitment", StatusPass)
+
+	// Not in the round
+	chain.AddAccount(validators[3].Address, 3, 0)
+	r = &Report{}
+	CheckValidator(r, cfg, config.Validator{Name: "c", PrivateKey: validators[3].PrivateKeyHex}, chain.DialOption())
+	expectStatus(t, r, "c: share", StatusWarn)
+
+	// Account not on chain
+	r = &Report{}
+	CheckValidator(r, cfg, config.Validator{Name: "d", PrivateKey: validators[2].PrivateKeyHex}, chain.DialOption())
+	expectStatus(t, r, "d: account", StatusFail)
+}
+
+func TestCheckChain(t *testing.T) {
+	chain := fakechain.New()
+	t.Cleanup(chain.Close)
+
+	node := config.DefaultConfig(false).FairyRingNode
+
+	chain.SetChainInfo(node.ChainID, node.Denom, "fairy", "")
+	r := &Report{}
+	CheckChain(r, node, fakechain.Endpoint, chain.DialOption())
+	expectStatus(t, r, "chain", StatusPass)
+
+	chain.SetChainInfo(node.ChainID, node.Denom, "fairy", "0.025ufairy")
+	r = &Report{}
+	CheckChain(r, node, fakechain.Endpoint, chain.DialOption())
+	expectStatus(t, r, "chain", StatusWarn)
+
+	chain.SetChainInfo("other-chain", node.Denom, "fairy", "")
+	r = &Report{}
+	CheckChain(r, node, fakechain.Endpoint, chain.DialOption())
+	expectStatus(t, r, "chain", StatusFail)
+}
@@ -0,0 +1,219 @@
+// Package election elects a single active submitter between the client replicas sharing a lease backend.
+// The lease is counted in blocks instead of time: the holder renews it at the latest height in the background
+// and a standby takes it over once it has not been renewed for the configured number of blocks.
+package election
+
+import (
+	"context"
+	"fairyringclient/config"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultLockFileName = "leader.lock"
+
+	BackendFile = "file"
+	BackendHTTP = "http"
+
+	backendTimeout = 3 * time.Second
+)
+
+// Lease is held by a single replica at a time
+type Lease struct {
+	Holder string `json:"holder"`
+	Height uint64 `json:"height"`
+}
+
+// Backend stores the lease shared by the replicas
+type Backend interface {
+	// TryAcquire takes or renews the lease for holder at height, a lease held by another replica is only
+	// taken over once it has not been renewed for leaseBlocks, the lease after the attempt is returned
+	TryAcquire(ctx context.Context, holder string, height uint64, leaseBlocks uint64) (Lease, error)
+	// Release gives up the lease if it is held by holder
+	Release(ctx context.Context, holder string) error
+}
+
+// acquire is the lease transition applied by every backend
+func acquire(current Lease, holder string, height uint64, leaseBlocks uint64) Lease {
+	if current.Holder == holder {
+		if height < current.Height {
+			height = current.Height
+		}
+		return Lease{Holder: holder, Height: height}
+	}
+	if len(current.Holder) == 0 || height >= current.Height+leaseBlocks {
+		return Lease{Holder: holder, Height: height}
+	}
+	return current
+}
+
+func release(current Lease, holder string) Lease {
+	if current.Holder == holder {
+		return Lease{}
+	}
+	return current
+}
+
+// Elector keeps track of whether this replica holds the lease
+type Elector struct {
+	backend     Backend
+	id          string
+	leaseBlocks uint64
+	leader      atomic.Bool
+}
+
+func NewElector(backend Backend, id string, leaseBlocks uint64) *Elector {
+	return &Elector{
+		backend:     backend,
+		id:          id,
+		leaseBlocks: leaseBlocks,
+	}
+}
+
+// New creates the elector from config, it returns nil if HA is disabled,
+// a nil Elector is always the leader
+func New(cfg config.HA) (*Elector, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if cfg.LeaseBlocks == 0 {
+		return nil, errors.New("HA lease blocks must be greater than 0")
+	}
+
+	id := cfg.ReplicaID
+	if len(id) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	var backend Backend
+	switch cfg.Backend {
+	case BackendFile:
+		lockPath := cfg.LockPath
+		if len(lockPath) == 0 {
+			homeDir, err := config.HomeDir()
+			if err != nil {
+				return nil, err
+			}
+			lockPath = filepath.Join(homeDir, DefaultLockFileName)
+		}
+		b, err := NewFileBackend(lockPath)
+		if err != nil {
+			return nil, err
+		}
+		backend = b
+	case BackendHTTP:
+		if len(cfg.LeaseURL) == 0 {
+			return nil, errors.New("HA lease URL not found in config")
+		}
+		backend = NewHTTPBackend(cfg.LeaseURL)
+	default:
+		return nil, errors.Errorf("unknown HA backend: %s, expected %s or %s", cfg.Backend, BackendFile, BackendHTTP)
+	}
+
+	return NewElector(backend, id, cfg.LeaseBlocks), nil
+}
+
+func (e *Elector) ID() string {
+	if e == nil {
+		return ""
+	}
+	return e.id
+}
+
+// IsLeader returns true if this replica is the active submitter
+func (e *Elector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+	return e.leader.Load()
+}
+
+// Observe renews or tries to take over the lease at height and returns whether this replica is the leader.
+// The replica steps down if the backend can not be reached, so two replicas never submit at the same time.
+func (e *Elector) Observe(height uint64) bool {
+	if e == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+
+	lease, err := e.backend.TryAcquire(ctx, e.id, height, e.leaseBlocks)
+	if err != nil {
+		if e.leader.Swap(false) {
+			log.Printf("Stepped down as the active submitter at height %d, unable to renew the lease: %s\n", height, err.Error())
+		} else {
+			log.Printf("Error checking the HA lease at height %d: %s\n", height, err.Error())
+		}
+		return false
+	}
+
+	isLeader := lease.Holder == e.id
+	wasLeader := e.leader.Swap(isLeader)
+	if isLeader && !wasLeader {
+		log.Printf("Became the active submitter at height %d as replica %s\n", height, e.id)
+	}
+	if !isLeader && wasLeader {
+		log.Printf("Lease taken over by replica %s at height %d, running as standby\n", lease.Holder, lease.Height)
+	}
+
+	return isLeader
+}
+
+// Run renews or tries to take over the lease at the latest height every interval until ctx is done, then resigns.
+// It runs in the background of the block handling, so a slow lease backend never delays a submission.
+// The replica steps down if the latest height can not be fetched, observed is called with the result of each attempt
+func (e *Elector) Run(ctx context.Context, interval time.Duration, latestHeight func() (uint64, error), observed func(isLeader bool)) {
+	if e == nil {
+		return
+	}
+	defer e.Resign()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			height, err := latestHeight()
+			if err != nil {
+				if e.leader.Swap(false) {
+					log.Printf("Stepped down as the active submitter, unable to get the latest height: %s\n", err.Error())
+				} else {
+					log.Printf("Error getting the latest height for the HA lease: %s\n", err.Error())
+				}
+				observed(false)
+				continue
+			}
+			observed(e.Observe(height))
+		}
+	}
+}
+
+// Resign releases the lease if this replica holds it, so a standby can take over on the next block
+func (e *Elector) Resign() {
+	if e == nil || !e.leader.Swap(false) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+
+	if err := e.backend.Release(ctx, e.id); err != nil {
+		log.Printf("Error releasing the HA lease: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,193 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fairyringclient/config"
+)
+
+const testLeaseBlocks = 3
+
+func TestAcquire(t *testing.T) {
+	lease := acquire(Lease{}, "a", 10, testLeaseBlocks)
+	if lease.Holder != "a" || lease.Height != 10 {
+		t.Fatalf("expected empty lease taken by a, got: %+v", lease)
+	}
+
+	if lease = acquire(lease, "b", 12, testLeaseBlocks); lease.Holder != "a" {
+		t.Fatalf("expected lease kept by a before it lapses, got: %+v", lease)
+	}
+
+	// Renewing with a lower height from a lagging node does not shorten the lease
+	if lease = acquire(lease, "a", 9, testLeaseBlocks); lease.Holder != "a" || lease.Height != 10 {
+		t.Fatalf("expected lease renewed at height 10, got: %+v", lease)
+	}
+
+	if lease = acquire(lease, "b", 13, testLeaseBlocks); lease.Holder != "b" || lease.Height != 13 {
+		t.Fatalf("expected lease taken over by b after %d blocks, got: %+v", testLeaseBlocks, lease)
+	}
+
+	if lease = release(lease, "a"); lease.Holder != "b" {
+		t.Fatalf("expected release by another replica ignored, got: %+v", lease)
+	}
+	if lease = release(lease, "b"); len(lease.Holder) != 0 {
+		t.Fatalf("expected lease released, got: %+v", lease)
+	}
+}
+
+// testFailover runs a leader & a standby on the backend, the leader stops renewing after height 10
+func testFailover(t *testing.T, backend Backend) {
+	t.Helper()
+
+	leader := NewElector(backend, "leader", testLeaseBlocks)
+	standby := NewElector(backend, "standby", testLeaseBlocks)
+
+	for height := uint64(1); height <= 10; height++ {
+		if !leader.Observe(height) {
+			t.Fatalf("expected leader to hold the lease at height %d", height)
+		}
+		if standby.Observe(height) {
+			t.Fatalf("expected standby not to hold the lease at height %d", height)
+		}
+	}
+
+	for height := uint64(11); height < 10+testLeaseBlocks; height++ {
+		if standby.Observe(height) {
+			t.Fatalf("expected standby not to take over before the lease lapses at height %d", height)
+		}
+	}
+
+	if !standby.Observe(10 + testLeaseBlocks) {
+		t.Fatalf("expected standby to take over at height %d", 10+testLeaseBlocks)
+	}
+	if leader.Observe(10 + testLeaseBlocks) {
+		t.Fatal("expected previous leader to step down once the lease is taken over")
+	}
+
+	standby.Resign()
+	if standby.IsLeader() {
+		t.Fatal("expected standby not leader after resigning")
+	}
+	if !leader.Observe(14) {
+		t.Fatal("expected leader to take the released lease right away")
+	}
+}
+
+func TestFileBackendFailover(t *testing.T) {
+	backend, err := NewFileBackend(filepath.Join(t.TempDir(), "ha", DefaultLockFileName))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	testFailover(t, backend)
+}
+
+func TestHTTPBackendFailover(t *testing.T) {
+	server := httptest.NewServer(NewLeaseServer())
+	defer server.Close()
+
+	testFailover(t, NewHTTPBackend(server.URL+"/"))
+}
+
+func TestObserveStepsDownOnBackendError(t *testing.T) {
+	server := httptest.NewServer(NewLeaseServer())
+	e := NewElector(NewHTTPBackend(server.URL), "leader", testLeaseBlocks)
+
+	if !e.Observe(1) {
+		t.Fatal("expected elector to hold the lease")
+	}
+
+	server.Close()
+	if e.Observe(2) {
+		t.Fatal("expected elector to step down when the lease server is unreachable")
+	}
+}
+
+func TestRunRenewsUntilDone(t *testing.T) {
+	backend, err := NewFileBackend(filepath.Join(t.TempDir(), DefaultLockFileName))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	e := NewElector(backend, "leader", testLeaseBlocks)
+
+	var height atomic.Uint64
+	var heightErr atomic.Bool
+	observed := make(chan bool, 100)
+	latestHeight := func() (uint64, error) {
+		if heightErr.Load() {
+			return 0, errors.New("node unreachable")
+		}
+		return height.Add(1), nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Run(ctx, time.Millisecond, latestHeight, func(isLeader bool) { observed <- isLeader })
+	}()
+
+	if !<-observed || !e.IsLeader() {
+		t.Fatal("expected elector to take the lease in the background")
+	}
+
+	heightErr.Store(true)
+	for <-observed {
+	}
+	if e.IsLeader() {
+		t.Fatal("expected elector to step down without the latest height")
+	}
+
+	heightErr.Store(false)
+	for !<-observed {
+	}
+
+	cancel()
+	<-done
+	if e.IsLeader() {
+		t.Fatal("expected elector to resign once done")
+	}
+	lease, err := backend.TryAcquire(context.Background(), "standby", height.Load(), testLeaseBlocks)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if lease.Holder != "standby" {
+		t.Fatalf("expected the released lease taken by the standby right away, got: %+v", lease)
+	}
+}
+
+func TestHTTPBackendInvalidRequest(t *testing.T) {
+	server := httptest.NewServer(NewLeaseServer())
+	defer server.Close()
+
+	if _, err := NewHTTPBackend(server.URL).TryAcquire(context.Background(), "", 1, testLeaseBlocks); err == nil {
+		t.Fatal("expected error acquiring the lease without holder")
+	}
+}
+
+func TestNilElector(t *testing.T) {
+	e, err := New(config.HA{Enabled: false})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !e.IsLeader() || !e.Observe(1) {
+		t.Fatal("expected nil elector to always be the leader")
+	}
+	e.Resign()
+	e.Run(context.Background(), time.Millisecond, nil, nil)
+
+	if _, err = New(config.HA{Enabled: true, Backend: "unknown", LeaseBlocks: testLeaseBlocks}); err == nil {
+		t.Fatal("expected error for unknown backend")
+	}
+	if _, err = New(config.HA{Enabled: true, Backend: BackendHTTP, LeaseBlocks: testLeaseBlocks}); err == nil {
+		t.Fatal("expected error for http backend without lease url")
+	}
+	if _, err = New(config.HA{Enabled: true, Backend: BackendFile}); err == nil {
+		t.Fatal("expected error for 0 lease blocks")
+	}
+}
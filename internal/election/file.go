@@ -0,0 +1,78 @@
+package election
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileBackend stores the lease in a file locked with flock, for replicas on the same host or sharing a volume
+type FileBackend struct {
+	path string
+}
+
+func NewFileBackend(path string) (*FileBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %v", err)
+	}
+	return &FileBackend{path: path}, nil
+}
+
+func (b *FileBackend) TryAcquire(_ context.Context, holder string, height uint64, leaseBlocks uint64) (Lease, error) {
+	return b.update(func(current Lease) Lease {
+		return acquire(current, holder, height, leaseBlocks)
+	})
+}
+
+func (b *FileBackend) Release(_ context.Context, holder string) error {
+	_, err := b.update(func(current Lease) Lease {
+		return release(current, holder)
+	})
+	return err
+}
+
+// update reads the lease, applies fn and writes the result while holding the lock on the file
+func (b *FileBackend) update(fn func(Lease) Lease) (Lease, error) {
+	file, err := os.OpenFile(b.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return Lease{}, fmt.Errorf("failed to open lock file: %v", err)
+	}
+	defer file.Close()
+
+	if err = lockFile(file); err != nil {
+		return Lease{}, fmt.Errorf("failed to lock file: %v", err)
+	}
+	defer unlockFile(file)
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return Lease{}, err
+	}
+
+	var current Lease
+	if len(content) > 0 {
+		if err = json.Unmarshal(content, &current); err != nil {
+			return Lease{}, fmt.Errorf("invalid lease in lock file: %v", err)
+		}
+	}
+
+	next := fn(current)
+	if next == current && len(content) > 0 {
+		return next, nil
+	}
+
+	content, err = json.Marshal(next)
+	if err != nil {
+		return Lease{}, err
+	}
+	if err = file.Truncate(0); err != nil {
+		return Lease{}, err
+	}
+	if _, err = file.WriteAt(content, 0); err != nil {
+		return Lease{}, err
+	}
+	return next, file.Sync()
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package election
+
+import (
+	"errors"
+	"os"
+)
+
+var errFileLockNotSupported = errors.New("file lock backend is not supported on this platform, use the http backend")
+
+func lockFile(_ *os.File) error {
+	return errFileLockNotSupported
+}
+
+func unlockFile(_ *os.File) error {
+	return errFileLockNotSupported
+}
@@ -0,0 +1,156 @@
+package election
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// The lease over HTTP protocol, any server implementing it can be used as the backend:
+//
+//	POST /acquire {"holder": "...", "height": 1, "lease_blocks": 3} responds the lease after the attempt {"holder": "...", "height": 1}
+//	POST /release {"holder": "..."} responds 200 once released
+type acquireRequest struct {
+	Holder      string `json:"holder"`
+	Height      uint64 `json:"height"`
+	LeaseBlocks uint64 `json:"lease_blocks"`
+}
+
+type releaseRequest struct {
+	Holder string `json:"holder"`
+}
+
+// HTTPBackend stores the lease on a lease server reachable by all the replicas
+type HTTPBackend struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPBackend(url string) *HTTPBackend {
+	return &HTTPBackend{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{},
+	}
+}
+
+func (b *HTTPBackend) TryAcquire(ctx context.Context, holder string, height uint64, leaseBlocks uint64) (Lease, error) {
+	var lease Lease
+	err := b.post(ctx, "/acquire", acquireRequest{Holder: holder, Height: height, LeaseBlocks: leaseBlocks}, &lease)
+	return lease, err
+}
+
+func (b *HTTPBackend) Release(ctx context.Context, holder string) error {
+	return b.post(ctx, "/release", releaseRequest{Holder: holder}, nil)
+}
+
+func (b *HTTPBackend) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("lease server responded status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// LeaseServer is an in-memory lease server implementing the lease over HTTP protocol,
+// it is a local stand-in for the lease service shared by the replicas
+type LeaseServer struct {
+	mu    sync.Mutex
+	lease Lease
+	mux   *http.ServeMux
+}
+
+func NewLeaseServer() *LeaseServer {
+	s := &LeaseServer{mux: http.NewServeMux()}
+	s.mux.HandleFunc("/acquire", s.handleAcquire)
+	s.mux.HandleFunc("/release", s.handleRelease)
+	s.mux.HandleFunc("/lease", s.handleLease)
+	return s
+}
+
+func (s *LeaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Lease returns the current lease
+func (s *LeaseServer) Lease() Lease {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lease
+}
+
+func (s *LeaseServer) handleAcquire(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req acquireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Holder) == 0 {
+		http.Error(w, "invalid acquire request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.lease = acquire(s.lease, req.Holder, req.Height, req.LeaseBlocks)
+	lease := s.lease
+	s.mu.Unlock()
+
+	writeJSON(w, lease)
+}
+
+func (s *LeaseServer) handleRelease(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req releaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Holder) == 0 {
+		http.Error(w, "invalid release request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.lease = release(s.lease, req.Holder)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *LeaseServer) handleLease(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.Lease())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
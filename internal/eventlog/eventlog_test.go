@@ -0,0 +1,189 @@
+package eventlog
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+)
+
+const (
+	blockQuery = "tm.event = 'NewBlock'"
+	txQuery    = "tm.event = 'Tx'"
+)
+
+// chanSource returns the channel of each query as the subscription
+type chanSource map[string]chan coretypes.ResultEvent
+
+func (s chanSource) Subscribe(_ context.Context, _, query string, _ ...int) (<-chan coretypes.ResultEvent, error) {
+	return s[query], nil
+}
+
+func newBlockEvent(height int64) coretypes.ResultEvent {
+	return coretypes.ResultEvent{
+		Query: blockQuery,
+		Data: tmtypes.EventDataNewBlock{
+			Block: &tmtypes.Block{Header: tmtypes.Header{Height: height}},
+		},
+		Events: map[string][]string{"tm.event": {"NewBlock"}},
+	}
+}
+
+func newTxEvent() coretypes.ResultEvent {
+	return coretypes.ResultEvent{
+		Query:  txQuery,
+		Data:   tmtypes.EventDataTx{},
+		Events: map[string][]string{"tm.event": {"Tx"}, "tx.height": {"1"}},
+	}
+}
+
+func eventHeight(t *testing.T, e coretypes.ResultEvent) int64 {
+	t.Helper()
+	block, ok := e.Data.(tmtypes.EventDataNewBlock)
+	if !ok {
+		t.Fatalf("expected EventDataNewBlock, got: %T", e.Data)
+	}
+	return block.Block.Height
+}
+
+func TestRecordAndReadEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	source := chanSource{blockQuery: make(chan coretypes.ResultEvent), txQuery: make(chan coretypes.ResultEvent)}
+
+	r, err := NewRecorder(source, path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks, err := r.Subscribe(ctx, "", blockQuery)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	txs, err := r.Subscribe(ctx, "", txQuery)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	source[blockQuery] <- newBlockEvent(1)
+	if h := eventHeight(t, <-blocks); h != 1 {
+		t.Fatalf("expected forwarded block 1, got: %d", h)
+	}
+	source[txQuery] <- newTxEvent()
+	if e := <-txs; e.Query != txQuery {
+		t.Fatalf("expected forwarded tx event, got: %s", e.Query)
+	}
+	source[blockQuery] <- newBlockEvent(2)
+	<-blocks
+
+	if err = r.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	entries, err := ReadEntries(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got: %d", len(entries))
+	}
+	if eventHeight(t, entries[0].Event) != 1 || entries[1].Event.Query != txQuery || eventHeight(t, entries[2].Event) != 2 {
+		t.Fatal("entries are not in the received order")
+	}
+	if entries[1].Event.Events["tx.height"][0] != "1" {
+		t.Fatalf("expected events of the tx kept, got: %v", entries[1].Event.Events)
+	}
+}
+
+func TestRecordForSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	first := chanSource{blockQuery: make(chan coretypes.ResultEvent)}
+	second := chanSource{blockQuery: make(chan coretypes.ResultEvent)}
+
+	r, err := NewRecorder(first, path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The events of another source are appended to the same event log
+	for i, recorder := range []*Recorder{r, r.ForSource(second)} {
+		blocks, err := recorder.Subscribe(ctx, "", blockQuery)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+		source := []chanSource{first, second}[i]
+		source[blockQuery] <- newBlockEvent(int64(i + 1))
+		<-blocks
+	}
+
+	if err = r.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	entries, err := ReadEntries(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(entries) != 2 || eventHeight(t, entries[0].Event) != 1 || eventHeight(t, entries[1].Event) != 2 {
+		t.Fatalf("expected blocks 1 & 2 recorded to the same event log, got: %d entries", len(entries))
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	start := time.Now()
+	r := NewReplayer([]Entry{
+		{RecordedAt: start, Event: newBlockEvent(1)},
+		{RecordedAt: start.Add(time.Second), Event: newTxEvent()},
+		{RecordedAt: start.Add(2 * time.Second), Event: newBlockEvent(2)},
+	}, 20)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks, err := r.Subscribe(ctx, "", blockQuery)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if _, err = r.Subscribe(ctx, "", blockQuery); err == nil {
+		t.Fatal("expected error subscribing the same query twice")
+	}
+
+	// The replay waits until every query in the log is subscribed
+	select {
+	case <-blocks:
+		t.Fatal("expected no event before the tx query is subscribed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	replayStart := time.Now()
+	txs, err := r.Subscribe(ctx, "", txQuery)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if h := eventHeight(t, <-blocks); h != 1 {
+		t.Fatalf("expected block 1, got: %d", h)
+	}
+	<-txs
+	if h := eventHeight(t, <-blocks); h != 2 {
+		t.Fatalf("expected block 2, got: %d", h)
+	}
+
+	select {
+	case <-r.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected replay done after the last event")
+	}
+
+	// 2 seconds recorded at 20x speed
+	if elapsed := time.Since(replayStart); elapsed < 100*time.Millisecond {
+		t.Fatalf("expected replay to keep the recorded intervals, took: %s", elapsed)
+	}
+}
@@ -0,0 +1,163 @@
+// Package eventlog records the block & tx events received from FairyRing to a JSONL file
+// and replays them, so the behavior of the client on specific blocks can be reproduced.
+package eventlog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cmtjson "github.com/cometbft/cometbft/libs/json"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+const defaultOutCapacity = 100
+
+// Entry is a single event written to the event log, the event is encoded with the same JSON codec as the
+// CometBFT RPC, so the event data (block, events & tx results) is kept as received
+type Entry struct {
+	RecordedAt time.Time             `json:"recorded_at"`
+	Event      coretypes.ResultEvent `json:"event"`
+}
+
+// Source subscribes to the events of the chain
+type Source interface {
+	Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan coretypes.ResultEvent, error)
+}
+
+// Recorder forwards the events of each subscription from the source and writes them to the event log
+type Recorder struct {
+	source Source
+	writer *logWriter
+}
+
+// logWriter appends the entries to the event log, it is shared by the recorders of the same event log
+type logWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+}
+
+func NewRecorder(source Source, path string) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %v", err)
+	}
+
+	return &Recorder{source: source, writer: &logWriter{file: file, path: path}}, nil
+}
+
+// ForSource returns a recorder of the events of source writing to the same event log, such as the events
+// of another node. The event log is closed by Close of any of them
+func (r *Recorder) ForSource(source Source) *Recorder {
+	return &Recorder{source: source, writer: r.writer}
+}
+
+func (r *Recorder) Path() string {
+	return r.writer.path
+}
+
+// Unsubscribe ends the subscription of the source, nothing is done if the source does not support it
+func (r *Recorder) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	if unsubscriber, ok := r.source.(interface {
+		Unsubscribe(ctx context.Context, subscriber, query string) error
+	}); ok {
+		return unsubscriber.Unsubscribe(ctx, subscriber, query)
+	}
+	return nil
+}
+
+func (r *Recorder) Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan coretypes.ResultEvent, error) {
+	in, err := r.source.Subscribe(ctx, subscriber, query, outCapacity...)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := defaultOutCapacity
+	if len(outCapacity) > 0 {
+		capacity = outCapacity[0]
+	}
+	out := make(chan coretypes.ResultEvent, capacity)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-in:
+				if !ok {
+					return
+				}
+				if err := r.Write(Entry{RecordedAt: time.Now(), Event: event}); err != nil {
+					log.Printf("Error recording event: %s\n", err.Error())
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Write appends the entry to the end of the event log
+func (r *Recorder) Write(e Entry) error {
+	line, err := cmtjson.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	r.writer.mu.Lock()
+	defer r.writer.mu.Unlock()
+
+	_, err = r.writer.file.Write(line)
+	return err
+}
+
+func (r *Recorder) Close() error {
+	return r.writer.file.Close()
+}
+
+// ReadEntries reads all the entries of the event log in order
+func ReadEntries(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	// A block with many txs results in a long line
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err = cmtjson.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid event at line %d: %v", lineNum, err)
+		}
+		entries = append(entries, e)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,113 @@
+package eventlog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+// Replayer is an event source feeding the events of an event log to the subscriptions with the same query.
+// The replay starts once every query found in the log is subscribed, the events are sent in the recorded order
+// over unbuffered channels, so each event is received before the next one is sent.
+type Replayer struct {
+	mu      sync.Mutex
+	entries []Entry
+	speed   float64
+	queries map[string]bool
+	subs    map[string]chan coretypes.ResultEvent
+	started bool
+	done    chan struct{}
+}
+
+// NewReplayer replays the entries, speed 1 keeps the recorded intervals between the events,
+// speed 10 replays 10 times faster, speed 0 sends the events without waiting
+func NewReplayer(entries []Entry, speed float64) *Replayer {
+	queries := make(map[string]bool)
+	for _, e := range entries {
+		queries[e.Event.Query] = true
+	}
+
+	return &Replayer{
+		entries: entries,
+		speed:   speed,
+		queries: queries,
+		subs:    make(map[string]chan coretypes.ResultEvent),
+		done:    make(chan struct{}),
+	}
+}
+
+// OpenReplayer reads the event log at path and replays it
+func OpenReplayer(path string, speed float64) (*Replayer, error) {
+	entries, err := ReadEntries(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(entries, speed), nil
+}
+
+func (r *Replayer) Subscribe(ctx context.Context, _, query string, _ ...int) (<-chan coretypes.ResultEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, found := r.subs[query]; found {
+		return nil, fmt.Errorf("already subscribed to query: %s", query)
+	}
+
+	out := make(chan coretypes.ResultEvent)
+	r.subs[query] = out
+
+	if !r.started && r.allSubscribed() {
+		r.started = true
+		subs := make(map[string]chan coretypes.ResultEvent, len(r.subs))
+		for q, ch := range r.subs {
+			subs[q] = ch
+		}
+		go r.replay(ctx, subs)
+	}
+
+	return out, nil
+}
+
+// Done is closed once all the events are sent
+func (r *Replayer) Done() <-chan struct{} {
+	return r.done
+}
+
+// Len returns the number of events in the log
+func (r *Replayer) Len() int {
+	return len(r.entries)
+}
+
+func (r *Replayer) allSubscribed() bool {
+	for q := range r.queries {
+		if _, found := r.subs[q]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Replayer) replay(ctx context.Context, subs map[string]chan coretypes.ResultEvent) {
+	defer close(r.done)
+
+	for i, e := range r.entries {
+		if r.speed > 0 && i > 0 {
+			if wait := e.RecordedAt.Sub(r.entries[i-1].RecordedAt); wait > 0 {
+				select {
+				case <-time.After(time.Duration(float64(wait) / r.speed)):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		select {
+		case subs[e.Event.Query] <- e.Event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package fairyringclient
+
+import (
+	"encoding/hex"
+	"log"
+	"strconv"
+	"sync"
+
+	distIBE "github.com/FairBlock/DistributedIBE"
+	"github.com/drand/kyber/pairing"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	aggregatedKeyResultSuccess = "success"
+	aggregatedKeyResultFailure = "failure"
+	aggregatedKeyResultMissing = "missing"
+)
+
+var (
+	aggregatedKeyVerification = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fairyringclient_aggregated_key_verification",
+		Help: "The total number of aggregated decryption keys verified against the active pubkey by result: success, failure, missing",
+	}, []string{"validator", "result"})
+	latestVerifiedAggregatedKey = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fairyringclient_latest_verified_aggregated_key_height",
+		Help: "The latest height that the aggregated decryption key is verified successfully",
+	}, []string{"validator"})
+)
+
+// AggregatedKeyVerifier checks the decryption key aggregated by the chain for each height against the active pubkey,
+// the previous pubkey is kept so the keys aggregated right before a pubkey rotation can still be verified
+type AggregatedKeyVerifier struct {
+	mu             sync.Mutex
+	previousPubKey string
+	currentPubKey  string
+}
+
+// VerifyAggregatedKey queries the aggregated decryption key of height and verifies it against the active pubkey
+func (v *ValidatorClients) VerifyAggregatedKey(height uint64) {
+	if v.AggregatedKeyVerifier == nil {
+		return
+	}
+
+	heightStr := strconv.FormatUint(height, 10)
+
+	decryptionKey, err := v.Querier.GetDecryptionKey(height)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			aggregatedKeyVerification.WithLabelValues(v.Broadcaster.GetAddress(), aggregatedKeyResultMissing).Inc()
+			log.Printf("Aggregated decryption key for height %s is MISSING\n", heightStr)
+			return
+		}
+		log.Printf("Error getting aggregated decryption key for height %s: %s\n", heightStr, err.Error())
+		return
+	}
+
+	pubKey, err := v.Querier.GetPepPubKey()
+	if err != nil {
+		log.Printf("Error getting active pubkey from pep module: %s\n", err.Error())
+		return
+	}
+
+	valid, err := v.AggregatedKeyVerifier.Verify(pubKey.ActivePubkey.PublicKey, decryptionKey.Data, heightStr)
+	if err != nil || !valid {
+		aggregatedKeyVerification.WithLabelValues(v.Broadcaster.GetAddress(), aggregatedKeyResultFailure).Inc()
+		if err != nil {
+			log.Printf("Aggregated decryption key for height %s is INVALID: %s\n", heightStr, err.Error())
+		} else {
+			log.Printf("Aggregated decryption key for height %s is INVALID, it does not match the active pubkey\n", heightStr)
+		}
+		return
+	}
+
+	aggregatedKeyVerification.WithLabelValues(v.Broadcaster.GetAddress(), aggregatedKeyResultSuccess).Inc()
+	latestVerifiedAggregatedKey.WithLabelValues(v.Broadcaster.GetAddress()).Set(float64(height))
+	log.Printf("Aggregated decryption key for height %s verified\n", heightStr)
+}
+
+// Verify checks the hex encoded aggregated key for id against the active pubkey,
+// falling back to the previous pubkey if the active one changed recently
+func (a *AggregatedKeyVerifier) Verify(activePubKeyHex string, aggregatedKeyHex string, id string) (bool, error) {
+	a.mu.Lock()
+	if activePubKeyHex != a.currentPubKey {
+		a.previousPubKey = a.currentPubKey
+		a.currentPubKey = activePubKeyHex
+	}
+	candidates := []string{a.currentPubKey}
+	if len(a.previousPubKey) > 0 {
+		candidates = append(candidates, a.previousPubKey)
+	}
+	a.mu.Unlock()
+
+	s := suite
+
+	aggregatedKeyBytes, err := hex.DecodeString(aggregatedKeyHex)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to decode aggregated key")
+	}
+	aggregatedKey := s.G2().Point()
+	if err = aggregatedKey.UnmarshalBinary(aggregatedKeyBytes); err != nil {
+		return false, errors.Wrap(err, "unable to unmarshal aggregated key")
+	}
+
+	for _, candidate := range candidates {
+		valid, err := verifyAggregatedKeyWithPubKey(s, candidate, distIBE.ExtractedKey{SK: aggregatedKey}, id)
+		if err != nil {
+			return false, err
+		}
+		if valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyAggregatedKeyWithPubKey checks e(pubkey, H(id)) == e(G1, aggregated key)
+func verifyAggregatedKeyWithPubKey(s pairing.Suite, pubKeyHex string, aggregatedKey distIBE.ExtractedKey, id string) (bool, error) {
+	pubKey, err := parseCommitment(s, pubKeyHex)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to parse active pubkey")
+	}
+	return verifyExtractedKey(s, pubKey, 0, aggregatedKey, []byte(id))
+}
@@ -0,0 +1,59 @@
+package fairyringclient
+
+import (
+	"fairyringclient/internal/audit"
+	"log"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// RecordSubmission writes the result of a keyshare submission to the audit log & the policy engine,
+// txResp is nil when the submission failed before the tx is included in a block
+func (v *ValidatorClients) RecordSubmission(r audit.Record, txResp *tx.GetTxResponse, submitErr error) {
+	switch {
+	case submitErr != nil || txResp == nil || txResp.TxResponse == nil:
+		v.Policy.RecordSubmission(submissionFailed)
+	case hasCoinSpentEvent(txResp.TxResponse.Events):
+		v.Policy.RecordSubmission(submissionInvalid)
+	case txResp.TxResponse.Code != 0:
+		v.Policy.RecordSubmission(submissionFailed)
+	default:
+		v.Policy.RecordSubmission(submissionConfirmed)
+	}
+
+	if v.AuditLog == nil {
+		return
+	}
+
+	r.Address = v.Broadcaster.GetAddress()
+	r.ResultAt = time.Now()
+
+	switch {
+	case submitErr != nil:
+		r.Outcome = audit.OutcomeError
+		r.Error = submitErr.Error()
+	case txResp == nil || txResp.TxResponse == nil:
+		r.Outcome = audit.OutcomeError
+		r.Error = "empty tx response"
+	default:
+		r.TxHash = txResp.TxResponse.TxHash
+		r.GasWanted = txResp.TxResponse.GasWanted
+		r.GasUsed = txResp.TxResponse.GasUsed
+		r.Code = txResp.TxResponse.Code
+		r.Slashed = hasCoinSpentEvent(txResp.TxResponse.Events)
+
+		if r.Slashed {
+			r.Outcome = audit.OutcomeSlashed
+		} else if r.Code != 0 {
+			r.Outcome = audit.OutcomeFailed
+			r.Error = txResp.TxResponse.RawLog
+		} else {
+			r.Outcome = audit.OutcomeConfirmed
+		}
+	}
+
+	if err := v.AuditLog.Append(r); err != nil {
+		log.Printf("Error writing submission to audit log: %s\n", err.Error())
+	}
+}
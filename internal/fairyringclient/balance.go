@@ -0,0 +1,190 @@
+package fairyringclient
+
+import (
+	"fairyringclient/config"
+	"fairyringclient/internal/notifier"
+	"log"
+	"math/big"
+	"sync"
+
+	"cosmossdk.io/math"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// balanceSampleWindow is the number of balance samples kept for estimating the spending rate
+const balanceSampleWindow = 20
+
+var (
+	accountBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fairyringclient_account_balance",
+		Help: "The latest balance of the submitter account",
+	}, []string{"validator"})
+	balanceRunwayBlocks = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fairyringclient_account_balance_runway_blocks",
+		Help: "Estimated number of blocks until the submitter account runs out of funds, -1 if unknown",
+	}, []string{"validator"})
+	lowBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fairyringclient_account_balance_low",
+		Help: "1 if the submitter account balance is below the configured threshold, 0 otherwise",
+	}, []string{"validator"})
+)
+
+type balanceSample struct {
+	height  uint64
+	balance math.Int
+}
+
+type BalanceMonitor struct {
+	mu                sync.Mutex
+	address           string
+	denom             string
+	checkInterval     uint64
+	threshold         math.Int
+	lastCheckedHeight uint64
+	samples           []balanceSample
+	low               bool
+}
+
+func NewBalanceMonitor(address string, denom string, cfg config.BalanceMonitor) *BalanceMonitor {
+	m := &BalanceMonitor{
+		address: address,
+		denom:   denom,
+	}
+	m.UpdateConfig(cfg)
+	return m
+}
+
+// UpdateConfig applies the check interval & threshold, the recorded samples are kept.
+// The new threshold applies from the next check
+func (m *BalanceMonitor) UpdateConfig(cfg config.BalanceMonitor) {
+	interval := cfg.CheckInterval
+	if interval == 0 {
+		interval = config.DefaultBalanceCheckInterval
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkInterval = interval
+	m.threshold = math.NewIntFromUint64(cfg.LowBalanceThreshold)
+}
+
+// ShouldCheck returns true if the balance has not been checked within the last check interval,
+// the check at height is reserved so the balance is queried once when the blocks are handled concurrently
+func (m *BalanceMonitor) ShouldCheck(height uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lastCheckedHeight != 0 && height < m.lastCheckedHeight+m.checkInterval {
+		return false
+	}
+	m.lastCheckedHeight = height
+	return true
+}
+
+// Record stores the balance at the given height, updates the metrics and
+// returns true if the balance is below the low balance threshold
+func (m *BalanceMonitor) Record(height uint64, balance math.Int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples = append(m.samples, balanceSample{height: height, balance: balance})
+	if len(m.samples) > balanceSampleWindow {
+		m.samples = m.samples[len(m.samples)-balanceSampleWindow:]
+	}
+
+	m.low = !m.threshold.IsZero() && balance.LT(m.threshold)
+
+	accountBalance.WithLabelValues(m.address).Set(intToFloat(balance))
+	balanceRunwayBlocks.WithLabelValues(m.address).Set(m.estimateRunway(balance))
+	if m.low {
+		lowBalance.WithLabelValues(m.address).Set(1)
+	} else {
+		lowBalance.WithLabelValues(m.address).Set(0)
+	}
+
+	return m.low
+}
+
+// estimateRunway estimates the blocks left before the account is empty from the
+// amount spent between the recorded samples, top ups are not counted as spending
+func (m *BalanceMonitor) estimateRunway(balance math.Int) float64 {
+	if len(m.samples) < 2 {
+		return -1
+	}
+
+	spent := math.ZeroInt()
+	for i := 1; i < len(m.samples); i++ {
+		if m.samples[i].balance.LT(m.samples[i-1].balance) {
+			spent = spent.Add(m.samples[i-1].balance.Sub(m.samples[i].balance))
+		}
+	}
+
+	blocks := m.samples[len(m.samples)-1].height - m.samples[0].height
+	if spent.IsZero() || blocks == 0 {
+		return -1
+	}
+
+	spentPerBlock := intToFloat(spent) / float64(blocks)
+	return intToFloat(balance) / spentPerBlock
+}
+
+func (m *BalanceMonitor) IsLow() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.low
+}
+
+func (m *BalanceMonitor) Threshold() math.Int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.threshold
+}
+
+// Latest returns the last balance recorded, false if the balance is not checked yet
+func (m *BalanceMonitor) Latest() (math.Int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.samples) == 0 {
+		return math.Int{}, false
+	}
+	return m.samples[len(m.samples)-1].balance, true
+}
+
+// CheckBalance polls the account balance if the check interval passed and warns when it is below the threshold.
+// Optional duties are paused by the low balance policy when BalanceMonitor.PauseOptionalDuties is set
+func (v *ValidatorClients) CheckBalance(height uint64) {
+	if v.BalanceMonitor == nil || !v.BalanceMonitor.ShouldCheck(height) {
+		return
+	}
+
+	bal, err := v.Querier.GetBalance(v.BalanceMonitor.denom)
+	if err != nil {
+		log.Printf("Error getting account balance: %s\n", err.Error())
+		return
+	}
+
+	if !v.BalanceMonitor.Record(height, *bal) {
+		return
+	}
+
+	log.Printf("WARNING: Account balance %s %s is below the threshold %s %s, please top up the account: %s\n",
+		bal.String(), v.BalanceMonitor.denom,
+		v.BalanceMonitor.Threshold().String(), v.BalanceMonitor.denom,
+		v.Broadcaster.GetAddress(),
+	)
+
+	v.notify(notifier.Event{
+		Type:    notifier.EventLowBalance,
+		Message: "Submitter account balance is below the threshold",
+		Height:  height,
+		Fields: map[string]string{
+			"balance":   bal.String() + v.BalanceMonitor.denom,
+			"threshold": v.BalanceMonitor.Threshold().String() + v.BalanceMonitor.denom,
+		},
+	})
+}
+
+func intToFloat(i math.Int) float64 {
+	f, _ := new(big.Float).SetInt(i.BigInt()).Float64()
+	return f
+}
@@ -0,0 +1,136 @@
+package fairyringclient
+
+import (
+	"sync"
+	"testing"
+
+	"fairyringclient/config"
+
+	"cosmossdk.io/math"
+)
+
+func TestBalanceMonitorShouldCheck(t *testing.T) {
+	m := NewBalanceMonitor("fairy1validator", "ufairy", config.BalanceMonitor{CheckInterval: 10})
+
+	if !m.ShouldCheck(100) {
+		t.Fatal("expected first check to be due")
+	}
+	if m.ShouldCheck(100) {
+		t.Fatal("expected check at the same height to be reserved")
+	}
+	if m.ShouldCheck(109) {
+		t.Fatal("expected no check within the interval")
+	}
+	if !m.ShouldCheck(110) {
+		t.Fatal("expected check once the interval passed")
+	}
+
+	// Concurrent blocks query the balance once
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	checks := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.ShouldCheck(120) {
+				mu.Lock()
+				checks++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if checks != 1 {
+		t.Fatalf("expected 1 check between concurrent blocks, got: %d", checks)
+	}
+}
+
+func TestBalanceMonitorThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold uint64
+		balance   int64
+		low       bool
+	}{
+		{name: "below threshold", threshold: 1000, balance: 999, low: true},
+		{name: "at threshold", threshold: 1000, balance: 1000, low: false},
+		{name: "above threshold", threshold: 1000, balance: 5000, low: false},
+		{name: "threshold disabled", threshold: 0, balance: 0, low: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewBalanceMonitor("fairy1validator", "ufairy", config.BalanceMonitor{LowBalanceThreshold: tc.threshold})
+			if low := m.Record(1, math.NewInt(tc.balance)); low != tc.low {
+				t.Fatalf("expected low %t, got: %t", tc.low, low)
+			}
+			if m.IsLow() != tc.low {
+				t.Fatalf("expected IsLow %t, got: %t", tc.low, m.IsLow())
+			}
+		})
+	}
+
+	// A new threshold applies from the next check
+	m := NewBalanceMonitor("fairy1validator", "ufairy", config.BalanceMonitor{LowBalanceThreshold: 1000})
+	if !m.Record(1, math.NewInt(500)) {
+		t.Fatal("expected low balance")
+	}
+	m.UpdateConfig(config.BalanceMonitor{LowBalanceThreshold: 100})
+	if m.Record(2, math.NewInt(500)) {
+		t.Fatal("expected balance above the updated threshold")
+	}
+}
+
+func TestBalanceMonitorEstimateRunway(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  []balanceSample
+		expected float64
+	}{
+		{
+			name:     "single sample",
+			samples:  []balanceSample{{height: 10, balance: math.NewInt(1000)}},
+			expected: -1,
+		},
+		{
+			name: "no spending",
+			samples: []balanceSample{
+				{height: 10, balance: math.NewInt(1000)},
+				{height: 20, balance: math.NewInt(1000)},
+			},
+			expected: -1,
+		},
+		{
+			name: "constant spending",
+			samples: []balanceSample{
+				{height: 10, balance: math.NewInt(1000)},
+				{height: 20, balance: math.NewInt(900)},
+				{height: 30, balance: math.NewInt(800)},
+			},
+			// 200 spent in 20 blocks, 800 left
+			expected: 80,
+		},
+		{
+			name: "top up not counted as spending",
+			samples: []balanceSample{
+				{height: 10, balance: math.NewInt(1000)},
+				{height: 20, balance: math.NewInt(900)},
+				{height: 30, balance: math.NewInt(1900)},
+				{height: 40, balance: math.NewInt(1800)},
+			},
+			// 200 spent in 30 blocks, 1800 left
+			expected: 270,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &BalanceMonitor{samples: tc.samples}
+			latest := tc.samples[len(tc.samples)-1].balance
+			if runway := m.estimateRunway(latest); runway != tc.expected {
+				t.Fatalf("expected runway %v, got: %v", tc.expected, runway)
+			}
+		})
+	}
+}
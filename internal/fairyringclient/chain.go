@@ -0,0 +1,51 @@
+package fairyringclient
+
+import (
+	"context"
+	"fairyringclient/pkg/cosmosClient"
+
+	"cosmossdk.io/math"
+	distIBE "github.com/FairBlock/DistributedIBE"
+	"github.com/Fairblock/fairyring/x/keyshare/types"
+	peptypes "github.com/Fairblock/fairyring/x/pep/types"
+	tmclient "github.com/cometbft/cometbft/rpc/client/http"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// ChainQuerier queries the state of FairyRing needed by the client
+type ChainQuerier interface {
+	IsAddrAuthorized(target string) bool
+	GetKeyShare(getPendingShare bool) (*distIBE.Share, uint64, uint64, error)
+	GetCommitments() (*types.QueryCommitmentsResponse, error)
+	GetBalance(denom string) (*math.Int, error)
+	GetDecryptionKey(height uint64) (*types.DecryptionKey, error)
+	GetPepPubKey() (*peptypes.QueryPubkeyResponse, error)
+	GetLatestHeight() (uint64, error)
+}
+
+// TxBroadcaster signs & broadcasts txs with the account of the client
+type TxBroadcaster interface {
+	GetAddress() string
+	BroadcastTx(msg cosmostypes.Msg, adjustGas bool) (*tx.GetTxResponse, error)
+	AddTxToQueue(
+		msg cosmostypes.Msg,
+		adjustGas bool,
+		errHandler func(error),
+		successHandler func(*tx.GetTxResponse),
+	)
+	HandleTxQueue() error
+}
+
+// EventSource subscribes to the block & tx events of FairyRing
+type EventSource interface {
+	Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan coretypes.ResultEvent, error)
+}
+
+var (
+	_ ChainQuerier      = (*cosmosClient.CosmosClient)(nil)
+	_ TxBroadcaster     = (*cosmosClient.CosmosClient)(nil)
+	_ EventSource       = (*tmclient.HTTP)(nil)
+	_ eventUnsubscriber = (*tmclient.HTTP)(nil)
+)
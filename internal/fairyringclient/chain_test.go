@@ -0,0 +1,86 @@
+package fairyringclient
+
+import (
+	"sync"
+	"testing"
+
+	"fairyringclient/config"
+
+	"github.com/Fairblock/fairyring/x/keyshare/types"
+	abciTypes "github.com/cometbft/cometbft/abci/types"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+// mockBroadcaster confirms every queued tx right away and records the msgs
+type mockBroadcaster struct {
+	mu      sync.Mutex
+	address string
+	msgs    []cosmostypes.Msg
+}
+
+func (m *mockBroadcaster) GetAddress() string {
+	return m.address
+}
+
+func (m *mockBroadcaster) BroadcastTx(msg cosmostypes.Msg, _ bool) (*tx.GetTxResponse, error) {
+	m.mu.Lock()
+	m.msgs = append(m.msgs, msg)
+	m.mu.Unlock()
+	return &tx.GetTxResponse{TxResponse: &cosmostypes.TxResponse{}}, nil
+}
+
+func (m *mockBroadcaster) AddTxToQueue(
+	msg cosmostypes.Msg,
+	_ bool,
+	_ func(error),
+	successHandler func(*tx.GetTxResponse),
+) {
+	m.mu.Lock()
+	m.msgs = append(m.msgs, msg)
+	m.mu.Unlock()
+	successHandler(&tx.GetTxResponse{TxResponse: &cosmostypes.TxResponse{}})
+}
+
+func (m *mockBroadcaster) HandleTxQueue() error {
+	return nil
+}
+
+func TestIndependentValidatorClients(t *testing.T) {
+	setup := newDealerSetup(t)
+
+	broadcasters := []*mockBroadcaster{{address: "fairy1first"}, {address: "fairy1second"}}
+	clients := make([]*ValidatorClients, len(broadcasters))
+	for i, b := range broadcasters {
+		clients[i] = NewValidatorClients(config.DefaultConfig(false), nil, b, nil)
+		clients[i].CurrentShare = setup.keyShare(t, uint64(i+1))
+	}
+
+	clients[0].handleEndBlockEvents([]abciTypes.Event{{
+		Type:       "start-send-general-keyshare",
+		Attributes: []abciTypes.EventAttribute{{Key: "identity", Value: "gov-1"}},
+	}})
+
+	if len(broadcasters[0].msgs) != 1 {
+		t.Fatalf("expected 1 msg from the first client, got: %d", len(broadcasters[0].msgs))
+	}
+	if len(broadcasters[1].msgs) != 0 {
+		t.Fatalf("expected no msg from the second client, got: %d", len(broadcasters[1].msgs))
+	}
+
+	msg, ok := broadcasters[0].msgs[0].(*types.MsgSubmitGeneralKeyshare)
+	if !ok {
+		t.Fatalf("expected MsgSubmitGeneralKeyshare, got: %T", broadcasters[0].msgs[0])
+	}
+	if msg.Creator != "fairy1first" || msg.KeyshareIndex != 1 || msg.IdValue != "gov-1" {
+		t.Fatalf("unexpected msg: %s", msg.String())
+	}
+
+	clients[1].RegisterValidatorSet()
+	if _, ok = broadcasters[1].msgs[0].(*types.MsgRegisterValidator); !ok {
+		t.Fatalf("expected MsgRegisterValidator from the second client, got: %T", broadcasters[1].msgs[0])
+	}
+	if len(broadcasters[0].msgs) != 1 {
+		t.Fatalf("expected first client not affected, got: %d msgs", len(broadcasters[0].msgs))
+	}
+}
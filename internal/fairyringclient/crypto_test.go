@@ -0,0 +1,345 @@
+package fairyringclient
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	distIBE "github.com/FairBlock/DistributedIBE"
+	"github.com/Fairblock/fairyring/x/keyshare/types"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/drand/kyber"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testNumberOfValidators = 4
+	testThreshold          = 3
+)
+
+type dealerSetup struct {
+	shares      []distIBE.Share
+	mpk         kyber.Point
+	commitments *types.Commitments
+}
+
+// newDealerSetup generates a master secret, one share per validator and the commitments
+// in the same format the keyshare module stores them
+func newDealerSetup(tb testing.TB) dealerSetup {
+	tb.Helper()
+
+	shares, mpk, _, err := distIBE.GenerateShares(testNumberOfValidators, testThreshold)
+	if err != nil {
+		tb.Fatalf("error generating shares: %s", err.Error())
+	}
+
+	commitments := make([]string, len(shares))
+	for i, share := range shares {
+		commitment := suite.G1().Point().Mul(share.Value, suite.G1().Point().Base())
+		commitmentBytes, err := commitment.MarshalBinary()
+		if err != nil {
+			tb.Fatalf("error marshaling commitment: %s", err.Error())
+		}
+		commitments[i] = hex.EncodeToString(commitmentBytes)
+	}
+
+	return dealerSetup{
+		shares:      shares,
+		mpk:         mpk,
+		commitments: &types.Commitments{Commitments: commitments},
+	}
+}
+
+// keyShare returns the share of the validator at index (starting from 1) with its commitment
+func (d dealerSetup) keyShare(tb testing.TB, index uint64) *KeyShare {
+	tb.Helper()
+
+	commitment, err := parseCommitment(suite, d.commitments.Commitments[index-1])
+	if err != nil {
+		tb.Fatalf("error parsing commitment: %s", err.Error())
+	}
+
+	share := d.shares[index-1]
+	return &KeyShare{
+		Share:      &share,
+		Index:      index,
+		Commitment: commitment,
+	}
+}
+
+func TestVerifyShare(t *testing.T) {
+	setup := newDealerSetup(t)
+
+	for i := uint64(1); i <= testNumberOfValidators; i++ {
+		v := &ValidatorClients{CurrentShare: setup.keyShare(t, i)}
+		valid, err := v.VerifyShare(setup.commitments, false)
+		if err != nil {
+			t.Fatalf("share %d: unexpected error: %s", i, err.Error())
+		}
+		if !valid {
+			t.Fatalf("share %d: expected valid share", i)
+		}
+	}
+}
+
+func TestVerifyPendingShare(t *testing.T) {
+	current := newDealerSetup(t)
+	pending := newDealerSetup(t)
+
+	v := &ValidatorClients{
+		CurrentShare: current.keyShare(t, 1),
+		PendingShare: pending.keyShare(t, 2),
+	}
+
+	valid, err := v.VerifyShare(pending.commitments, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatal("expected valid pending share")
+	}
+
+	valid, err = v.VerifyShare(current.commitments, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if valid {
+		t.Fatal("expected pending share to fail against the commitments of another round")
+	}
+}
+
+func TestVerifyShareTampered(t *testing.T) {
+	setup := newDealerSetup(t)
+
+	share := setup.keyShare(t, 1)
+	tampered := distIBE.Share{
+		Index: share.Share.Index,
+		Value: suite.G1().Scalar().Add(share.Share.Value, suite.G1().Scalar().One()),
+	}
+	share.Share = &tampered
+
+	v := &ValidatorClients{CurrentShare: share}
+	valid, err := v.VerifyShare(setup.commitments, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if valid {
+		t.Fatal("expected tampered share to fail verification")
+	}
+}
+
+func TestVerifyShareWrongIndex(t *testing.T) {
+	setup := newDealerSetup(t)
+
+	share := setup.keyShare(t, 1)
+	share.Index = 2
+
+	v := &ValidatorClients{CurrentShare: share}
+	valid, err := v.VerifyShare(setup.commitments, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if valid {
+		t.Fatal("expected share with wrong index to fail verification")
+	}
+
+	share.Index = testNumberOfValidators + 1
+	if _, err = v.VerifyShare(setup.commitments, false); err == nil {
+		t.Fatal("expected error for share index out of the commitments range")
+	}
+}
+
+func TestVerifyShareErrors(t *testing.T) {
+	setup := newDealerSetup(t)
+
+	v := &ValidatorClients{}
+	if _, err := v.VerifyShare(setup.commitments, false); err == nil {
+		t.Fatal("expected error when current share not found")
+	}
+
+	v.CurrentShare = setup.keyShare(t, 1)
+	if _, err := v.VerifyShare(setup.commitments, true); err == nil {
+		t.Fatal("expected error when pending share not found")
+	}
+
+	if _, err := v.VerifyShare(&types.Commitments{}, false); err == nil {
+		t.Fatal("expected error when commitments are empty")
+	}
+}
+
+func TestDeriveKeyShare(t *testing.T) {
+	setup := newDealerSetup(t)
+	id := []byte("1234")
+
+	v := &ValidatorClients{CurrentShare: setup.keyShare(t, 3)}
+	derived, index, err := v.DeriveKeyShare(id)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if index != 3 {
+		t.Fatalf("expected index 3, got: %d", index)
+	}
+
+	expected := distIBE.Extract(suite, setup.shares[2].Value, 3, id)
+	expectedBytes, err := expected.SK.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error marshaling extracted key: %s", err.Error())
+	}
+	if derived != hex.EncodeToString(expectedBytes) {
+		t.Fatalf("derived key share does not match the extracted key")
+	}
+}
+
+func TestDeriveKeyShareWrongCommitment(t *testing.T) {
+	setup := newDealerSetup(t)
+
+	share := setup.keyShare(t, 1)
+	share.Commitment = setup.keyShare(t, 2).Commitment
+
+	v := &ValidatorClients{CurrentShare: share}
+	_, _, err := v.DeriveKeyShare([]byte("1234"))
+	if !errors.Is(err, ErrInvalidDerivedKeyShare) {
+		t.Fatalf("expected ErrInvalidDerivedKeyShare, got: %v", err)
+	}
+
+	share.Commitment = nil
+	_, _, err = v.DeriveKeyShare([]byte("1234"))
+	if !errors.Is(err, ErrInvalidDerivedKeyShare) {
+		t.Fatalf("expected ErrInvalidDerivedKeyShare without commitment, got: %v", err)
+	}
+
+	v.CurrentShare = nil
+	if _, _, err = v.DeriveKeyShare([]byte("1234")); err == nil {
+		t.Fatal("expected error when current share not found")
+	}
+}
+
+func TestAggregatedKeyVerifier(t *testing.T) {
+	setup := newDealerSetup(t)
+	id := strconv.FormatUint(100, 10)
+
+	extractedKeys := make([]distIBE.ExtractedKey, 0, testThreshold)
+	commitments := make([]distIBE.Commitment, 0, testThreshold)
+	for i := uint64(1); i <= testThreshold; i++ {
+		share := setup.keyShare(t, i)
+		extractedKeys = append(extractedKeys, distIBE.Extract(suite, share.Share.Value, uint32(i), []byte(id)))
+		commitments = append(commitments, distIBE.Commitment{SP: share.Commitment, Index: uint32(i)})
+	}
+
+	// The second result is the indexes of the keyshares failing verification, which are left out of the aggregated key
+	aggregated, invalid := distIBE.AggregateSK(suite, extractedKeys, commitments, []byte(id))
+	require.Empty(t, invalid, "expected every keyshare to pass verification")
+	aggregatedBytes, err := aggregated.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error marshaling aggregated key: %s", err.Error())
+	}
+	mpkBytes, err := setup.mpk.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error marshaling master public key: %s", err.Error())
+	}
+
+	verifier := &AggregatedKeyVerifier{}
+	valid, err := verifier.Verify(hex.EncodeToString(mpkBytes), hex.EncodeToString(aggregatedBytes), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatal("expected aggregated key to match the master public key")
+	}
+
+	valid, err = verifier.Verify(hex.EncodeToString(mpkBytes), hex.EncodeToString(aggregatedBytes), "101")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if valid {
+		t.Fatal("expected aggregated key to fail for another identity")
+	}
+
+	// Rotated to a new pubkey, the key aggregated with the previous pubkey is still accepted
+	other := newDealerSetup(t)
+	otherMpkBytes, err := other.mpk.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error marshaling master public key: %s", err.Error())
+	}
+	valid, err = verifier.Verify(hex.EncodeToString(otherMpkBytes), hex.EncodeToString(aggregatedBytes), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !valid {
+		t.Fatal("expected aggregated key to match the previous master public key")
+	}
+}
+
+func TestEncryptWithPublicKey(t *testing.T) {
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("error generating private key: %s", err.Error())
+	}
+	pubKey := base64.StdEncoding.EncodeToString(privKey.PubKey().SerializeCompressed())
+
+	message := "derived key share"
+	encrypted, err := encryptWithPublicKey(message, pubKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	cipherBytes, err := hex.DecodeString(encrypted)
+	if err != nil {
+		t.Fatalf("expected hex encoded ciphertext: %s", err.Error())
+	}
+	plain, err := btcec.Decrypt(privKey, cipherBytes)
+	if err != nil {
+		t.Fatalf("error decrypting: %s", err.Error())
+	}
+	if string(plain) != message {
+		t.Fatalf("expected decrypted message %q, got: %q", message, string(plain))
+	}
+
+	if _, err = encryptWithPublicKey(message, "invalid base64"); err == nil {
+		t.Fatal("expected error for invalid base64 public key")
+	}
+	if _, err = encryptWithPublicKey(message, base64.StdEncoding.EncodeToString([]byte("invalid"))); err == nil {
+		t.Fatal("expected error for invalid public key")
+	}
+}
+
+func BenchmarkDeriveKeyShare(b *testing.B) {
+	setup := newDealerSetup(b)
+	v := &ValidatorClients{CurrentShare: setup.keyShare(b, 1)}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := v.DeriveKeyShare([]byte(strconv.Itoa(i))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifyShare(b *testing.B) {
+	setup := newDealerSetup(b)
+	v := &ValidatorClients{CurrentShare: setup.keyShare(b, 1)}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := v.VerifyShare(setup.commitments, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncryptWithPublicKey(b *testing.B) {
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		b.Fatal(err)
+	}
+	pubKey := base64.StdEncoding.EncodeToString(privKey.PubKey().SerializeCompressed())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := encryptWithPublicKey("derived key share", pubKey); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package fairyringclient
+
+import (
+	"fmt"
+	"log"
+
+	"fairyringclient/config"
+	"fairyringclient/pkg/cosmosClient"
+
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+)
+
+// CheckChainInfo compares the chain discovered from the node with the node config.
+// A different chain ID or address prefix is returned as error, txs would be signed for another chain or from another address.
+// A different denom or a gas price the node does not accept are returned as warnings
+func CheckChainInfo(node config.Node, info *cosmosClient.ChainInfo) ([]string, error) {
+	if info.ChainID != node.ChainID {
+		return nil, errors.Errorf("node runs chain %s but config has %s, update FairyRingNode.chainID or connect to another node", info.ChainID, node.ChainID)
+	}
+
+	prefix := node.Bech32Prefix
+	if len(prefix) == 0 {
+		prefix = config.DefaultBech32Prefix
+	}
+	if len(info.Bech32Prefix) > 0 && info.Bech32Prefix != prefix {
+		return nil, errors.Errorf("chain uses address prefix %s but config has %s, update FairyRingNode.bech32Prefix", info.Bech32Prefix, prefix)
+	}
+
+	var warnings []string
+	if len(info.Denom) > 0 && info.Denom != node.Denom {
+		warnings = append(warnings, fmt.Sprintf("bond denom of the chain is %s but config has %s, the balance is monitored in %s", info.Denom, node.Denom, node.Denom))
+	}
+
+	if info.MinGasPrices.IsZero() {
+		return warnings, nil
+	}
+	if len(node.GasPrice) == 0 {
+		return append(warnings, fmt.Sprintf("node requires a minimum gas price of %s, txs without fee are rejected, set FairyRingNode.gasPrice", info.MinGasPrices.String())), nil
+	}
+
+	gasPrice, err := cosmostypes.ParseDecCoin(node.GasPrice)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid gas price %s", node.GasPrice)
+	}
+	minAmount := info.MinGasPrices.AmountOf(gasPrice.Denom)
+	switch {
+	case minAmount.IsZero():
+		warnings = append(warnings, fmt.Sprintf("node does not accept fees in %s, minimum gas prices: %s", gasPrice.Denom, info.MinGasPrices.String()))
+	case gasPrice.Amount.LT(minAmount):
+		warnings = append(warnings, fmt.Sprintf("gas price %s is below the minimum gas price %s%s of the node, txs are rejected", node.GasPrice, minAmount.String(), gasPrice.Denom))
+	}
+	return warnings, nil
+}
+
+// discoverChain checks the config against the chain discovered from the node, the warnings are logged
+func discoverChain(cfg config.Config) error {
+	info, err := cosmosClient.DiscoverChain(cfg.GetGRPCEndpoint(), cfg.GetGRPCDialOptions()...)
+	if err != nil {
+		return errors.Wrap(err, "error discovering chain from node")
+	}
+
+	warnings, err := CheckChainInfo(cfg.FairyRingNode, info)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		log.Printf("WARNING: %s\n", warning)
+	}
+
+	log.Printf("Discovered chain %s from node, denom: %s, address prefix: %s\n", info.ChainID, info.Denom, info.Bech32Prefix)
+	return nil
+}
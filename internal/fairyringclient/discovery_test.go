@@ -0,0 +1,66 @@
+package fairyringclient
+
+import (
+	"strings"
+	"testing"
+
+	"fairyringclient/config"
+	"fairyringclient/pkg/cosmosClient"
+
+	"cosmossdk.io/math"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestCheckChainInfo(t *testing.T) {
+	node := config.DefaultConfig(false).FairyRingNode
+	info := func(modify func(info *cosmosClient.ChainInfo)) *cosmosClient.ChainInfo {
+		i := &cosmosClient.ChainInfo{ChainID: node.ChainID, Denom: node.Denom, Bech32Prefix: "fairy"}
+		modify(i)
+		return i
+	}
+	minGasPrices := cosmostypes.NewDecCoins(cosmostypes.NewDecCoinFromDec("ufairy", math.LegacyMustNewDecFromStr("0.025")))
+
+	for _, tc := range []struct {
+		name     string
+		gasPrice string
+		info     *cosmosClient.ChainInfo
+		err      string
+		warning  string
+	}{
+		{name: "matching", info: info(func(*cosmosClient.ChainInfo) {})},
+		{name: "prefix not exposed", info: info(func(i *cosmosClient.ChainInfo) { i.Bech32Prefix = "" })},
+		{name: "chain id", info: info(func(i *cosmosClient.ChainInfo) { i.ChainID = "other" }), err: "node runs chain other"},
+		{name: "prefix", info: info(func(i *cosmosClient.ChainInfo) { i.Bech32Prefix = "cosmos" }), err: "address prefix cosmos"},
+		{name: "denom", info: info(func(i *cosmosClient.ChainInfo) { i.Denom = "uatom" }), warning: "bond denom of the chain is uatom"},
+		{name: "no fee", info: info(func(i *cosmosClient.ChainInfo) { i.MinGasPrices = minGasPrices }), warning: "txs without fee are rejected"},
+		{name: "gas price below minimum", gasPrice: "0.01ufairy", info: info(func(i *cosmosClient.ChainInfo) { i.MinGasPrices = minGasPrices }), warning: "below the minimum gas price"},
+		{name: "gas price denom", gasPrice: "1uatom", info: info(func(i *cosmosClient.ChainInfo) { i.MinGasPrices = minGasPrices }), warning: "does not accept fees in uatom"},
+		{name: "gas price accepted", gasPrice: "0.025ufairy", info: info(func(i *cosmosClient.ChainInfo) { i.MinGasPrices = minGasPrices })},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := node
+			n.GasPrice = tc.gasPrice
+
+			warnings, err := CheckChainInfo(n, tc.info)
+			if len(tc.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+
+			if len(tc.warning) == 0 {
+				if len(warnings) > 0 {
+					t.Fatalf("expected no warning, got: %v", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0], tc.warning) {
+				t.Fatalf("expected warning containing %q, got: %v", tc.warning, warnings)
+			}
+		})
+	}
+}
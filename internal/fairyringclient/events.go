@@ -0,0 +1,186 @@
+package fairyringclient
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const fanoutCapacity = 100
+
+var fanoutDroppedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "fairyringclient_fanout_dropped_events",
+	Help: "The total number of events dropped for a subscriber falling behind by query",
+}, []string{"query"})
+
+// eventUnsubscriber is implemented by the sources the subscriptions are ended on, such as *tmclient.HTTP
+type eventUnsubscriber interface {
+	Unsubscribe(ctx context.Context, subscriber, query string) error
+}
+
+// EventFanout shares a single subscription of the source per query between the validators run in the same process.
+// The source is subscribed on the first subscriber of a query and unsubscribed once the last one leaves,
+// a subscriber joining later receives the events from then on.
+// An event is dropped for a subscriber whose buffer is full, so a validator falling behind never blocks the others.
+type EventFanout struct {
+	mu     sync.Mutex
+	source EventSource
+	subs   map[string]*fanoutQuery
+}
+
+// fanoutQuery is the source subscription of a query, it is owned by the fanout instead of a subscriber
+type fanoutQuery struct {
+	subscriber string
+	cancel     context.CancelFunc
+	outs       []chan coretypes.ResultEvent
+}
+
+func NewEventFanout(source EventSource) *EventFanout {
+	return &EventFanout{
+		source: source,
+		subs:   make(map[string]*fanoutQuery),
+	}
+}
+
+// Subscribe adds a subscriber of query until ctx is done
+func (f *EventFanout) Subscribe(ctx context.Context, subscriber, query string, _ ...int) (<-chan coretypes.ResultEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	q, subscribed := f.subs[query]
+	if !subscribed {
+		sourceCtx, cancel := context.WithCancel(context.Background())
+		in, err := f.source.Subscribe(sourceCtx, subscriber, query)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		q = &fanoutQuery{subscriber: subscriber, cancel: cancel}
+		f.subs[query] = q
+		go f.forward(sourceCtx, query, q, in)
+	}
+
+	out := make(chan coretypes.ResultEvent, fanoutCapacity)
+	q.outs = append(q.outs, out)
+
+	go func() {
+		<-ctx.Done()
+		f.unsubscribe(query, out)
+	}()
+
+	return out, nil
+}
+
+// SetSource subscribes the queries in use on source, then switches their subscribers to it & ends the subscriptions
+// of the previous source, so the subscribers keep receiving the events from the new source.
+// Nothing is switched if a query can not be subscribed on source
+func (f *EventFanout) SetSource(source EventSource) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	type subscription struct {
+		query  string
+		ctx    context.Context
+		cancel context.CancelFunc
+		in     <-chan coretypes.ResultEvent
+	}
+	subscriptions := make([]subscription, 0, len(f.subs))
+	for query, q := range f.subs {
+		ctx, cancel := context.WithCancel(context.Background())
+		in, err := source.Subscribe(ctx, q.subscriber, query)
+		if err != nil {
+			cancel()
+			for _, sub := range subscriptions {
+				sub.cancel()
+				unsubscribeSource(source, sub.query)
+			}
+			return errors.Wrapf(err, "error subscribing query %s", query)
+		}
+		subscriptions = append(subscriptions, subscription{query: query, ctx: ctx, cancel: cancel, in: in})
+	}
+
+	previous := f.source
+	f.source = source
+	for _, sub := range subscriptions {
+		current := f.subs[sub.query]
+		current.cancel()
+		unsubscribeSource(previous, sub.query)
+
+		q := &fanoutQuery{subscriber: current.subscriber, cancel: sub.cancel, outs: current.outs}
+		f.subs[sub.query] = q
+		go f.forward(sub.ctx, sub.query, q, sub.in)
+	}
+	return nil
+}
+
+func (f *EventFanout) unsubscribe(query string, out chan coretypes.ResultEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// The query is not found if the source ended the subscription
+	q, subscribed := f.subs[query]
+	if !subscribed {
+		return
+	}
+	for i, sub := range q.outs {
+		if sub == out {
+			q.outs = append(q.outs[:i:i], q.outs[i+1:]...)
+			break
+		}
+	}
+	if len(q.outs) > 0 {
+		return
+	}
+
+	// The source is unsubscribed once the last subscriber leaves, the next subscriber subscribes it again
+	delete(f.subs, query)
+	q.cancel()
+	unsubscribeSource(f.source, query)
+}
+
+// unsubscribeSource ends the subscription of query on source, nothing is done if the source does not support it
+func unsubscribeSource(source EventSource, query string) {
+	if unsubscriber, ok := source.(eventUnsubscriber); ok {
+		if err := unsubscriber.Unsubscribe(context.Background(), "", query); err != nil {
+			log.Printf("Error unsubscribing query %s: %s\n", query, err.Error())
+		}
+	}
+}
+
+func (f *EventFanout) forward(ctx context.Context, query string, q *fanoutQuery, in <-chan coretypes.ResultEvent) {
+	// The source is subscribed again by the next subscriber if the source ends the subscription
+	defer func() {
+		f.mu.Lock()
+		if f.subs[query] == q {
+			delete(f.subs, query)
+		}
+		f.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-in:
+			if !ok {
+				return
+			}
+
+			f.mu.Lock()
+			for _, out := range q.outs {
+				select {
+				case out <- event:
+				default:
+					fanoutDroppedEvents.WithLabelValues(query).Inc()
+					log.Printf("Event subscriber of query %s is falling behind, dropped event\n", query)
+				}
+			}
+			f.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package fairyringclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+)
+
+// fakeEventSource hands out a channel per subscription, the events are sent by the test
+type fakeEventSource struct {
+	mu           sync.Mutex
+	subs         map[string][]chan coretypes.ResultEvent
+	unsubscribes map[string]int
+	// failQuery can not be subscribed
+	failQuery string
+}
+
+func (s *fakeEventSource) Subscribe(_ context.Context, _, query string, _ ...int) (<-chan coretypes.ResultEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if query == s.failQuery {
+		return nil, errors.New("subscription refused")
+	}
+	ch := make(chan coretypes.ResultEvent)
+	if s.subs == nil {
+		s.subs = make(map[string][]chan coretypes.ResultEvent)
+	}
+	s.subs[query] = append(s.subs[query], ch)
+	return ch, nil
+}
+
+func (s *fakeEventSource) Unsubscribe(_ context.Context, _, query string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.unsubscribes == nil {
+		s.unsubscribes = make(map[string]int)
+	}
+	s.unsubscribes[query]++
+	return nil
+}
+
+func (s *fakeEventSource) unsubscribed(query string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unsubscribes[query]
+}
+
+func (s *fakeEventSource) subscriptions(query string) []chan coretypes.ResultEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subs[query]
+}
+
+func receiveEvent(t *testing.T, out <-chan coretypes.ResultEvent) coretypes.ResultEvent {
+	t.Helper()
+
+	select {
+	case e := <-out:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return coretypes.ResultEvent{}
+}
+
+func TestEventFanoutSubscribesOnFirstSubscriber(t *testing.T) {
+	source := &fakeEventSource{}
+	fanout := NewEventFanout(source)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, err := fanout.Subscribe(ctx, "", "tm.event = 'NewBlock'")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got := len(source.subscriptions("tm.event = 'NewBlock'")); got != 1 {
+		t.Fatalf("expected the source subscribed on the first subscriber, got: %d subscriptions", got)
+	}
+
+	in := source.subscriptions("tm.event = 'NewBlock'")[0]
+	in <- coretypes.ResultEvent{Query: "1"}
+	if e := receiveEvent(t, first); e.Query != "1" {
+		t.Fatalf("expected event 1, got: %s", e.Query)
+	}
+
+	second, err := fanout.Subscribe(ctx, "", "tm.event = 'NewBlock'")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got := len(source.subscriptions("tm.event = 'NewBlock'")); got != 1 {
+		t.Fatalf("expected a single source subscription per query, got: %d", got)
+	}
+
+	in <- coretypes.ResultEvent{Query: "2"}
+	if e := receiveEvent(t, first); e.Query != "2" {
+		t.Fatalf("expected event 2 for the first subscriber, got: %s", e.Query)
+	}
+	if e := receiveEvent(t, second); e.Query != "2" {
+		t.Fatalf("expected event 2 for the second subscriber, got: %s", e.Query)
+	}
+}
+
+func TestEventFanoutDropsForFullSubscriber(t *testing.T) {
+	source := &fakeEventSource{}
+	fanout := NewEventFanout(source)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow, _ := fanout.Subscribe(ctx, "", "tm.event = 'Tx'")
+	fast, _ := fanout.Subscribe(ctx, "", "tm.event = 'Tx'")
+	in := source.subscriptions("tm.event = 'Tx'")[0]
+
+	// The slow subscriber never reads, the fast one keeps receiving every event
+	for i := 0; i < fanoutCapacity+10; i++ {
+		in <- coretypes.ResultEvent{}
+		receiveEvent(t, fast)
+	}
+
+	if len(slow) != fanoutCapacity {
+		t.Fatalf("expected the slow subscriber buffer to be full, got: %d", len(slow))
+	}
+}
+
+func TestEventFanoutUnsubscribesOnDone(t *testing.T) {
+	source := &fakeEventSource{}
+	fanout := NewEventFanout(source)
+
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	secondCtx, secondCancel := context.WithCancel(context.Background())
+	defer secondCancel()
+
+	_, _ = fanout.Subscribe(firstCtx, "", "tm.event = 'NewBlock'")
+	second, _ := fanout.Subscribe(secondCtx, "", "tm.event = 'NewBlock'")
+
+	// The source subscription outlives the first subscriber, the others keep receiving events
+	firstCancel()
+	waitSubscribers(t, fanout, "tm.event = 'NewBlock'", 1)
+
+	in := source.subscriptions("tm.event = 'NewBlock'")[0]
+	in <- coretypes.ResultEvent{Query: "1"}
+	if e := receiveEvent(t, second); e.Query != "1" {
+		t.Fatalf("expected event 1 after the first subscriber left, got: %s", e.Query)
+	}
+
+	// The source is unsubscribed once the last subscriber leaves, and subscribed again by the next one
+	secondCancel()
+	waitSubscribers(t, fanout, "tm.event = 'NewBlock'", 0)
+	if got := source.unsubscribed("tm.event = 'NewBlock'"); got != 1 {
+		t.Fatalf("expected the source unsubscribed once, got: %d", got)
+	}
+
+	if _, err := fanout.Subscribe(context.Background(), "", "tm.event = 'NewBlock'"); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got := len(source.subscriptions("tm.event = 'NewBlock'")); got != 2 {
+		t.Fatalf("expected the source subscribed again, got: %d subscriptions", got)
+	}
+}
+
+func TestEventFanoutSetSource(t *testing.T) {
+	source := &fakeEventSource{}
+	fanout := NewEventFanout(source)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks, _ := fanout.Subscribe(ctx, "", "tm.event = 'NewBlock'")
+	txs, _ := fanout.Subscribe(ctx, "", "tm.event = 'Tx'")
+
+	// A query can not be subscribed on the new source, the subscribers keep the source in use
+	failing := &fakeEventSource{failQuery: "tm.event = 'Tx'"}
+	if err := fanout.SetSource(failing); err == nil {
+		t.Fatal("expected error switching to a source refusing a query")
+	}
+	if got := source.unsubscribed("tm.event = 'NewBlock'") + source.unsubscribed("tm.event = 'Tx'"); got != 0 {
+		t.Fatalf("expected the source in use kept subscribed, got: %d unsubscribes", got)
+	}
+	source.subscriptions("tm.event = 'NewBlock'")[0] <- coretypes.ResultEvent{Query: "1"}
+	if e := receiveEvent(t, blocks); e.Query != "1" {
+		t.Fatalf("expected event 1 from the source in use, got: %s", e.Query)
+	}
+
+	// Each query is subscribed on the new source & the subscribers receive its events
+	next := &fakeEventSource{}
+	if err := fanout.SetSource(next); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got := source.unsubscribed("tm.event = 'NewBlock'") + source.unsubscribed("tm.event = 'Tx'"); got != 2 {
+		t.Fatalf("expected the previous source unsubscribed of both queries, got: %d", got)
+	}
+	next.subscriptions("tm.event = 'NewBlock'")[0] <- coretypes.ResultEvent{Query: "2"}
+	if e := receiveEvent(t, blocks); e.Query != "2" {
+		t.Fatalf("expected event 2 from the new source, got: %s", e.Query)
+	}
+	next.subscriptions("tm.event = 'Tx'")[0] <- coretypes.ResultEvent{Query: "3"}
+	if e := receiveEvent(t, txs); e.Query != "3" {
+		t.Fatalf("expected event 3 from the new source, got: %s", e.Query)
+	}
+
+	// The subscribers leaving unsubscribe the new source
+	cancel()
+	waitSubscribers(t, fanout, "tm.event = 'NewBlock'", 0)
+	waitSubscribers(t, fanout, "tm.event = 'Tx'", 0)
+	if got := next.unsubscribed("tm.event = 'NewBlock'"); got != 1 {
+		t.Fatalf("expected the new source unsubscribed, got: %d", got)
+	}
+}
+
+// waitSubscribers waits until the query has the number of subscribers, 0 once the source subscription ended
+func waitSubscribers(t *testing.T, fanout *EventFanout, query string, expected int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		fanout.mu.Lock()
+		subscribers := 0
+		if q, found := fanout.subs[query]; found {
+			subscribers = len(q.outs)
+		}
+		fanout.mu.Unlock()
+		if subscribers == expected {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d subscribers of %s, got: %d", expected, query, subscribers)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
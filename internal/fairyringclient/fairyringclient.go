@@ -5,10 +5,17 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fairyringclient/config"
+	"fairyringclient/internal/audit"
+	"fairyringclient/internal/chainevents"
+	"fairyringclient/internal/election"
+	"fairyringclient/internal/eventlog"
+	"fairyringclient/internal/logging"
+	"fairyringclient/internal/notifier"
 	"fairyringclient/pkg/cosmosClient"
 	"fmt"
-	"net/http"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/cosmos/cosmos-sdk/types/tx"
@@ -17,7 +24,6 @@ import (
 	"github.com/Fairblock/fairyring/x/keyshare/types"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"log"
 	"strconv"
@@ -31,163 +37,349 @@ import (
 )
 
 var (
-	validatorCosmosClient *ValidatorClients
-)
-
-var (
-	invalidShareSubmitted = promauto.NewCounter(prometheus.CounterOpts{
+	invalidShareSubmitted = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "fairyringclient_invalid_share_submitted",
 		Help: "The total number of invalid key share submitted",
-	})
-	validShareSubmitted = promauto.NewCounter(prometheus.CounterOpts{
+	}, []string{"validator"})
+	validShareSubmitted = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "fairyringclient_valid_share_submitted",
 		Help: "The total number of valid key share submitted",
-	})
-	failedShareSubmitted = promauto.NewCounter(prometheus.CounterOpts{
+	}, []string{"validator"})
+	failedShareSubmitted = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "fairyringclient_failed_share_submitted",
 		Help: "The total number of key share failed to submit",
-	})
-	currentShareExpiry = promauto.NewGauge(prometheus.GaugeOpts{
+	}, []string{"validator"})
+	currentShareExpiry = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "fairyringclient_current_share_expiry",
 		Help: "The expiry block of current key share",
-	})
-	latestProcessedHeight = promauto.NewGauge(prometheus.GaugeOpts{
+	}, []string{"validator"})
+	latestProcessedHeight = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "fairyringclient_latest_processed_height",
 		Help: "The latest height that submitted keyshare",
-	})
-	latestSubmitKeyshare = promauto.NewGauge(prometheus.GaugeOpts{
+	}, []string{"validator"})
+	latestSubmitKeyshare = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "fairyringclient_latest_submit_keyshare_height",
 		Help: "Get latest submit keyshare block height",
-	})
+	}, []string{"validator"})
+	invalidDerivedKeyShare = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fairyringclient_invalid_derived_keyshare",
+		Help: "The total number of derived key share failed self verification and not submitted",
+	}, []string{"validator"})
 )
 
-func StartFairyRingClient(cfg config.Config) {
+// replayDrainTimeout is the time given to the handlers of the last replayed event before stopping the replay
+const replayDrainTimeout = 3 * time.Second
+
+type StartOptions struct {
+	DryRun bool
+	// RecordEvents is the path of the event log the received events are written to, recording is disabled if empty
+	RecordEvents string
+}
 
-	PauseThreshold := cfg.InvalidSharePauseThreshold
+func StartFairyRingClient(cfg config.Config, opts StartOptions) {
+	if err := logging.SetLevel(cfg.LogLevel); err != nil {
+		log.Fatal(err)
+	}
 
-	vCosmosClient, client, err := InitializeValidatorClient(cfg)
+	validators, client, err := InitializeValidatorClients(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	validatorCosmosClient = vCosmosClient
+	var events EventSource = client
+	var recorder *eventlog.Recorder
+	if len(opts.RecordEvents) > 0 {
+		recorder, err = eventlog.NewRecorder(client, opts.RecordEvents)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer recorder.Close()
+
+		events = recorder
+		log.Printf("Recording events to: %s\n", recorder.Path())
+	}
+
+	// The events of a new RPC endpoint are recorded to the same event log
+	dialEvents := func(cfg config.Config) (EventSource, func(), error) {
+		client, err := newEventClient(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		closeClient := func() { _ = client.Stop() }
+		if recorder != nil {
+			return recorder.ForSource(client), closeClient, nil
+		}
+		return client, closeClient, nil
+	}
 
-	if !validatorCosmosClient.IsAccountAuthorized() {
-		validatorCosmosClient.RegisterValidatorSet()
+	fanout := NewEventFanout(events)
+	for _, v := range validators {
+		v.Events = fanout
+		if opts.DryRun {
+			v.EnableDryRun()
+		}
+	}
+
+	metrics, err := NewMetricsServer(cfg.MetricsPort)
+	if err != nil {
+		log.Printf("Error listening metrics on port %d: %s\n", cfg.MetricsPort, err.Error())
 	} else {
-		log.Println("Account is Authorized, skip registering in keyshare module.")
+		log.Printf("Metrics is listening on port: %d\n", cfg.MetricsPort)
 	}
 
-	_ = validatorCosmosClient.UpdateKeyShareFromChain(false)
-	_ = validatorCosmosClient.UpdateKeyShareFromChain(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	out, err := client.Subscribe(context.Background(), "", "tm.event = 'NewBlock'")
+	configPath, err := config.ConfigFilePath()
 	if err != nil {
 		log.Fatal(err)
 	}
+	reloader := NewConfigReloader(cfg, validators, metrics)
+	reloader.SetEventSource(fanout, dialEvents, func() { _ = client.Stop() })
+	go reloader.Watch(ctx, configPath)
 
-	txOut, err := client.Subscribe(context.Background(), "", "tm.event = 'Tx'")
+	electionDone, err := startElection(ctx, validators)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	RunValidators(ctx, validators)
+
+	// Release the HA lease before exiting, so a standby takes over on the next block
+	cancel()
+	<-electionDone
+	reloader.Close()
+}
+
+// EnableDryRun derives & verifies the keyshares without submitting them, no notification is sent
+// and the policies never exit the process
+func (v *ValidatorClients) EnableDryRun() {
+	v.DryRun = true
+	v.SetNotifier(nil)
+}
+
+// RunValidators runs each validator until all of them stop
+func RunValidators(ctx context.Context, validators []*ValidatorClients) {
+	var wg sync.WaitGroup
+	for _, v := range validators {
+		wg.Add(1)
+		go func(v *ValidatorClients) {
+			defer wg.Done()
+			if err := v.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Validator %s (%s) stopped: %s\n", v.Name, v.Broadcaster.GetAddress(), err.Error())
+			}
+		}(v)
+	}
+	wg.Wait()
+}
+
+// ReplayEvents runs the client in dry run mode on the events recorded in the event log at path,
+// the chain state is still queried from the node in config
+func ReplayEvents(cfg config.Config, path string, speed float64) {
+	if err := logging.SetLevel(cfg.LogLevel); err != nil {
+		log.Fatal(err)
+	}
+
+	entries, err := eventlog.ReadEntries(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	validators, client, err := InitializeValidatorClients(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	defer client.Stop()
 
-	go handleTxEvents(txOut)
+	// Each validator replays all the events from its own replayer, so none is missed by a validator subscribing later
+	replayers := make([]*eventlog.Replayer, len(validators))
+	for i, v := range validators {
+		replayers[i] = eventlog.NewReplayer(entries, speed)
+		v.EnableDryRun()
+		v.Events = replayers[i]
+		// The replay never takes part in the HA election of the running replicas
+		v.Elector = nil
+	}
 
-	http.Handle("/metrics", promhttp.Handler())
-	log.Printf("Metrics is listening on port: %d\n", cfg.MetricsPort)
-	go http.ListenAndServe(fmt.Sprintf(":%d", cfg.MetricsPort), nil)
+	log.Printf("Replaying %d events from: %s\n", len(entries), path)
 
+	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
-		if err := validatorCosmosClient.CosmosClient.HandleTxQueue(); err != nil {
-			log.Printf("Error in queued tx handler: %v", err)
+		for _, r := range replayers {
+			<-r.Done()
 		}
+		time.Sleep(replayDrainTimeout)
+		cancel()
 	}()
 
+	RunValidators(ctx, validators)
+	log.Println("Replay finished")
+}
+
+// Run registers the account in keyshare module if needed, then derives & submits the key shares
+// for each new block from the event source until ctx is done
+func (v *ValidatorClients) Run(ctx context.Context) error {
+	if v.DryRun {
+		log.Println("Running in dry run mode, key shares will be derived & verified but never submitted")
+	}
+
+	v.registerIfUnauthorized()
+
+	_ = v.UpdateKeyShareFromChain(false)
+	_ = v.UpdateKeyShareFromChain(true)
+
+	out, err := v.Events.Subscribe(ctx, "", "tm.event = 'NewBlock'")
+	if err != nil {
+		return err
+	}
+
+	txOut, err := v.Events.Subscribe(ctx, "", "tm.event = 'Tx'")
+	if err != nil {
+		return err
+	}
+
+	go v.handleTxEvents(txOut)
+
+	if !v.DryRun {
+		go func() {
+			if err := v.Broadcaster.HandleTxQueue(); err != nil {
+				log.Printf("Error in queued tx handler: %v", err)
+			}
+		}()
+	}
+
+	policyTicker := time.NewTicker(policyCheckInterval)
+	defer policyTicker.Stop()
+
+	var latestHeight uint64
 	for {
 		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-policyTicker.C:
+			v.EvaluatePolicies(latestHeight)
 		case result := <-out:
 			newBlock := result.Data.(tmtypes.EventDataNewBlock)
 
 			height := newBlock.Block.Height
 			fmt.Println("")
 
+			latestHeight = uint64(height)
+			v.Policy.ObserveBlock(latestHeight)
+
 			totalEventList := newBlock.ResultFinalizeBlock.Events
 			for _, txResult := range newBlock.ResultFinalizeBlock.TxResults {
 				totalEventList = append(totalEventList, txResult.Events...)
 			}
 
-			go handleEndBlockEvents(totalEventList)
+			go v.handleEndBlockEvents(totalEventList)
+
+			go v.CheckBalance(uint64(height))
+
+			go v.VerifyAggregatedKey(uint64(height))
 
 			processHeight := uint64(height + 1)
 			processHeightStr := strconv.FormatUint(processHeight, 10)
 
-			log.Printf("Latest Block Height: %d | Deriving Share for Height: %s\n", height, processHeightStr)
+			logging.Infof("Latest Block Height: %d | Deriving Share for Height: %s\n", height, processHeightStr)
 
-			if validatorCosmosClient.CurrentShare == nil {
+			currentShare, currentExpiry := v.GetCurrentShare()
+			if currentShare == nil {
 				log.Println("Current Share not found, Getting Share from FairyRing")
-				if err := validatorCosmosClient.UpdateKeyShareFromChain(false); err != nil {
+				if err := v.UpdateKeyShareFromChain(false); err != nil {
 					continue
 				}
+				currentShare, currentExpiry = v.GetCurrentShare()
 			}
-			log.Printf("Current Share Expires at: %d, in %d blocks | %v",
-				validatorCosmosClient.CurrentShareExpiryBlock,
-				validatorCosmosClient.CurrentShareExpiryBlock-uint64(height),
-				validatorCosmosClient.CurrentShare.Share,
+			logging.Debugf("Current Share Expires at: %d, in %d blocks | %v",
+				currentExpiry,
+				currentExpiry-uint64(height),
+				currentShare.Share,
 			)
-			if validatorCosmosClient.PendingShare != nil {
-				log.Printf("Pending Share expires at: %d, in %d blocks | %v",
-					validatorCosmosClient.PendingShareExpiryBlock,
-					validatorCosmosClient.PendingShareExpiryBlock-uint64(height),
-					validatorCosmosClient.PendingShare.Share,
+			pendingShare, pendingExpiry := v.GetPendingShare()
+			if pendingShare != nil {
+				logging.Debugf("Pending Share expires at: %d, in %d blocks | %v",
+					pendingExpiry,
+					pendingExpiry-uint64(height),
+					pendingShare.Share,
 				)
 			}
 			// When it is time to switch key share
-			if validatorCosmosClient.CurrentShareExpiryBlock != 0 && validatorCosmosClient.CurrentShareExpiryBlock <= processHeight {
+			if currentExpiry != 0 && currentExpiry <= processHeight {
 				log.Println("Current share expired, Switching to the queued one")
-				validatorCosmosClient.RemoveCurrentShare()
+				v.RemoveCurrentShare()
 
 				// But pending key share not found
-				if validatorCosmosClient.PendingShare == nil {
+				if pendingShare == nil {
 					log.Println("Pending share not found, Getting share from FairyRing now")
-					if err = validatorCosmosClient.UpdateKeyShareFromChain(true); err != nil {
+					if err = v.UpdateKeyShareFromChain(true); err != nil {
+						v.notify(notifier.Event{
+							Type:    notifier.EventShareMissing,
+							Message: "Current share expired but share for the upcoming round is not found",
+							Height:  processHeight,
+							Fields:  map[string]string{"error": err.Error()},
+						})
 						continue
 					}
 				}
 
-				validatorCosmosClient.ResetInvalidShareNum()
+				v.ResetInvalidShareNum()
 
-				if validatorCosmosClient.Paused {
-					validatorCosmosClient.Unpause()
-					log.Printf("Client Unpaused, Current invalid share count: %d\n", validatorCosmosClient.InvalidShareInARow)
+				v.ActivatePendingShare()
+				currentShare, currentExpiry = v.GetCurrentShare()
+				if currentShare == nil {
+					log.Println("Pending share removed while activating, Getting share from FairyRing on next block")
+					continue
 				}
-
-				validatorCosmosClient.ActivatePendingShare()
-				log.Printf("Activated pending key share, New Share: %v\n", validatorCosmosClient.CurrentShare.Share.Value.String())
+				log.Printf("Activated pending key share, New Share: %v\n", currentShare.Share.Value.String())
+				v.notify(notifier.Event{
+					Type:    notifier.EventShareRotation,
+					Message: "Activated pending key share",
+					Height:  processHeight,
+					Fields: map[string]string{
+						"index":  strconv.FormatUint(currentShare.Index, 10),
+						"expiry": strconv.FormatUint(currentExpiry, 10),
+					},
+				})
 			}
 
-			go func() {
-				defer currentShareExpiry.Set(float64(validatorCosmosClient.CurrentShareExpiryBlock))
-			}()
+			currentShareExpiry.WithLabelValues(v.Broadcaster.GetAddress()).Set(float64(currentExpiry))
 
-			if validatorCosmosClient.Paused {
-				log.Printf("Client paused, Skip submitting keyshare for height %s, Waiting until next round\n", processHeightStr)
-				return
+			v.EvaluatePolicies(latestHeight)
+
+			if v.Paused() {
+				log.Printf("Client paused, Skip submitting keyshare for height %s, Waiting until the pausing policies no longer hold\n", processHeightStr)
+				continue
 			}
 
-			extractedKeyHex, keyShareIndex, err := validatorCosmosClient.DeriveKeyShare([]byte(processHeightStr))
-			if err != nil {
-				log.Fatal(err)
+			usedShare := currentShare
+			extractedKeyHex, keyShareIndex, found := v.precomputedKeyShare(processHeight, usedShare)
+			if !found {
+				extractedKeyHex, keyShareIndex, err = v.DeriveKeyShareFrom(usedShare, []byte(processHeightStr))
+				if err != nil {
+					v.handleDeriveKeyShareError(err, "height "+processHeightStr)
+					continue
+				}
 			}
 
-			validatorCosmosClient.CosmosClient.AddTxToQueue(&types.MsgSendKeyshare{
-				Creator:       validatorCosmosClient.CosmosClient.GetAddress(),
+			go v.PrecomputeKeyShares(processHeight + 1)
+
+			submission := audit.Record{
+				Type:        audit.TypeKeyshare,
+				Height:      processHeight,
+				ShareIndex:  keyShareIndex,
+				SubmittedAt: time.Now(),
+			}
+
+			v.SubmitTx(string(audit.TypeKeyshare), &types.MsgSendKeyshare{
+				Creator:       v.Broadcaster.GetAddress(),
 				Message:       extractedKeyHex,
 				KeyshareIndex: keyShareIndex,
 				BlockHeight:   processHeight,
-			}, true,
+			},
 				func(err error) {
+					v.RecordSubmission(submission, nil, err)
 					log.Printf("Submit KeyShare for Height %s ERROR: %s\n", processHeightStr, err.Error())
 					if strings.Contains(err.Error(), "transaction indexing is disabled") {
 						log.Fatal("Transaction indexing is disabled on the node, please enable it or use another node with tx indexing, exiting FairyRingClient")
@@ -195,87 +387,190 @@ func StartFairyRingClient(cfg config.Config) {
 					if strings.Contains(err.Error(), "account sequence mismatch") {
 						log.Println("Account sequence mismatch, when submitting keyshares")
 					}
+					v.RecordSubmissionFailure(processHeight, err.Error())
 				},
 				func(txResp *tx.GetTxResponse) {
 					if hasCoinSpentEvent(txResp.TxResponse.Events) {
-						validatorCosmosClient.IncreaseInvalidShareNum()
-						log.Printf("KeyShare for Height %s is INVALID, Got Slashed, Current number invalid share in a row: %d\n", processHeightStr, validatorCosmosClient.InvalidShareInARow)
-
-						defer invalidShareSubmitted.Inc()
-
-						if validatorCosmosClient.InvalidShareInARow >= PauseThreshold {
-							validatorCosmosClient.Pause()
-							log.Printf("Client paused due to number of invalid share in a row '%d' reaches threshold '%d', Waiting until next round\n", validatorCosmosClient.InvalidShareInARow, PauseThreshold)
-						}
+						invalidShares := v.IncreaseInvalidShareNum()
+						log.Printf("KeyShare for Height %s is INVALID, Got Slashed, Current number invalid share in a row: %d\n", processHeightStr, invalidShares)
+
+						defer invalidShareSubmitted.WithLabelValues(v.Broadcaster.GetAddress()).Inc()
+
+						cause := v.HealInvalidShare(usedShare)
+						log.Printf("Root cause of invalid KeyShare for Height %s: %s\n", processHeightStr, cause)
+						submission.RootCause = string(cause)
+						v.RecordSubmission(submission, txResp, nil)
+
+						v.notify(notifier.Event{
+							Type:    notifier.EventInvalidShare,
+							Message: "Submitted keyshare is invalid, got slashed",
+							Height:  processHeight,
+							Fields: map[string]string{
+								"txHash":            txResp.TxResponse.TxHash,
+								"invalidShareInRow": strconv.FormatUint(invalidShares, 10),
+								"rootCause":         string(cause),
+							},
+						})
 
 						return
 					}
 
+					v.RecordSubmission(submission, txResp, nil)
+
 					if txResp.TxResponse.Code != 0 {
 						log.Printf("KeyShare for Height %s Failed: %s\n", processHeightStr, txResp.TxResponse.RawLog)
-						defer failedShareSubmitted.Inc()
+						defer failedShareSubmitted.WithLabelValues(v.Broadcaster.GetAddress()).Inc()
+						v.RecordSubmissionFailure(processHeight, txResp.TxResponse.RawLog)
 						return
 					}
-					log.Printf("Submit KeyShare for Height %s Confirmed\n", processHeightStr)
-					latestSubmitKeyshare.Set(float64(processHeight))
-					defer validShareSubmitted.Inc()
+					logging.Infof("Submit KeyShare for Height %s Confirmed\n", processHeightStr)
+					v.ResetFailedSubmissionNum()
+					latestSubmitKeyshare.WithLabelValues(v.Broadcaster.GetAddress()).Set(float64(processHeight))
+					defer validShareSubmitted.WithLabelValues(v.Broadcaster.GetAddress()).Inc()
 				})
 
-			latestProcessedHeight.Set(float64(processHeight))
+			latestProcessedHeight.WithLabelValues(v.Broadcaster.GetAddress()).Set(float64(processHeight))
 		}
 	}
 }
 
-func InitializeValidatorClient(cfg config.Config) (*ValidatorClients, *tmclient.HTTP, error) {
+// InitializeValidatorClients creates a client for each validator in config, sharing the node connection,
+// the notifier, the audit log & the HA elector
+func InitializeValidatorClients(cfg config.Config) ([]*ValidatorClients, *tmclient.HTTP, error) {
 	denom := cfg.FairyRingNode.Denom
 
 	if len(denom) == 0 {
 		return nil, nil, errors.New("denom not found in config")
 	}
 
+	validatorsCfg := cfg.GetValidators()
+	if len(validatorsCfg) == 0 {
+		log.Fatal("Private Key is empty in config file, please add a valid cosmos account private key before starting")
+	}
+
 	gRPCEndpoint := cfg.GetGRPCEndpoint()
 
-	client, err := tmclient.New(
-		fmt.Sprintf(
-			"%s://%s:%d",
-			cfg.FairyRingNode.Protocol,
-			cfg.FairyRingNode.IP,
-			cfg.FairyRingNode.Port,
-		),
-		"/websocket",
-	)
-	if err != nil {
+	cosmosClient.SetBech32Prefix(cfg.FairyRingNode.Bech32Prefix)
+	if err := discoverChain(cfg); err != nil {
 		return nil, nil, err
 	}
 
-	if err = client.Start(); err != nil {
+	client, err := newEventClient(cfg)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	if len(cfg.PrivateKey) == 0 {
-		log.Fatal("Private Key is empty in config file, please add a valid cosmos account private key before starting")
+	n, err := notifier.New(cfg.Notifier)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating notifier")
 	}
 
-	vCosmosClient, err := cosmosClient.NewCosmosClient(
-		gRPCEndpoint,
-		cfg.PrivateKey,
-		cfg.FairyRingNode.ChainID,
-	)
+	homeDir, err := config.HomeDir()
+	if err != nil {
+		return nil, nil, err
+	}
 
+	auditLog, err := audit.Open(filepath.Join(homeDir, audit.DefaultFileName))
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "error creating custom cosmos client, make sure provided account is activated")
+		return nil, nil, errors.Wrap(err, "error opening submission audit log")
 	}
+	log.Printf("Recording submissions to: %s\n", auditLog.Path())
+
+	elector, err := election.New(cfg.HA)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating HA elector")
+	}
+
+	validators := make([]*ValidatorClients, 0, len(validatorsCfg))
+	addresses := make(map[string]string)
+	for _, validatorCfg := range validatorsCfg {
+		if len(validatorCfg.PrivateKey) == 0 {
+			return nil, nil, errors.Errorf("private key of validator %s is empty", validatorCfg.Name)
+		}
+
+		vCosmosClient, err := cosmosClient.NewCosmosClient(
+			gRPCEndpoint,
+			validatorCfg.PrivateKey,
+			cfg.FairyRingNode.ChainID,
+			cfg.GetGRPCDialOptions()...,
+		)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error creating custom cosmos client for validator %s, make sure provided account is activated", validatorCfg.Name)
+		}
+		if err = vCosmosClient.SetGasPrice(cfg.FairyRingNode.GasPrice); err != nil {
+			return nil, nil, err
+		}
+
+		addr := vCosmosClient.GetAddress()
+		if name, found := addresses[addr]; found {
+			return nil, nil, errors.Errorf("validator %s has the same key as validator %s", validatorCfg.Name, name)
+		}
+		addresses[addr] = validatorCfg.Name
+		log.Printf("Validator %s Cosmos Client Loaded Address: %s\n", validatorCfg.Name, addr)
+
+		bal, err := vCosmosClient.GetBalance(denom)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error getting account balance")
+		}
+		log.Printf("Address: %s , Balance: %s %s\n", addr, bal.String(), denom)
 
-	addr := vCosmosClient.GetAddress()
-	log.Printf("Validator Cosmos Client Loaded Address: %s\n", addr)
+		v := NewValidatorClients(cfg, vCosmosClient, vCosmosClient, client)
+		v.Name = validatorCfg.Name
+		v.SetNotifier(n)
+		v.AuditLog = auditLog
+		v.Elector = elector
 
-	bal, err := vCosmosClient.GetBalance(denom)
+		validators = append(validators, v)
+	}
+
+	return validators, client, nil
+}
+
+// newEventClient connects to the websocket of the RPC endpoint of the node, the events are subscribed on it
+func newEventClient(cfg config.Config) (*tmclient.HTTP, error) {
+	client, err := tmclient.New(cfg.GetFairyRingNodeURI(), "/websocket")
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "error getting account balance")
+		return nil, err
 	}
-	log.Printf("Address: %s , Balance: %s %s\n", vCosmosClient.GetAddress(), bal.String(), denom)
 
-	return &ValidatorClients{CosmosClient: vCosmosClient}, client, nil
+	if err = client.Start(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewValidatorClients creates a client submitting key shares through the given chain querier, tx broadcaster & event source.
+// The notifier & AuditLog are optional, they are left empty and can be set by the caller.
+func NewValidatorClients(
+	cfg config.Config,
+	querier ChainQuerier,
+	broadcaster TxBroadcaster,
+	events EventSource,
+) *ValidatorClients {
+	v := &ValidatorClients{
+		Querier:               querier,
+		Broadcaster:           broadcaster,
+		Events:                events,
+		BalanceMonitor:        NewBalanceMonitor(broadcaster.GetAddress(), cfg.FairyRingNode.Denom, cfg.BalanceMonitor),
+		AggregatedKeyVerifier: &AggregatedKeyVerifier{},
+		Precomputer:           NewKeySharePrecomputer(broadcaster.GetAddress(), cfg.Precompute.Heights, cfg.Precompute.Workers),
+		Policy:                NewPolicyEngine(cfg.PolicyRules()),
+		handledRequests:       newRequestTracker(),
+	}
+	v.TxEventHandlers = v.txEventHandlers()
+	return v
+}
+
+// handleDeriveKeyShareError skips submitting the keyshare that failed to be derived or failed self verification,
+// such as when the active share is not found, and refreshes the shares in the background
+func (v *ValidatorClients) handleDeriveKeyShareError(err error, target string) {
+	if errors.Is(err, ErrInvalidDerivedKeyShare) {
+		invalidDerivedKeyShare.WithLabelValues(v.Broadcaster.GetAddress()).Inc()
+		log.Printf("Derived KeyShare for %s is INVALID, Skip submitting: %s\n", target, err.Error())
+	} else {
+		log.Printf("Error deriving KeyShare for %s, Skip submitting: %s\n", target, err.Error())
+	}
+	v.TriggerShareRefresh()
 }
 
 func hasCoinSpentEvent(e []abciTypes.Event) bool {
@@ -287,107 +582,96 @@ func hasCoinSpentEvent(e []abciTypes.Event) bool {
 	return false
 }
 
-func handleTxEvents(txOut <-chan coretypes.ResultEvent) {
-	for {
-		select {
-		case result := <-txOut:
-			for k := range result.Events {
-				switch k {
-				case "queued-pubkey-created.pubkey":
-					handleNewPubKeyEvent(result.Events)
-					break
-				case "pubkey-overrode.pubkey":
-					handlePubKeyOverrodeEvent(result.Events)
-					break
-				}
-			}
-		}
-	}
+// txEventHandlers registers the handlers of the events of the tx subscription, the pubkey rotations.
+// The events of txs are in new blocks too, so each type is only handled by one of the registries
+func (v *ValidatorClients) txEventHandlers() *chainevents.Registry {
+	r := chainevents.NewRegistry()
+	chainevents.On(r, v.handleNewPubKeyEvent)
+	chainevents.On(r, v.handlePubKeyOverrodeEvent)
+	return r
 }
 
-func handleEndBlockEvents(events []abciTypes.Event) {
-	for _, e := range events {
-		if e.Type == "start-send-encrypted-keyshare" {
-			var id, secpPubkey, requester string
-			for _, a := range e.Attributes {
-				if a.Key == "identity" {
-					id = a.Value
-				}
-				if a.Key == "requester" {
-					requester = a.Value
-				}
-				if a.Key == "secp256k1-pubkey" {
-					secpPubkey = a.Value
-				}
-			}
-
-			if len(id) < 1 {
-				log.Printf("Empty Identity detected in start send private key share event")
-				continue
-			}
-
-			handleStartSubmitEncryptedKeyShareEvent(id, secpPubkey, requester)
-			continue
-		}
-
-		if e.Type != "start-send-general-keyshare" {
-			continue
-		}
-		for _, a := range e.Attributes {
-
-			if a.Key != "identity" {
-				continue
-			}
-
-			identity := a.Value
-			if len(identity) < 1 {
-				log.Printf("Empty Identity detected in start send general key share event")
-				return
-			}
+// dispatchEvents calls the handlers of the events, the events failing validation are logged & skipped
+func dispatchEvents(r *chainevents.Registry, events []chainevents.Raw) {
+	for _, err := range r.Dispatch(events) {
+		log.Printf("Skip invalid event: %s\n", err.Error())
+	}
+}
 
-			handleStartSubmitGeneralKeyShareEvent(identity)
-			return
-		}
+func (v *ValidatorClients) handleTxEvents(txOut <-chan coretypes.ResultEvent) {
+	for result := range txOut {
+		dispatchEvents(v.TxEventHandlers, chainevents.FromTxEvents(result.Events))
 	}
 }
 
-func handleStartSubmitEncryptedKeyShareEvent(
+// handleEndBlockEvents handles every general & encrypted keyshare request in the events of a block
+func (v *ValidatorClients) handleEndBlockEvents(events []abciTypes.Event) []keyshareRequestResult {
+	var requests []keyshareRequest
+	dispatchEvents(v.blockEventHandlers(&requests), chainevents.FromABCI(events))
+	return v.handleKeyshareRequests(requests)
+}
+
+func (v *ValidatorClients) handleStartSubmitEncryptedKeyShareEvent(
 	identity string,
 	secpPubkey string,
 	requester string,
-) {
-	log.Printf("Start Submitting Encrypted Key Share for identity: %s pubkey: %s requester: %s", identity, secpPubkey, requester)
-	derivedShare, index, err := validatorCosmosClient.DeriveKeyShare([]byte(identity))
+) string {
+	if v.OptionalDutiesPaused() {
+		log.Printf("Optional duties paused, Skip submitting encrypted key share for identity: %s", identity)
+		return requestOutcomeSkipped
+	}
+
+	logging.Infof("Start Submitting Encrypted Key Share for identity: %s pubkey: %s requester: %s", identity, secpPubkey, requester)
+	derivedShare, index, err := v.DeriveKeyShare([]byte(identity))
 	if err != nil {
-		log.Fatal(err)
+		v.handleDeriveKeyShareError(err, "identity "+identity)
+		return requestOutcomeFailed
 	}
-	log.Printf("Derived Private Key Share: %s\n", derivedShare)
+	logging.Debugf("Derived Private Key Share: %s\n", derivedShare)
 
 	// Encrypt the message
 	encryptedMessage, err := encryptWithPublicKey(derivedShare, secpPubkey)
 	if err != nil {
 		fmt.Printf("Error encrypting message: %s\n", err)
-		return
+		return requestOutcomeFailed
+	}
+
+	submission := audit.Record{
+		Type:        audit.TypeEncryptedKeyshare,
+		Identity:    identity,
+		Requester:   requester,
+		ShareIndex:  index,
+		SubmittedAt: time.Now(),
 	}
 
-	validatorCosmosClient.CosmosClient.AddTxToQueue(&types.MsgSubmitEncryptedKeyshare{
-		Creator:           validatorCosmosClient.CosmosClient.GetAddress(),
+	queued := v.SubmitTx(string(audit.TypeEncryptedKeyshare), &types.MsgSubmitEncryptedKeyshare{
+		Creator:           v.Broadcaster.GetAddress(),
 		Identity:          identity,
 		KeyshareIndex:     index,
 		Requester:         requester,
 		EncryptedKeyshare: encryptedMessage,
-	}, true,
+	},
 		func(err error) {
+			v.RecordSubmission(submission, nil, err)
 			log.Printf("Submit Private KeyShare for Identity %s Requester %s Failed: %s\n", identity, requester, err.Error())
+			v.RecordSubmissionFailure(0, err.Error())
 		},
 		func(txResp *tx.GetTxResponse) {
+			v.RecordSubmission(submission, txResp, nil)
 			if txResp.TxResponse.Code != 0 {
 				log.Printf("Private KeyShare for Identity %s Requester %s Failed: %s\n", identity, requester, txResp.TxResponse.RawLog)
+				v.RecordSubmissionFailure(0, txResp.TxResponse.RawLog)
 				return
 			} else {
-				log.Printf("Private KeyShare for Identity %s Requester %s Confirmed\n", identity, requester)
+				logging.Infof("Private KeyShare for Identity %s Requester %s Confirmed\n", identity, requester)
+				v.ResetFailedSubmissionNum()
 			}
 		})
+	if !queued {
+		return requestOutcomeSkipped
+	}
+
+	return requestOutcomeSubmitted
 }
 
 // This function encrypts data using an RSA public key.
@@ -413,83 +697,106 @@ func encryptWithPublicKey(data string, pubKeyBase64 string) (string, error) {
 	return hex.EncodeToString(ciphertext), nil
 }
 
-func handleStartSubmitGeneralKeyShareEvent(identity string) {
-	log.Printf("Start Submitting General Key Share for identity: %s", identity)
-	derivedShare, index, err := validatorCosmosClient.DeriveKeyShare([]byte(identity))
+func (v *ValidatorClients) handleStartSubmitGeneralKeyShareEvent(identity string) string {
+	if v.OptionalDutiesPaused() {
+		log.Printf("Optional duties paused, Skip submitting general key share for identity: %s", identity)
+		return requestOutcomeSkipped
+	}
+
+	logging.Infof("Start Submitting General Key Share for identity: %s", identity)
+	derivedShare, index, err := v.DeriveKeyShare([]byte(identity))
 	if err != nil {
-		log.Fatal(err)
+		v.handleDeriveKeyShareError(err, "identity "+identity)
+		return requestOutcomeFailed
 	}
-	log.Printf("Derived General Key Share: %s\n", derivedShare)
+	logging.Debugf("Derived General Key Share: %s\n", derivedShare)
 
-	validatorCosmosClient.CosmosClient.AddTxToQueue(&types.MsgSubmitGeneralKeyshare{
-		Creator:       validatorCosmosClient.CosmosClient.GetAddress(),
+	submission := audit.Record{
+		Type:        audit.TypeGeneralKeyshare,
+		Identity:    identity,
+		ShareIndex:  index,
+		SubmittedAt: time.Now(),
+	}
+
+	queued := v.SubmitTx(string(audit.TypeGeneralKeyshare), &types.MsgSubmitGeneralKeyshare{
+		Creator:       v.Broadcaster.GetAddress(),
 		Keyshare:      derivedShare,
 		KeyshareIndex: index,
 		IdType:        "private-gov-identity",
 		IdValue:       identity,
-	}, true,
+	},
 		func(err error) {
+			v.RecordSubmission(submission, nil, err)
 			log.Printf("Submit General KeyShare for Identity %s ERROR: %s\n", identity, err.Error())
 			if strings.Contains(err.Error(), "account sequence") {
-				go func(id string) {
-					handleStartSubmitGeneralKeyShareEvent(id)
-				}(identity)
+				go v.retryKeyshareRequest(keyshareRequest{Type: audit.TypeGeneralKeyshare, Identity: identity})
 			}
 		},
 		func(txResp *tx.GetTxResponse) {
+			v.RecordSubmission(submission, txResp, nil)
 			if txResp.TxResponse.Code != 0 {
 				log.Printf("General KeyShare for Identity %s Failed: %s\n", identity, txResp.TxResponse.RawLog)
+				v.RecordSubmissionFailure(0, txResp.TxResponse.RawLog)
 				return
 			} else {
-				log.Printf("Submit General KeyShare for Identity %s Confirmed\n", identity)
+				logging.Infof("Submit General KeyShare for Identity %s Confirmed\n", identity)
+				v.ResetFailedSubmissionNum()
 			}
 		})
+	if !queued {
+		return requestOutcomeSkipped
+	}
+
+	return requestOutcomeSubmitted
 }
 
-func handlePubKeyOverrodeEvent(data map[string][]string) {
-	pubKey, found := data["pubkey-overrode.pubkey"]
-	if !found {
-		return
-	}
+func (v *ValidatorClients) handlePubKeyOverrodeEvent(e chainevents.PubkeyOverrode) {
+	log.Printf("Old Pubkey Overrode, New Pubkey found: %s\n", e.Pubkey)
 
-	log.Printf("Old Pubkey Overrode, New Pubkey found: %s\n", pubKey[0])
+	v.notify(notifier.Event{
+		Type:    notifier.EventShareRotation,
+		Message: "Active pubkey overrode, updating the current share",
+		Fields:  map[string]string{"pubkey": e.Pubkey},
+	})
 
 	for {
-		err := validatorCosmosClient.UpdateKeyShareFromChain(false)
+		err := v.UpdateKeyShareFromChain(false)
 		if err != nil {
 			time.Sleep(3 * time.Second)
 			continue
 		}
+		current, _ := v.GetCurrentShare()
 		log.Printf(
 			"Successfully Updated Shares for the current overrode round: %s | Index: %d",
-			validatorCosmosClient.CurrentShare.Share.Value.String(),
-			validatorCosmosClient.CurrentShare.Index,
+			current.Share.Value.String(),
+			current.Index,
 		)
-		validatorCosmosClient.RemovePendingShare()
+		v.RemovePendingShare()
+		v.Precomputer.Invalidate()
 		break
 	}
 }
 
-func handleNewPubKeyEvent(data map[string][]string) {
-	pubKey, found := data["queued-pubkey-created.pubkey"]
-	if !found {
-		return
-	}
-
-	log.Printf("New Pubkey found: %s\n", pubKey[0])
+func (v *ValidatorClients) handleNewPubKeyEvent(e chainevents.QueuedPubkeyCreated) {
+	log.Printf("New Pubkey found: %s\n", e.Pubkey)
 
 	// Get Share & Commits on chain few blocks later
 	for {
-		err := validatorCosmosClient.UpdateKeyShareFromChain(true)
+		err := v.UpdateKeyShareFromChain(true)
 		if err != nil {
 			time.Sleep(3 * time.Second)
 			continue
 		}
+		pending, _ := v.GetPendingShare()
 		log.Printf(
 			"Successfully Updated Shares for next round: %s | Index: %d",
-			validatorCosmosClient.PendingShare.Share.Value.String(),
-			validatorCosmosClient.PendingShare.Index,
+			pending.Share.Value.String(),
+			pending.Index,
 		)
 		break
 	}
 }
+
+func (v *ValidatorClients) handleGeneralKeyshareAggregatedEvent(e chainevents.GeneralKeyshareAggregated) {
+	logging.Infof("General KeyShare Aggregated for Identity %s | Type: %s\n", e.IDValue, e.IDType)
+}
@@ -0,0 +1,101 @@
+package fairyringclient
+
+import (
+	"log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// InvalidShareCause is the root cause of a keyshare found invalid on chain, found by HealInvalidShare
+type InvalidShareCause string
+
+const (
+	// CauseStaleShare means the share on chain is not the share the keyshare is derived from, such as after the active pubkey is overrode
+	CauseStaleShare InvalidShareCause = "stale_share"
+	// CauseCommitmentChanged means the share is the same but the active commitment of its index changed
+	CauseCommitmentChanged InvalidShareCause = "commitment_changed"
+	// CauseInvalidShareOnChain means the share on chain does not match the active commitments, so it cannot be healed by the client
+	CauseInvalidShareOnChain InvalidShareCause = "invalid_share_on_chain"
+	// CauseShareUnchanged means the share & commitment on chain are the ones the keyshare is derived from
+	CauseShareUnchanged InvalidShareCause = "share_unchanged"
+	// CauseQueryError means the share or the commitments could not be fetched from chain
+	CauseQueryError InvalidShareCause = "query_error"
+)
+
+var invalidShareRootCause = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "fairyringclient_invalid_share_root_cause",
+	Help: "The total number of invalid keyshares submitted by root cause",
+}, []string{"validator", "cause"})
+
+// HealInvalidShare re-fetches the active share & commitments after a keyshare derived from used is found invalid on chain,
+// verifies the fetched share and swaps the current share if it changed, so the next heights are submitted with the share on chain.
+// Returns the root cause of the invalid keyshare
+func (v *ValidatorClients) HealInvalidShare(used *KeyShare) InvalidShareCause {
+	v.shareRefreshMu.Lock()
+	defer v.shareRefreshMu.Unlock()
+
+	cause := v.healInvalidShare(used)
+	invalidShareRootCause.WithLabelValues(v.Broadcaster.GetAddress(), string(cause)).Inc()
+	return cause
+}
+
+func (v *ValidatorClients) healInvalidShare(used *KeyShare) InvalidShareCause {
+	share, index, expiry, err := v.Querier.GetKeyShare(false)
+	if err != nil {
+		log.Printf("Error fetching current share after invalid keyshare: %s\n", err.Error())
+		return CauseQueryError
+	}
+
+	commits, err := v.Querier.GetCommitments()
+	if err != nil {
+		log.Printf("Error fetching commitments after invalid keyshare: %s\n", err.Error())
+		return CauseQueryError
+	}
+
+	fetched := &KeyShare{Share: share, Index: index}
+	valid, err := verifyShare(fetched, commits.ActiveCommitments)
+	if err != nil {
+		log.Printf("Error verifying current share on chain after invalid keyshare: %s\n", err.Error())
+		v.Policy.RecordShareVerification(false)
+		return CauseInvalidShareOnChain
+	}
+	v.Policy.RecordShareVerification(valid)
+	if !valid {
+		log.Printf("Current share of index %d on chain does not match the active commitments\n", index)
+		return CauseInvalidShareOnChain
+	}
+
+	fetched.Commitment, err = parseCommitment(suite, commits.ActiveCommitments.Commitments[index-1])
+	if err != nil {
+		log.Printf("Error parsing commitment of share index %d: %s\n", index, err.Error())
+		return CauseQueryError
+	}
+
+	cause := CauseShareUnchanged
+	switch {
+	case !sameShare(used, fetched):
+		cause = CauseStaleShare
+	case !used.Commitment.Equal(fetched.Commitment):
+		cause = CauseCommitmentChanged
+	}
+
+	// The current share may have been swapped already by the heal of an earlier height
+	if current, _ := v.GetCurrentShare(); sameShare(current, fetched) && current.Commitment.Equal(fetched.Commitment) {
+		return cause
+	}
+
+	v.setShare(false, fetched, expiry, commits)
+	v.Precomputer.Invalidate()
+	log.Printf("Swapped current share with the share on chain | Index: %d | Expiry: %d | Cause: %s\n", index, expiry, cause)
+
+	return cause
+}
+
+// sameShare returns true if both shares have the same index & value, shares without commitment are never the same
+func sameShare(a, b *KeyShare) bool {
+	if a == nil || b == nil || a.Commitment == nil || b.Commitment == nil {
+		return false
+	}
+	return a.Index == b.Index && a.Share.Value.Equal(b.Share.Value)
+}
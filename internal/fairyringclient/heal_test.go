@@ -0,0 +1,90 @@
+package fairyringclient
+
+import (
+	"testing"
+
+	"fairyringclient/config"
+	"fairyringclient/pkg/cosmosClient"
+	"fairyringclient/pkg/cosmosClient/fakechain"
+
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestHealInvalidShare(t *testing.T) {
+	cosmostypes.GetConfig().SetBech32PrefixForAccount("fairy", "fairypub")
+
+	validators := make([]fakechain.Validator, 4)
+	for i := range validators {
+		validators[i] = fakechain.NewValidator()
+	}
+	newRound := func() *fakechain.Round {
+		round, err := fakechain.NewRound(validators, 3)
+		if err != nil {
+			t.Fatalf("error creating round: %s", err.Error())
+		}
+		return round
+	}
+
+	chain := fakechain.New()
+	t.Cleanup(chain.Close)
+	first := newRound()
+	chain.SetRound(first, 100, false)
+	chain.AddAccount(validators[0].Address, 0, 0)
+
+	client, err := cosmosClient.NewCosmosClient(fakechain.Endpoint, validators[0].PrivateKeyHex, "fairyring-test", chain.DialOption())
+	if err != nil {
+		t.Fatalf("error creating cosmos client: %s", err.Error())
+	}
+	v := NewValidatorClients(config.DefaultConfig(false), client, client, nil)
+	if err = v.UpdateKeyShareFromChain(false); err != nil {
+		t.Fatalf("error fetching share: %s", err.Error())
+	}
+	used := v.CurrentShare
+
+	if cause := v.HealInvalidShare(used); cause != CauseShareUnchanged {
+		t.Fatalf("expected %s when the share on chain is unchanged, got: %s", CauseShareUnchanged, cause)
+	}
+	if v.CurrentShare != used {
+		t.Fatal("expected the current share to be kept when the share on chain is unchanged")
+	}
+
+	usedCommitment := *used
+	usedCommitment.Commitment = suite.G1().Point().Base()
+	if cause := v.HealInvalidShare(&usedCommitment); cause != CauseCommitmentChanged {
+		t.Fatalf("expected %s when the commitment changed, got: %s", CauseCommitmentChanged, cause)
+	}
+
+	// The active pubkey is overrode, the share in memory is stale
+	second := newRound()
+	chain.SetRound(second, 200, false)
+	if cause := v.HealInvalidShare(used); cause != CauseStaleShare {
+		t.Fatalf("expected %s after the pubkey is overrode, got: %s", CauseStaleShare, cause)
+	}
+	if v.CurrentShare == used || !v.CurrentShare.Share.Value.Equal(second.Shares[used.Index-1].Value) {
+		t.Fatal("expected the current share to be swapped with the share on chain")
+	}
+	if v.CurrentShareExpiryBlock != 200 {
+		t.Fatalf("expected current share expiry 200, got: %d", v.CurrentShareExpiryBlock)
+	}
+	if _, _, err = v.DeriveKeyShare([]byte("10")); err != nil {
+		t.Fatalf("error deriving keyshare from the swapped share: %s", err.Error())
+	}
+
+	// A keyshare of the next height derived from the stale share is healed already
+	healed := v.CurrentShare
+	if cause := v.HealInvalidShare(used); cause != CauseStaleShare {
+		t.Fatalf("expected %s, got: %s", CauseStaleShare, cause)
+	}
+	if v.CurrentShare != healed {
+		t.Fatal("expected the healed share to be kept")
+	}
+
+	// The share on chain does not match the commitments, it is not swapped in
+	chain.SetActivePubkey(second.PubKey, 200, second.EncryptedKeyshares, first.Commitments)
+	if cause := v.HealInvalidShare(healed); cause != CauseInvalidShareOnChain {
+		t.Fatalf("expected %s, got: %s", CauseInvalidShareOnChain, cause)
+	}
+	if v.CurrentShare != healed {
+		t.Fatal("expected the current share to be kept when the share on chain is invalid")
+	}
+}
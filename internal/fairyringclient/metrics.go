@@ -0,0 +1,59 @@
+package fairyringclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsServer serves the metrics endpoint, it can be moved to another port while running
+type MetricsServer struct {
+	mu     sync.Mutex
+	port   uint64
+	server *http.Server
+}
+
+// NewMetricsServer listens on the port before returning so the error is reported to the caller
+func NewMetricsServer(port uint64) (*MetricsServer, error) {
+	s := &MetricsServer{}
+	if err := s.Listen(port); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Listen serves the metrics on the port, the previous port is closed once the new one is listening
+func (s *MetricsServer) Listen(port uint64) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{Handler: mux}
+	go server.Serve(ln)
+
+	s.mu.Lock()
+	previous := s.server
+	s.port = port
+	s.server = server
+	s.mu.Unlock()
+
+	if previous != nil {
+		_ = previous.Close()
+	}
+	return nil
+}
+
+func (s *MetricsServer) Port() uint64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.port
+}
@@ -0,0 +1,316 @@
+package fairyringclient
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fairyringclient/config"
+	"fairyringclient/internal/notifier"
+
+	"cosmossdk.io/math"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// policyCheckInterval is the interval the policies are evaluated between blocks, so no_new_block holds while no block is received
+const policyCheckInterval = 5 * time.Second
+
+var policyActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "fairyringclient_policy_active",
+	Help: "1 if the condition of the policy holds, 0 otherwise",
+}, []string{"validator", "policy"})
+
+type submissionOutcome int
+
+const (
+	submissionConfirmed submissionOutcome = iota
+	submissionInvalid
+	submissionFailed
+)
+
+type policySubmission struct {
+	height  uint64
+	outcome submissionOutcome
+}
+
+// PolicyInputs are the values of the validator the policies are evaluated on,
+// the submissions, blocks & share verifications are recorded by the engine
+type PolicyInputs struct {
+	Height             uint64
+	InvalidShareInARow uint64
+	// Balance is the last balance checked, nil if it is not checked yet
+	Balance *math.Int
+}
+
+// PolicyDecision is the result of an evaluation, the pauses hold as long as one of the policies pausing holds
+type PolicyDecision struct {
+	PauseKeysharesBy      []string
+	PauseOptionalDutiesBy []string
+	// Triggered are the policies starting to hold, their notify & exit actions are run once
+	Triggered []config.Policy
+	// Cleared are the policies no longer holding
+	Cleared []config.Policy
+}
+
+// PolicyEngine evaluates the policies of a validator, the recording methods are no-op on a nil engine
+type PolicyEngine struct {
+	mu                   sync.Mutex
+	rules                []config.Policy
+	active               map[string]bool
+	submissions          []policySubmission
+	lastBlockHeight      uint64
+	lastBlockTime        time.Time
+	verificationFailures uint64
+	now                  func() time.Time
+}
+
+func NewPolicyEngine(rules []config.Policy) *PolicyEngine {
+	e := &PolicyEngine{
+		active: make(map[string]bool),
+		now:    time.Now,
+	}
+	e.lastBlockTime = e.now()
+	e.SetRules(rules)
+	return e
+}
+
+// SetRules replaces the policies, the policies of the same name keep holding without being triggered again
+func (e *PolicyEngine) SetRules(rules []config.Policy) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	active := make(map[string]bool)
+	for _, rule := range rules {
+		if e.active[rule.Name] {
+			active[rule.Name] = true
+		}
+	}
+	e.rules = append([]config.Policy{}, rules...)
+	e.active = active
+}
+
+// Rules returns the policies evaluated, nil on a nil engine
+func (e *PolicyEngine) Rules() []config.Policy {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]config.Policy{}, e.rules...)
+}
+
+// ObserveBlock records a new block, the submissions are counted in the window of the latest block
+func (e *PolicyEngine) ObserveBlock(height uint64) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastBlockHeight = height
+	e.lastBlockTime = e.now()
+
+	var window uint64
+	for _, rule := range e.rules {
+		if rule.Window > window {
+			window = rule.Window
+		}
+	}
+	kept := e.submissions[:0]
+	for _, s := range e.submissions {
+		if s.height+window > height {
+			kept = append(kept, s)
+		}
+	}
+	e.submissions = kept
+}
+
+// RecordSubmission records the outcome of a submitted tx at the latest block
+func (e *PolicyEngine) RecordSubmission(outcome submissionOutcome) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.submissions = append(e.submissions, policySubmission{height: e.lastBlockHeight, outcome: outcome})
+}
+
+// RecordShareVerification counts the verifications of the share or derived keyshares failed in a row
+func (e *PolicyEngine) RecordShareVerification(valid bool) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if valid {
+		e.verificationFailures = 0
+		return
+	}
+	e.verificationFailures++
+}
+
+// Evaluate checks the condition of each policy and returns the policies triggered, cleared & pausing
+func (e *PolicyEngine) Evaluate(in PolicyInputs) PolicyDecision {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var d PolicyDecision
+	for _, rule := range e.rules {
+		holds := e.holds(rule, in)
+		switch {
+		case holds && !e.active[rule.Name]:
+			d.Triggered = append(d.Triggered, rule)
+		case !holds && e.active[rule.Name]:
+			d.Cleared = append(d.Cleared, rule)
+		}
+		e.active[rule.Name] = holds
+
+		if !holds {
+			continue
+		}
+		for _, action := range rule.Actions {
+			switch action {
+			case config.PolicyActionPauseKeyshares:
+				d.PauseKeysharesBy = append(d.PauseKeysharesBy, rule.Name)
+			case config.PolicyActionPauseOptionalDuties:
+				d.PauseOptionalDutiesBy = append(d.PauseOptionalDutiesBy, rule.Name)
+			}
+		}
+	}
+	return d
+}
+
+func (e *PolicyEngine) holds(rule config.Policy, in PolicyInputs) bool {
+	switch rule.Condition {
+	case config.PolicyInvalidSharesInARow:
+		return float64(in.InvalidShareInARow) >= rule.Threshold
+	case config.PolicyInvalidSharesInWindow:
+		invalid, _ := e.countSubmissions(rule.Window, in.Height, submissionInvalid)
+		return float64(invalid) >= rule.Threshold
+	case config.PolicyFailedTxRatio:
+		failed, total := e.countSubmissions(rule.Window, in.Height, submissionFailed)
+		return total > 0 && float64(failed)/float64(total) >= rule.Threshold
+	case config.PolicyBalanceBelow:
+		return in.Balance != nil && in.Balance.LT(math.NewIntFromUint64(uint64(rule.Threshold)))
+	case config.PolicyNoNewBlock:
+		return e.now().Sub(e.lastBlockTime) >= time.Duration(rule.Threshold*float64(time.Second))
+	case config.PolicyShareVerificationFailed:
+		return float64(e.verificationFailures) >= rule.Threshold
+	}
+	return false
+}
+
+// countSubmissions returns the number of submissions of the outcome & of all submissions in the last window blocks
+func (e *PolicyEngine) countSubmissions(window uint64, height uint64, outcome submissionOutcome) (uint64, uint64) {
+	var matched, total uint64
+	for _, s := range e.submissions {
+		if s.height+window <= height {
+			continue
+		}
+		total++
+		if s.outcome == outcome {
+			matched++
+		}
+	}
+	return matched, total
+}
+
+// EvaluatePolicies evaluates the policies on the state of the validator, then applies the pauses
+// and runs the notify & exit actions of the policies triggered
+func (v *ValidatorClients) EvaluatePolicies(height uint64) {
+	if v.Policy == nil {
+		return
+	}
+
+	in := PolicyInputs{Height: height, InvalidShareInARow: v.InvalidShareNum()}
+	if v.BalanceMonitor != nil {
+		if balance, found := v.BalanceMonitor.Latest(); found {
+			in.Balance = &balance
+		}
+	}
+
+	d := v.Policy.Evaluate(in)
+	address := v.Broadcaster.GetAddress()
+
+	for _, p := range d.Cleared {
+		policyActive.WithLabelValues(address, p.Name).Set(0)
+		log.Printf("Policy %s no longer holds\n", p.Name)
+	}
+	for _, p := range d.Triggered {
+		policyActive.WithLabelValues(address, p.Name).Set(1)
+		log.Printf("Policy %s triggered, %s reached %s, Actions: %s\n", p.Name, p.Condition, formatThreshold(p.Threshold), strings.Join(p.Actions, ", "))
+	}
+
+	// The pause is swapped atomically, so the policy ticker & the block loop evaluating at the same time log & notify once
+	switch {
+	case len(d.PauseKeysharesBy) > 0 && v.Pause():
+		log.Printf("Client paused by policy %s, Skip submitting keyshares until it no longer holds\n", strings.Join(d.PauseKeysharesBy, ", "))
+		v.notify(notifier.Event{
+			Type:    notifier.EventClientPaused,
+			Message: fmt.Sprintf("Client paused by policy %s", strings.Join(d.PauseKeysharesBy, ", ")),
+			Height:  height,
+		})
+	case len(d.PauseKeysharesBy) == 0 && v.Unpause():
+		log.Printf("Client unpaused, Current invalid share count: %d\n", v.InvalidShareNum())
+		v.notify(notifier.Event{
+			Type:    notifier.EventClientUnpaused,
+			Message: "Client unpaused, the policies pausing it no longer hold",
+			Height:  height,
+		})
+	}
+
+	switch {
+	case len(d.PauseOptionalDutiesBy) > 0 && v.PauseOptionalDuties():
+		log.Printf("Optional duties (general & encrypted keyshares) paused by policy %s\n", strings.Join(d.PauseOptionalDutiesBy, ", "))
+	case len(d.PauseOptionalDutiesBy) == 0 && v.UnpauseOptionalDuties():
+		log.Println("Resumed optional duties, the policies pausing them no longer hold")
+	}
+
+	for _, p := range d.Triggered {
+		e := notifier.Event{
+			Type:    notifier.EventPolicyTriggered,
+			Message: fmt.Sprintf("Policy %s triggered, %s reached %s", p.Name, p.Condition, formatThreshold(p.Threshold)),
+			Height:  height,
+			Fields: map[string]string{
+				"policy":  p.Name,
+				"actions": strings.Join(p.Actions, ","),
+			},
+		}
+
+		if p.HasAction(config.PolicyActionNotify) {
+			v.notify(e)
+		}
+		if p.HasAction(config.PolicyActionExit) {
+			if v.DryRun {
+				log.Printf("Skip exiting by policy %s in dry run mode\n", p.Name)
+				continue
+			}
+			e.Fields["address"] = address
+			if err := v.Notifier().Send(e); err != nil {
+				log.Printf("Error sending %s notification: %s\n", e.Type, err.Error())
+			}
+			exitProcess(fmt.Sprintf("Exiting FairyRingClient, policy %s triggered", p.Name))
+		}
+	}
+}
+
+// exit stops the process, it is replaced in tests
+var exitProcess = func(reason string) {
+	log.Fatal(reason)
+}
+
+func formatThreshold(threshold float64) string {
+	return strconv.FormatFloat(threshold, 'f', -1, 64)
+}
@@ -0,0 +1,122 @@
+package fairyringclient
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"fairyringclient/config"
+
+	"cosmossdk.io/math"
+)
+
+func TestPolicyEngineConditions(t *testing.T) {
+	now := time.Unix(1000, 0)
+	e := NewPolicyEngine([]config.Policy{
+		{Name: "in-a-row", Condition: config.PolicyInvalidSharesInARow, Threshold: 2, Actions: []string{config.PolicyActionPauseKeyshares}},
+		{Name: "in-window", Condition: config.PolicyInvalidSharesInWindow, Threshold: 2, Window: 10, Actions: []string{config.PolicyActionNotify}},
+		{Name: "failed-ratio", Condition: config.PolicyFailedTxRatio, Threshold: 0.4, Window: 10, Actions: []string{config.PolicyActionPauseOptionalDuties}},
+		{Name: "balance", Condition: config.PolicyBalanceBelow, Threshold: 100, Actions: []string{config.PolicyActionPauseOptionalDuties}},
+		{Name: "no-block", Condition: config.PolicyNoNewBlock, Threshold: 30, Actions: []string{config.PolicyActionNotify}},
+		{Name: "verification", Condition: config.PolicyShareVerificationFailed, Threshold: 1, Actions: []string{config.PolicyActionPauseKeyshares}},
+	})
+	e.now = func() time.Time { return now }
+
+	e.ObserveBlock(1)
+	if d := e.Evaluate(PolicyInputs{Height: 1}); len(d.Triggered) != 0 {
+		t.Fatalf("expected no policy triggered, got: %+v", d.Triggered)
+	}
+
+	// Invalid shares at block 1 & 5 are in the window of block 10, the one of block 1 is out of the window of block 11
+	e.RecordSubmission(submissionInvalid)
+	e.ObserveBlock(5)
+	e.RecordSubmission(submissionInvalid)
+	e.RecordSubmission(submissionConfirmed)
+	e.RecordSubmission(submissionConfirmed)
+	e.ObserveBlock(10)
+	d := e.Evaluate(PolicyInputs{Height: 10, InvalidShareInARow: 2})
+	if len(d.Triggered) != 2 || d.Triggered[0].Name != "in-a-row" || d.Triggered[1].Name != "in-window" {
+		t.Fatalf("expected in-a-row & in-window triggered, got: %+v", d.Triggered)
+	}
+	if len(d.PauseKeysharesBy) != 1 || d.PauseKeysharesBy[0] != "in-a-row" {
+		t.Fatalf("expected keyshares paused by in-a-row, got: %v", d.PauseKeysharesBy)
+	}
+
+	// Still holding policies are not triggered again
+	if d = e.Evaluate(PolicyInputs{Height: 10, InvalidShareInARow: 2}); len(d.Triggered) != 0 || len(d.PauseKeysharesBy) != 1 {
+		t.Fatalf("expected no policy triggered again, got: %+v", d)
+	}
+
+	e.ObserveBlock(11)
+	d = e.Evaluate(PolicyInputs{Height: 11})
+	if len(d.Cleared) != 2 || len(d.PauseKeysharesBy) != 0 {
+		t.Fatalf("expected in-a-row & in-window cleared, got: %+v", d)
+	}
+
+	// 2 failed of the 5 submissions in the window
+	e.RecordSubmission(submissionFailed)
+	e.RecordSubmission(submissionFailed)
+	balance := math.NewInt(99)
+	d = e.Evaluate(PolicyInputs{Height: 11, Balance: &balance})
+	if len(d.PauseOptionalDutiesBy) != 2 {
+		t.Fatalf("expected optional duties paused by failed-ratio & balance, got: %v", d.PauseOptionalDutiesBy)
+	}
+
+	now = now.Add(30 * time.Second)
+	e.RecordShareVerification(false)
+	d = e.Evaluate(PolicyInputs{Height: 11})
+	if len(d.Triggered) != 2 || d.Triggered[0].Name != "no-block" || d.Triggered[1].Name != "verification" {
+		t.Fatalf("expected no-block & verification triggered, got: %+v", d.Triggered)
+	}
+
+	e.RecordShareVerification(true)
+	e.ObserveBlock(12)
+	if d = e.Evaluate(PolicyInputs{Height: 12}); len(d.PauseKeysharesBy) != 0 {
+		t.Fatalf("expected keyshares no longer paused, got: %v", d.PauseKeysharesBy)
+	}
+}
+
+func TestEvaluatePoliciesPausesAndExits(t *testing.T) {
+	cfg := config.DefaultConfig(false)
+	cfg.InvalidSharePauseThreshold = 2
+	cfg.BalanceMonitor.PauseOptionalDuties = true
+	cfg.BalanceMonitor.LowBalanceThreshold = 100
+
+	v := NewValidatorClients(cfg, nil, &mockBroadcaster{address: "fairy1first"}, nil)
+
+	v.InvalidShareInARow = 2
+	v.BalanceMonitor.Record(1, math.NewInt(50))
+	v.EvaluatePolicies(1)
+	if !v.Paused() || !v.OptionalDutiesPaused() {
+		t.Fatalf("expected keyshares & optional duties paused by the built-in policies, got: %t, %t", v.Paused(), v.OptionalDutiesPaused())
+	}
+
+	// Switching to the next round resets the invalid shares in a row
+	v.ResetInvalidShareNum()
+	v.BalanceMonitor.Record(2, math.NewInt(500))
+	v.EvaluatePolicies(2)
+	if v.Paused() || v.OptionalDutiesPaused() {
+		t.Fatalf("expected unpaused once the policies no longer hold, got: %t, %t", v.Paused(), v.OptionalDutiesPaused())
+	}
+
+	var exitReason string
+	exitProcess = func(reason string) { exitReason = reason }
+	t.Cleanup(func() { exitProcess = func(reason string) { t.Fatal(reason) } })
+
+	cfg.Policies = []config.Policy{{Name: "halt", Condition: config.PolicyShareVerificationFailed, Threshold: 1, Actions: []string{config.PolicyActionNotify, config.PolicyActionExit}}}
+	v.Policy.SetRules(cfg.PolicyRules())
+	v.Policy.RecordShareVerification(false)
+	v.EvaluatePolicies(3)
+	if !strings.Contains(exitReason, "halt") {
+		t.Fatalf("expected exit by policy halt, got: %q", exitReason)
+	}
+
+	// The policies never exit in dry run mode
+	exitReason = ""
+	v.EnableDryRun()
+	v.Policy.RecordShareVerification(false)
+	v.EvaluatePolicies(4)
+	if len(exitReason) > 0 {
+		t.Fatalf("expected no exit in dry run mode, got: %q", exitReason)
+	}
+}
@@ -0,0 +1,179 @@
+package fairyringclient
+
+import (
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	precomputedKeyShareLookup = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fairyringclient_precomputed_keyshare_lookup",
+		Help: "The total number of precomputed key share lookups by result: hit, miss",
+	}, []string{"validator", "result"})
+)
+
+type precomputedKeyShare struct {
+	share    *KeyShare
+	keyShare string
+	index    uint64
+}
+
+type precomputeJob struct {
+	height uint64
+	share  *KeyShare
+}
+
+// KeySharePrecomputer derives the key shares for the upcoming heights ahead of time in a bounded worker pool,
+// so the block handler only needs to queue the tx. Each result is cached by height together with the share
+// it is derived from, a result derived from a share that is no longer in use is never returned.
+type KeySharePrecomputer struct {
+	mu        sync.Mutex
+	validator string
+	lookahead uint64
+	jobs      chan precomputeJob
+	cache     map[uint64]precomputedKeyShare
+	inFlight  map[uint64]*KeyShare
+}
+
+// NewKeySharePrecomputer starts the workers, it returns nil if precomputing is disabled,
+// calling any method on a nil KeySharePrecomputer is a no-op
+func NewKeySharePrecomputer(validator string, lookahead uint64, workers uint64) *KeySharePrecomputer {
+	if lookahead == 0 || workers == 0 {
+		return nil
+	}
+
+	p := &KeySharePrecomputer{
+		validator: validator,
+		lookahead: lookahead,
+		jobs:      make(chan precomputeJob, lookahead),
+		cache:     make(map[uint64]precomputedKeyShare),
+		inFlight:  make(map[uint64]*KeyShare),
+	}
+
+	for i := uint64(0); i < workers; i++ {
+		go p.worker()
+	}
+
+	return p
+}
+
+func (p *KeySharePrecomputer) worker() {
+	for job := range p.jobs {
+		keyShare, index, err := deriveKeyShare(job.share, []byte(strconv.FormatUint(job.height, 10)))
+
+		p.mu.Lock()
+		// Drop the result if the cache is invalidated while deriving
+		if p.inFlight[job.height] == job.share {
+			delete(p.inFlight, job.height)
+			if err == nil {
+				p.cache[job.height] = precomputedKeyShare{share: job.share, keyShare: keyShare, index: index}
+			}
+		}
+		p.mu.Unlock()
+
+		if err != nil {
+			log.Printf("Error precomputing key share for height %d: %s\n", job.height, err.Error())
+		}
+	}
+}
+
+// Schedule queues the heights from `from` to `from + lookahead - 1` that are not derived yet,
+// shareForHeight returns the share to be used for the height or nil if it is not available yet
+func (p *KeySharePrecomputer) Schedule(from uint64, shareForHeight func(uint64) *KeyShare) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for height := range p.cache {
+		if height < from {
+			delete(p.cache, height)
+		}
+	}
+
+	for height := from; height < from+p.lookahead; height++ {
+		share := shareForHeight(height)
+		if share == nil {
+			continue
+		}
+		if cached, found := p.cache[height]; found && cached.share == share {
+			continue
+		}
+		if p.inFlight[height] == share {
+			continue
+		}
+
+		select {
+		case p.jobs <- precomputeJob{height: height, share: share}:
+			p.inFlight[height] = share
+		default:
+			// All workers are busy, the height will be scheduled again on next block
+			return
+		}
+	}
+}
+
+// Get returns the key share precomputed for height if it is derived from share
+func (p *KeySharePrecomputer) Get(height uint64, share *KeyShare) (string, uint64, bool) {
+	if p == nil {
+		return "", 0, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cached, found := p.cache[height]
+	if !found || cached.share != share {
+		precomputedKeyShareLookup.WithLabelValues(p.validator, "miss").Inc()
+		return "", 0, false
+	}
+
+	delete(p.cache, height)
+	precomputedKeyShareLookup.WithLabelValues(p.validator, "hit").Inc()
+	return cached.keyShare, cached.index, true
+}
+
+// Invalidate drops all the precomputed key shares, used when the shares are overridden
+func (p *KeySharePrecomputer) Invalidate() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cache = make(map[uint64]precomputedKeyShare)
+	p.inFlight = make(map[uint64]*KeyShare)
+}
+
+// shareForHeight returns the share that is used to derive the key share for height
+func (v *ValidatorClients) shareForHeight(height uint64) *KeyShare {
+	v.sharesMu.RLock()
+	defer v.sharesMu.RUnlock()
+
+	if v.CurrentShareExpiryBlock != 0 && v.CurrentShareExpiryBlock <= height {
+		return v.PendingShare
+	}
+	return v.CurrentShare
+}
+
+// precomputedKeyShare returns the key share precomputed for height from share. The key share is verified against
+// the commitment of the share when it is derived, so a hit is recorded as a successful verification like a derivation on demand
+func (v *ValidatorClients) precomputedKeyShare(height uint64, share *KeyShare) (string, uint64, bool) {
+	keyShare, index, found := v.Precomputer.Get(height, share)
+	if found {
+		v.Policy.RecordShareVerification(true)
+	}
+	return keyShare, index, found
+}
+
+// PrecomputeKeyShares schedules deriving the key shares for the heights after `from`
+func (v *ValidatorClients) PrecomputeKeyShares(from uint64) {
+	v.Precomputer.Schedule(from, v.shareForHeight)
+}
@@ -0,0 +1,232 @@
+package fairyringclient
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"fairyringclient/config"
+)
+
+// waitPrecomputed waits until the key shares of the heights are cached
+func waitPrecomputed(t *testing.T, p *KeySharePrecomputer, heights ...uint64) {
+	t.Helper()
+	waitPrecomputedScheduling(t, p, func() {}, heights...)
+}
+
+// waitPrecomputedScheduling calls schedule until the key shares of the heights are cached, like on each new block
+func waitPrecomputedScheduling(t *testing.T, p *KeySharePrecomputer, schedule func(), heights ...uint64) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		schedule()
+
+		p.mu.Lock()
+		cached := 0
+		for _, height := range heights {
+			if _, found := p.cache[height]; found {
+				cached++
+			}
+		}
+		p.mu.Unlock()
+
+		if cached == len(heights) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for heights %v to be precomputed", heights)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func expectedKeyShare(t *testing.T, share *KeyShare, height uint64) string {
+	t.Helper()
+
+	keyShare, _, err := deriveKeyShare(share, []byte(strconv.FormatUint(height, 10)))
+	if err != nil {
+		t.Fatalf("error deriving key share: %s", err.Error())
+	}
+	return keyShare
+}
+
+func TestNewKeySharePrecomputerDisabled(t *testing.T) {
+	if p := NewKeySharePrecomputer("fairy1validator", 0, 2); p != nil {
+		t.Fatal("expected nil precomputer without lookahead")
+	}
+	if p := NewKeySharePrecomputer("fairy1validator", 2, 0); p != nil {
+		t.Fatal("expected nil precomputer without workers")
+	}
+
+	var p *KeySharePrecomputer
+	p.Schedule(1, func(uint64) *KeyShare { return nil })
+	p.Invalidate()
+	if _, _, found := p.Get(1, nil); found {
+		t.Fatal("expected no key share from a nil precomputer")
+	}
+}
+
+func TestPrecomputerCacheKeyedByShare(t *testing.T) {
+	setup := newDealerSetup(t)
+	share := setup.keyShare(t, 1)
+	// Same share & commitment, but another instance such as the share fetched again from chain
+	sameValue := setup.keyShare(t, 1)
+
+	p := NewKeySharePrecomputer("fairy1validator", 2, 2)
+	p.Schedule(10, func(uint64) *KeyShare { return share })
+	waitPrecomputed(t, p, 10, 11)
+
+	if _, _, found := p.Get(10, sameValue); found {
+		t.Fatal("expected a miss for another share instance")
+	}
+
+	keyShare, index, found := p.Get(10, share)
+	if !found {
+		t.Fatal("expected a hit for the share the key share is derived from")
+	}
+	if index != 1 || keyShare != expectedKeyShare(t, share, 10) {
+		t.Fatal("expected the key share derived from the share for the height")
+	}
+	if _, _, found = p.Get(10, share); found {
+		t.Fatal("expected the key share to be returned once")
+	}
+
+	// Heights before the scheduled range are dropped
+	p.Schedule(12, func(uint64) *KeyShare { return nil })
+	if _, _, found = p.Get(11, share); found {
+		t.Fatal("expected the key shares of past heights to be dropped")
+	}
+}
+
+func TestPrecomputerScheduleReturnsWhenWorkersBusy(t *testing.T) {
+	setup := newDealerSetup(t)
+	share := setup.keyShare(t, 1)
+
+	// No worker is started, the jobs are only queued
+	p := &KeySharePrecomputer{
+		lookahead: 5,
+		jobs:      make(chan precomputeJob, 2),
+		cache:     make(map[uint64]precomputedKeyShare),
+		inFlight:  make(map[uint64]*KeyShare),
+	}
+
+	requested := 0
+	p.Schedule(10, func(uint64) *KeyShare {
+		requested++
+		return share
+	})
+	if len(p.jobs) != 2 || len(p.inFlight) != 2 {
+		t.Fatalf("expected 2 heights queued, got: %d queued, %d in flight", len(p.jobs), len(p.inFlight))
+	}
+	if requested != 3 {
+		t.Fatalf("expected scheduling to stop at the first height not queued, got %d heights requested", requested)
+	}
+
+	// Once the workers are free, the heights in flight are not queued again
+	<-p.jobs
+	<-p.jobs
+	p.Schedule(10, func(uint64) *KeyShare { return share })
+	if len(p.jobs) != 2 {
+		t.Fatalf("expected 2 heights queued, got: %d", len(p.jobs))
+	}
+	queued := []uint64{(<-p.jobs).height, (<-p.jobs).height}
+	if queued[0] != 12 || queued[1] != 13 {
+		t.Fatalf("expected heights 12 & 13 queued, got: %v", queued)
+	}
+}
+
+func TestPrecomputerInvalidateWhileScheduling(t *testing.T) {
+	setup := newDealerSetup(t)
+	first := setup.keyShare(t, 1)
+	second := setup.keyShare(t, 2)
+
+	var mu sync.Mutex
+	current := first
+	shareForHeight := func(uint64) *KeyShare {
+		mu.Lock()
+		defer mu.Unlock()
+		return current
+	}
+
+	p := NewKeySharePrecomputer("fairy1validator", 4, 2)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			p.Schedule(10, shareForHeight)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if i == 25 {
+				mu.Lock()
+				current = second
+				mu.Unlock()
+			}
+			p.Invalidate()
+		}
+	}()
+	wg.Wait()
+
+	// The jobs queued before the invalidation may keep the workers busy, the heights are scheduled again on next block
+	waitPrecomputedScheduling(t, p, func() { p.Schedule(10, shareForHeight) }, 10, 11, 12, 13)
+
+	for height := uint64(10); height < 14; height++ {
+		if _, _, found := p.Get(height, first); found {
+			t.Fatalf("expected no key share of the invalidated share for height %d", height)
+		}
+		keyShare, index, found := p.Get(height, second)
+		if !found || index != 2 || keyShare != expectedKeyShare(t, second, height) {
+			t.Fatalf("expected the key share of the current share for height %d", height)
+		}
+	}
+}
+
+func TestPrecomputedKeyShareAfterRotation(t *testing.T) {
+	setup := newDealerSetup(t)
+	next := newDealerSetup(t)
+
+	cfg := config.DefaultConfig(false)
+	cfg.Precompute.Heights = 4
+	cfg.Precompute.Workers = 2
+	cfg.Policies = []config.Policy{{Name: "verification", Condition: config.PolicyShareVerificationFailed, Threshold: 1, Actions: []string{config.PolicyActionNotify}}}
+	v := NewValidatorClients(cfg, nil, &mockBroadcaster{address: "fairy1validator"}, nil)
+
+	current := setup.keyShare(t, 1)
+	pending := next.keyShare(t, 1)
+	v.setShare(false, current, 12, nil)
+	v.setShare(true, pending, 100, nil)
+
+	// Heights from the expiry are derived from the pending share
+	v.PrecomputeKeyShares(10)
+	waitPrecomputed(t, v.Precomputer, 10, 11, 12, 13)
+	if _, _, found := v.precomputedKeyShare(12, current); found {
+		t.Fatal("expected no key share of the current share after its expiry")
+	}
+
+	v.Policy.RecordShareVerification(false)
+	keyShare, _, found := v.precomputedKeyShare(10, current)
+	if !found || keyShare != expectedKeyShare(t, current, 10) {
+		t.Fatal("expected the key share of the current share before its expiry")
+	}
+	if d := v.Policy.Evaluate(PolicyInputs{Height: 10}); len(d.Triggered) != 0 {
+		t.Fatal("expected a precomputed key share to be recorded as a successful verification")
+	}
+
+	v.RemoveCurrentShare()
+	v.ActivatePendingShare()
+	rotated, _ := v.GetCurrentShare()
+
+	if _, _, found = v.precomputedKeyShare(11, rotated); found {
+		t.Fatal("expected no key share derived from the old share after rotation")
+	}
+	keyShare, _, found = v.precomputedKeyShare(12, rotated)
+	if !found || keyShare != expectedKeyShare(t, pending, 12) {
+		t.Fatal("expected the key share derived from the activated share")
+	}
+}
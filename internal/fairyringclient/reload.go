@@ -0,0 +1,380 @@
+package fairyringclient
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"fairyringclient/config"
+	"fairyringclient/internal/logging"
+	"fairyringclient/internal/notifier"
+
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+)
+
+// reloadPollInterval is the interval the config file is checked for changes, SIGHUP reloads right away
+const reloadPollInterval = 5 * time.Second
+
+const (
+	reloadResultSuccess  = "success"
+	reloadResultRejected = "rejected"
+	reloadResultError    = "error"
+)
+
+var (
+	configGeneration = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "fairyringclient_config_generation",
+		Help: "The generation of the config in use, increased on each successful reload",
+	})
+	configReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fairyringclient_config_reloads",
+		Help: "The total number of config reloads by result: success, rejected or error",
+	}, []string{"result"})
+)
+
+// gasPriceSetter is implemented by the broadcasters paying fees, such as *cosmosClient.CosmosClient
+type gasPriceSetter interface {
+	SetGasPrice(gasPrice string) error
+}
+
+// redialer is implemented by the chain clients connected to the gRPC endpoint of the node, such as *cosmosClient.CosmosClient
+type redialer interface {
+	Redial(endpoint string, dialOpts ...grpc.DialOption) error
+}
+
+// EventSourceDialer connects to the RPC endpoint of the node in cfg, it returns the event source
+// the validators subscribe through & a function closing the connection
+type EventSourceDialer func(cfg config.Config) (EventSource, func(), error)
+
+// configField is a part of the config compared between reloads
+type configField struct {
+	name  string
+	value func(c config.Config) interface{}
+}
+
+var (
+	// unsafeConfigFields change the identity of the client on chain, a reload changing them is rejected
+	unsafeConfigFields = []configField{
+		{"PrivateKey", func(c config.Config) interface{} { return c.PrivateKey }},
+		{"Validators", func(c config.Config) interface{} { return append([]config.Validator{}, c.Validators...) }},
+		{"FairyRingNode.ChainID", func(c config.Config) interface{} { return c.FairyRingNode.ChainID }},
+		{"FairyRingNode.Denom", func(c config.Config) interface{} { return c.FairyRingNode.Denom }},
+		{"FairyRingNode.Bech32Prefix", func(c config.Config) interface{} { return c.FairyRingNode.Bech32Prefix }},
+	}
+	// restartConfigFields are used when the workers are created, changes are applied on restart
+	restartConfigFields = []configField{
+		{"Precompute", func(c config.Config) interface{} { return c.Precompute }},
+		{"HA", func(c config.Config) interface{} { return c.HA }},
+	}
+	// grpcConfigFields are the gRPC endpoint of the node, the chain clients are redialed when they change
+	grpcConfigFields = []configField{
+		{"gRPC endpoint", func(c config.Config) interface{} { return c.GetGRPCEndpoint() }},
+		{"FairyRingNode.GRPCTLS", func(c config.Config) interface{} { return c.FairyRingNode.GRPCTLS }},
+	}
+	// rpcConfigFields are the RPC endpoint of the node, the events are subscribed on a new connection when they change
+	rpcConfigFields = []configField{
+		{"RPC endpoint", func(c config.Config) interface{} { return c.GetFairyRingNodeURI() }},
+	}
+)
+
+func changedConfigFields(fields []configField, current, next config.Config) []string {
+	var changed []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.value(current), f.value(next)) {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
+
+// ConfigReloader applies the changes of the config to the running validators
+type ConfigReloader struct {
+	mu         sync.Mutex
+	current    config.Config
+	generation uint64
+	validators []*ValidatorClients
+	metrics    *MetricsServer
+	// notifier is shared by the validators not in dry run mode, it is replaced when the notifier config changes
+	notifier *notifier.Notifier
+	// fanout shares the events of the node connection, it is switched to a new connection when the RPC endpoint changes
+	fanout      *EventFanout
+	dialEvents  EventSourceDialer
+	closeEvents func()
+}
+
+// NewConfigReloader starts from the config the validators are created with, metrics is optional
+func NewConfigReloader(cfg config.Config, validators []*ValidatorClients, metrics *MetricsServer) *ConfigReloader {
+	configGeneration.Set(1)
+	r := &ConfigReloader{
+		current:    cfg,
+		generation: 1,
+		validators: validators,
+		metrics:    metrics,
+	}
+	for _, v := range validators {
+		if !v.DryRun {
+			r.notifier = v.Notifier()
+			break
+		}
+	}
+	return r
+}
+
+// SetEventSource switches the events of the fanout to a connection made with dial when the RPC endpoint changes,
+// closeEvents closes the connection in use. Without event source, the RPC endpoint is applied on restart
+func (r *ConfigReloader) SetEventSource(fanout *EventFanout, dial EventSourceDialer, closeEvents func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fanout = fanout
+	r.dialEvents = dial
+	r.closeEvents = closeEvents
+}
+
+// Close waits for the notifications queued on the notifier in use to be sent, and closes the connection of the events
+func (r *ConfigReloader) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifier.Close()
+	if r.closeEvents != nil {
+		r.closeEvents()
+	}
+}
+
+// Generation returns the generation of the config in use, starting at 1
+func (r *ConfigReloader) Generation() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.generation
+}
+
+// Watch reloads the config on SIGHUP or when the config file is modified, until ctx is done
+func (r *ConfigReloader) Watch(ctx context.Context, path string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(reloadPollInterval)
+	defer ticker.Stop()
+
+	lastModified := modTime(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Println("Received SIGHUP, reloading config")
+			lastModified = modTime(path)
+			_ = r.Reload()
+		case <-ticker.C:
+			modified := modTime(path)
+			if modified.Equal(lastModified) {
+				continue
+			}
+			lastModified = modified
+			log.Printf("Config file %s modified, reloading config\n", path)
+			_ = r.Reload()
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Reload loads the config with the same profile & env overrides as on start and applies it
+func (r *ConfigReloader) Reload() error {
+	next, err := config.LoadConfig()
+	if err != nil {
+		configReloads.WithLabelValues(reloadResultError).Inc()
+		log.Printf("Error reloading config, keep using generation %d: %s\n", r.Generation(), err.Error())
+		return err
+	}
+	return r.Apply(*next)
+}
+
+// Apply applies the thresholds, policies, gas price, log level, notifier, metrics port & node endpoints of the config live.
+// The config is rejected as a whole if the key, chain ID, denom or address prefix changed, any of the live fields is invalid
+// or the new node endpoints can not be connected to. Changes of the precomputation & HA are logged and applied on restart
+func (r *ConfigReloader) Apply(next config.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if unsafe := changedConfigFields(unsafeConfigFields, r.current, next); len(unsafe) > 0 {
+		return r.reject(errors.Errorf("%s cannot be changed while running, restart the client to apply", strings.Join(unsafe, ", ")))
+	}
+
+	if len(next.FairyRingNode.GasPrice) > 0 {
+		if _, err := cosmostypes.ParseDecCoin(next.FairyRingNode.GasPrice); err != nil {
+			return r.reject(errors.Wrapf(err, "invalid gas price %s", next.FairyRingNode.GasPrice))
+		}
+	}
+
+	if _, err := logging.ParseLevel(next.LogLevel); err != nil {
+		return r.reject(err)
+	}
+
+	n := r.notifier
+	if !reflect.DeepEqual(r.current.Notifier, next.Notifier) && r.notifies() {
+		var err error
+		if n, err = notifier.New(next.Notifier); err != nil {
+			return r.reject(err)
+		}
+	}
+
+	metricsMoved := r.metrics != nil && next.MetricsPort != r.current.MetricsPort
+	if metricsMoved {
+		if err := r.metrics.Listen(next.MetricsPort); err != nil {
+			if n != r.notifier {
+				n.Close()
+			}
+			return r.reject(errors.Wrapf(err, "error listening metrics on port %d", next.MetricsPort))
+		}
+	}
+
+	// The node endpoints are switched last, as the metrics port is simpler to move back than the connections
+	if err := r.switchEndpoints(next); err != nil {
+		if n != r.notifier {
+			n.Close()
+		}
+		if metricsMoved {
+			if listenErr := r.metrics.Listen(r.current.MetricsPort); listenErr != nil {
+				log.Printf("Error listening metrics on port %d again: %s\n", r.current.MetricsPort, listenErr.Error())
+			}
+		}
+		return r.reject(err)
+	}
+	if metricsMoved {
+		log.Printf("Metrics is listening on port: %d\n", next.MetricsPort)
+	}
+
+	_ = logging.SetLevel(next.LogLevel)
+
+	for _, v := range r.validators {
+		v.Policy.SetRules(next.PolicyRules())
+		v.BalanceMonitor.UpdateConfig(next.BalanceMonitor)
+		// The validators in dry run mode never notify
+		if !v.DryRun {
+			v.SetNotifier(n)
+		}
+		if setter, ok := v.Broadcaster.(gasPriceSetter); ok {
+			_ = setter.SetGasPrice(next.FairyRingNode.GasPrice)
+		}
+	}
+
+	if n != r.notifier {
+		// The events queued on the replaced notifier are still sent, without holding up the reload
+		go r.notifier.Close()
+		r.notifier = n
+	}
+
+	if deferred := changedConfigFields(restartConfigFields, r.current, next); len(deferred) > 0 {
+		log.Printf("Config changes of %s are applied on restart\n", strings.Join(deferred, ", "))
+	}
+
+	// The fields applied on restart keep the values in use, so they are reported again until the restart
+	next.Precompute = r.current.Precompute
+	next.HA = r.current.HA
+
+	r.current = next
+	r.generation++
+	configGeneration.Set(float64(r.generation))
+	configReloads.WithLabelValues(reloadResultSuccess).Inc()
+	log.Printf("Config reloaded, generation: %d\n", r.generation)
+	return nil
+}
+
+// switchEndpoints redials the chain clients when the gRPC endpoint changed, then subscribes the events on a connection
+// to the new RPC endpoint. The chain clients are switched back to the endpoint in use if any of them fails
+func (r *ConfigReloader) switchEndpoints(next config.Config) error {
+	var redialed []redialer
+	if changed := changedConfigFields(grpcConfigFields, r.current, next); len(changed) > 0 {
+		endpoint := next.GetGRPCEndpoint()
+		for _, c := range r.redialers() {
+			if err := c.Redial(endpoint, next.GetGRPCDialOptions()...); err != nil {
+				r.redialBack(redialed)
+				return errors.Wrapf(err, "error connecting to the gRPC endpoint %s", endpoint)
+			}
+			redialed = append(redialed, c)
+		}
+	}
+
+	rpcChanged := len(changedConfigFields(rpcConfigFields, r.current, next)) > 0
+	if rpcChanged && r.fanout != nil {
+		endpoint := next.GetFairyRingNodeURI()
+		source, closeSource, err := r.dialEvents(next)
+		if err != nil {
+			r.redialBack(redialed)
+			return errors.Wrapf(err, "error connecting to the RPC endpoint %s", endpoint)
+		}
+		if err = r.fanout.SetSource(source); err != nil {
+			closeSource()
+			r.redialBack(redialed)
+			return errors.Wrapf(err, "error subscribing events on the RPC endpoint %s", endpoint)
+		}
+		r.closeEvents()
+		r.closeEvents = closeSource
+		log.Printf("Switched to the RPC endpoint: %s\n", endpoint)
+	} else if rpcChanged {
+		log.Println("Config changes of the RPC endpoint are applied on restart")
+	}
+
+	if len(redialed) > 0 {
+		log.Printf("Switched to the gRPC endpoint: %s\n", next.GetGRPCEndpoint())
+	}
+	return nil
+}
+
+// redialers returns the chain clients of the validators connected to the gRPC endpoint, each of them once
+func (r *ConfigReloader) redialers() []redialer {
+	var clients []redialer
+	seen := make(map[redialer]bool)
+	for _, v := range r.validators {
+		for _, c := range []interface{}{v.Querier, v.Broadcaster} {
+			if d, ok := c.(redialer); ok && !seen[d] {
+				seen[d] = true
+				clients = append(clients, d)
+			}
+		}
+	}
+	return clients
+}
+
+// redialBack switches the chain clients back to the gRPC endpoint in use
+func (r *ConfigReloader) redialBack(clients []redialer) {
+	endpoint := r.current.GetGRPCEndpoint()
+	for _, c := range clients {
+		if err := c.Redial(endpoint, r.current.GetGRPCDialOptions()...); err != nil {
+			log.Printf("Error connecting to the gRPC endpoint %s again: %s\n", endpoint, err.Error())
+		}
+	}
+}
+
+// notifies reports if any validator sends notifications, the validators in dry run mode never notify
+func (r *ConfigReloader) notifies() bool {
+	for _, v := range r.validators {
+		if !v.DryRun {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ConfigReloader) reject(err error) error {
+	configReloads.WithLabelValues(reloadResultRejected).Inc()
+	log.Printf("Rejected config reload, keep using generation %d: %s\n", r.generation, err.Error())
+	return err
+}
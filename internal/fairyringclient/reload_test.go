@@ -0,0 +1,259 @@
+package fairyringclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"fairyringclient/config"
+	"fairyringclient/internal/logging"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"google.golang.org/grpc"
+)
+
+// gasPriceBroadcaster records the gas price set on reload
+type gasPriceBroadcaster struct {
+	mockBroadcaster
+	gasPrice string
+}
+
+func (b *gasPriceBroadcaster) SetGasPrice(gasPrice string) error {
+	b.gasPrice = gasPrice
+	return nil
+}
+
+// pauseThreshold returns the threshold of the built-in policy pausing on invalid shares, 0 if not found
+func pauseThreshold(v *ValidatorClients) float64 {
+	for _, rule := range v.Policy.Rules() {
+		if rule.Name == config.PolicyInvalidSharePauseThreshold {
+			return rule.Threshold
+		}
+	}
+	return 0
+}
+
+func freePort(t *testing.T) uint64 {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	defer ln.Close()
+	return uint64(ln.Addr().(*net.TCPAddr).Port)
+}
+
+func TestConfigReloadAppliesSafeFields(t *testing.T) {
+	cfg := config.DefaultConfig(false)
+	cfg.PrivateKey = "key"
+	cfg.MetricsPort = freePort(t)
+
+	metrics, err := NewMetricsServer(cfg.MetricsPort)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	broadcaster := &gasPriceBroadcaster{mockBroadcaster: mockBroadcaster{address: "fairy1first"}}
+	v := NewValidatorClients(cfg, nil, broadcaster, nil)
+	reloader := NewConfigReloader(cfg, []*ValidatorClients{v}, metrics)
+
+	next := cfg
+	next.InvalidSharePauseThreshold = 10
+	next.BalanceMonitor.LowBalanceThreshold = 42
+	next.FairyRingNode.GasPrice = "0.1ufairy"
+	next.Notifier.WebhookURL = "http://127.0.0.1:1/webhook"
+	next.MetricsPort = freePort(t)
+	next.FairyRingNode.IP = "10.0.0.1"
+
+	if err = reloader.Apply(next); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if reloader.Generation() != 2 {
+		t.Fatalf("expected generation 2, got: %d", reloader.Generation())
+	}
+	if pauseThreshold(v) != 10 || v.BalanceMonitor.Threshold().Uint64() != 42 {
+		t.Fatalf("expected thresholds applied, got: %v, %s", pauseThreshold(v), v.BalanceMonitor.Threshold())
+	}
+	if broadcaster.gasPrice != "0.1ufairy" {
+		t.Fatalf("expected gas price applied, got: %s", broadcaster.gasPrice)
+	}
+	if v.Notifier() == nil || reloader.notifier != v.Notifier() {
+		t.Fatal("expected notifier created for the new webhook")
+	}
+
+	if metrics.Port() != next.MetricsPort {
+		t.Fatalf("expected metrics moved to port %d, got: %d", next.MetricsPort, metrics.Port())
+	}
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", next.MetricsPort))
+	if err != nil {
+		t.Fatalf("expected metrics served on the new port: %s", err.Error())
+	}
+	resp.Body.Close()
+
+	// The precomputation is applied on restart
+	next.Precompute.Workers++
+	if err = reloader.Apply(next); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if reloader.current.Precompute.Workers != cfg.Precompute.Workers {
+		t.Fatalf("expected precompute workers kept until restart, got: %d", reloader.current.Precompute.Workers)
+	}
+}
+
+func TestConfigReloadAppliesLogLevel(t *testing.T) {
+	defer logging.SetLevel("")
+
+	cfg := config.DefaultConfig(false)
+	cfg.PrivateKey = "key"
+
+	v := NewValidatorClients(cfg, nil, &mockBroadcaster{address: "fairy1first"}, nil)
+	reloader := NewConfigReloader(cfg, []*ValidatorClients{v}, nil)
+
+	next := cfg
+	next.LogLevel = "verbose"
+	if err := reloader.Apply(next); err == nil {
+		t.Fatal("expected reload with an invalid log level rejected")
+	}
+
+	next.LogLevel = "debug"
+	if err := reloader.Apply(next); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !logging.Enabled(logging.LevelDebug) {
+		t.Fatal("expected debug logs enabled")
+	}
+}
+
+// redialBroadcaster records the gRPC endpoints it is redialed to, redialing failEndpoint fails
+type redialBroadcaster struct {
+	mockBroadcaster
+	failEndpoint string
+	endpoints    []string
+}
+
+func (b *redialBroadcaster) Redial(endpoint string, _ ...grpc.DialOption) error {
+	if endpoint == b.failEndpoint {
+		return errors.New("connection refused")
+	}
+	b.endpoints = append(b.endpoints, endpoint)
+	return nil
+}
+
+func TestConfigReloadSwitchesEndpoints(t *testing.T) {
+	cfg := config.DefaultConfig(false)
+	cfg.PrivateKey = "key"
+
+	broadcaster := &redialBroadcaster{mockBroadcaster: mockBroadcaster{address: "fairy1first"}, failEndpoint: "10.0.0.3:9090"}
+	v := NewValidatorClients(cfg, nil, broadcaster, nil)
+	reloader := NewConfigReloader(cfg, []*ValidatorClients{v}, nil)
+
+	source := &fakeEventSource{}
+	fanout := NewEventFanout(source)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	blocks, _ := fanout.Subscribe(ctx, "", "tm.event = 'NewBlock'")
+
+	var dialed []string
+	var closed []string
+	next := &fakeEventSource{}
+	reloader.SetEventSource(fanout, func(c config.Config) (EventSource, func(), error) {
+		if c.FairyRingNode.IP == "10.0.0.2" {
+			return nil, nil, errors.New("connection refused")
+		}
+		dialed = append(dialed, c.GetFairyRingNodeURI())
+		return next, func() { closed = append(closed, c.GetFairyRingNodeURI()) }, nil
+	}, func() { closed = append(closed, cfg.GetFairyRingNodeURI()) })
+
+	// The node endpoints are switched live, the subscribers receive the events of the new node
+	switched := cfg
+	switched.FairyRingNode.IP = "10.0.0.1"
+	if err := reloader.Apply(switched); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(broadcaster.endpoints) != 1 || broadcaster.endpoints[0] != "10.0.0.1:9090" {
+		t.Fatalf("expected the chain client redialed to 10.0.0.1:9090, got: %v", broadcaster.endpoints)
+	}
+	if len(dialed) != 1 || dialed[0] != "http://10.0.0.1:26657" {
+		t.Fatalf("expected the events subscribed on http://10.0.0.1:26657, got: %v", dialed)
+	}
+	if len(closed) != 1 || closed[0] != cfg.GetFairyRingNodeURI() {
+		t.Fatalf("expected the previous connection closed, got: %v", closed)
+	}
+	next.subscriptions("tm.event = 'NewBlock'")[0] <- coretypes.ResultEvent{Query: "1"}
+	if e := receiveEvent(t, blocks); e.Query != "1" {
+		t.Fatalf("expected event 1 from the new node, got: %s", e.Query)
+	}
+
+	// The RPC endpoint can not be connected to, the chain client is switched back & nothing is applied
+	failing := switched
+	failing.FairyRingNode.IP = "10.0.0.2"
+	failing.InvalidSharePauseThreshold = 10
+	if err := reloader.Apply(failing); err == nil {
+		t.Fatal("expected reload rejected when the RPC endpoint can not be connected to")
+	}
+	if len(broadcaster.endpoints) != 3 || broadcaster.endpoints[2] != "10.0.0.1:9090" {
+		t.Fatalf("expected the chain client switched back to 10.0.0.1:9090, got: %v", broadcaster.endpoints)
+	}
+	if reloader.Generation() != 2 || pauseThreshold(v) == 10 {
+		t.Fatalf("expected nothing applied, got generation: %d", reloader.Generation())
+	}
+
+	// The gRPC endpoint can not be connected to, the events are not switched
+	failing.FairyRingNode.IP = "10.0.0.3"
+	if err := reloader.Apply(failing); err == nil {
+		t.Fatal("expected reload rejected when the gRPC endpoint can not be connected to")
+	}
+	if len(dialed) != 1 || reloader.Generation() != 2 {
+		t.Fatalf("expected the events kept on the node in use, got: %v", dialed)
+	}
+}
+
+func TestConfigReloadRejectsUnsafeChanges(t *testing.T) {
+	cfg := config.DefaultConfig(false)
+	cfg.PrivateKey = "key"
+
+	v := NewValidatorClients(cfg, nil, &mockBroadcaster{address: "fairy1first"}, nil)
+	reloader := NewConfigReloader(cfg, []*ValidatorClients{v}, nil)
+
+	for name, change := range map[string]func(c *config.Config){
+		"key":       func(c *config.Config) { c.PrivateKey = "other-key" },
+		"chain id":  func(c *config.Config) { c.FairyRingNode.ChainID = "other-chain" },
+		"validator": func(c *config.Config) { c.Validators = []config.Validator{{Name: "second", PrivateKey: "second-key"}} },
+		"gas price": func(c *config.Config) { c.FairyRingNode.GasPrice = "invalid" },
+	} {
+		next := cfg
+		next.InvalidSharePauseThreshold = 10
+		change(&next)
+
+		if err := reloader.Apply(next); err == nil {
+			t.Fatalf("expected reload changing %s rejected", name)
+		}
+		if reloader.Generation() != 1 || pauseThreshold(v) != float64(cfg.InvalidSharePauseThreshold) {
+			t.Fatalf("expected nothing applied when changing %s, got generation: %d, threshold: %v", name, reloader.Generation(), pauseThreshold(v))
+		}
+	}
+}
+
+func TestConfigReloadDryRunNeverNotifies(t *testing.T) {
+	cfg := config.DefaultConfig(false)
+	cfg.PrivateKey = "key"
+
+	v := NewValidatorClients(cfg, nil, &mockBroadcaster{address: "fairy1first"}, nil)
+	v.EnableDryRun()
+	reloader := NewConfigReloader(cfg, []*ValidatorClients{v}, nil)
+
+	next := cfg
+	next.Notifier.WebhookURL = "http://127.0.0.1:1/webhook"
+	if err := reloader.Apply(next); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if v.Notifier() != nil || reloader.notifier != nil {
+		t.Fatal("expected no notifier created in dry run mode")
+	}
+	reloader.Close()
+}
@@ -0,0 +1,150 @@
+package fairyringclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fairyringclient/config"
+	"fairyringclient/internal/eventlog"
+	"fairyringclient/pkg/cosmosClient"
+	"fairyringclient/pkg/cosmosClient/fakechain"
+
+	"github.com/Fairblock/fairyring/x/keyshare/types"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+// newFakeChainValidators starts a fake chain with a round of 4 validators, and creates a client for the first n of them
+func newFakeChainValidators(t *testing.T, n int) (*fakechain.FakeChain, []*ValidatorClients) {
+	t.Helper()
+
+	cosmostypes.GetConfig().SetBech32PrefixForAccount("fairy", "fairypub")
+
+	validators := make([]fakechain.Validator, 4)
+	for i := range validators {
+		validators[i] = fakechain.NewValidator()
+	}
+	round, err := fakechain.NewRound(validators, 3)
+	if err != nil {
+		t.Fatalf("error creating round: %s", err.Error())
+	}
+
+	chain := fakechain.New()
+	t.Cleanup(chain.Close)
+	chain.SetRound(round, 100, false)
+
+	clients := make([]*ValidatorClients, n)
+	for i := 0; i < n; i++ {
+		chain.AddAccount(validators[i].Address, uint64(i), 0)
+		chain.SetAuthorized(validators[i].Address, true)
+
+		client, err := cosmosClient.NewCosmosClient(fakechain.Endpoint, validators[i].PrivateKeyHex, "fairyring-test", chain.DialOption())
+		if err != nil {
+			t.Fatalf("error creating cosmos client: %s", err.Error())
+		}
+		clients[i] = NewValidatorClients(config.DefaultConfig(false), client, client, nil)
+	}
+
+	return chain, clients
+}
+
+// replayTrace runs the validators on the events of the trace until the expected number of txs is broadcast
+func replayTrace(t *testing.T, path string, chain *fakechain.FakeChain, validators []*ValidatorClients, expectedTxs int) []fakechain.BroadcastTx {
+	t.Helper()
+
+	entries, err := eventlog.ReadEntries(path)
+	if err != nil {
+		t.Fatalf("error opening trace: %s", err.Error())
+	}
+
+	replayers := make([]*eventlog.Replayer, len(validators))
+	for i, v := range validators {
+		replayers[i] = eventlog.NewReplayer(entries, 0)
+		v.Events = replayers[i]
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		RunValidators(ctx, validators)
+		close(stopped)
+	}()
+
+	deadline := time.After(10 * time.Second)
+	for len(chain.Broadcasts()) < expectedTxs {
+		select {
+		case <-stopped:
+			t.Fatal("validators stopped before broadcasting all txs")
+		case <-deadline:
+			t.Fatalf("timeout waiting for %d txs, got: %d", expectedTxs, len(chain.Broadcasts()))
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	for _, r := range replayers {
+		<-r.Done()
+	}
+	return chain.Broadcasts()
+}
+
+func TestReplayTrace(t *testing.T) {
+	chain, validators := newFakeChainValidators(t, 1)
+	broadcasts := replayTrace(t, "testdata/trace.jsonl", chain, validators, 4)
+
+	heights := make(map[uint64]bool)
+	generalIdentities := make(map[string]bool)
+	for _, b := range broadcasts {
+		switch msg := b.Msgs[0].(type) {
+		case *types.MsgSendKeyshare:
+			heights[msg.BlockHeight] = true
+		case *types.MsgSubmitGeneralKeyshare:
+			generalIdentities[msg.IdValue] = true
+		default:
+			t.Fatalf("unexpected msg: %T", msg)
+		}
+	}
+
+	for _, h := range []uint64{11, 12, 13} {
+		if !heights[h] {
+			t.Fatalf("expected keyshare submitted for height %d, got: %v", h, heights)
+		}
+	}
+	if !generalIdentities["gov-proposal-1"] {
+		t.Fatalf("expected general keyshare submitted for gov-proposal-1, got: %v", generalIdentities)
+	}
+}
+
+func TestMultipleValidatorsReplayTrace(t *testing.T) {
+	chain, validators := newFakeChainValidators(t, 2)
+	broadcasts := replayTrace(t, "testdata/trace.jsonl", chain, validators, 8)
+
+	// Each validator submits with its own account & share index
+	submitted := make(map[string]map[uint64]uint64)
+	for _, b := range broadcasts {
+		msg, ok := b.Msgs[0].(*types.MsgSendKeyshare)
+		if !ok {
+			continue
+		}
+		if _, found := submitted[msg.Creator]; !found {
+			submitted[msg.Creator] = make(map[uint64]uint64)
+		}
+		submitted[msg.Creator][msg.BlockHeight] = msg.KeyshareIndex
+	}
+
+	for i, v := range validators {
+		heights, found := submitted[v.Broadcaster.GetAddress()]
+		if !found || len(heights) != 3 {
+			t.Fatalf("expected validator %d to submit keyshares for 3 heights, got: %v", i, heights)
+		}
+		for h, index := range heights {
+			if index != uint64(i+1) {
+				t.Fatalf("expected validator %d to submit with index %d for height %d, got: %d", i, i+1, h, index)
+			}
+		}
+		if seq := chain.Sequence(v.Broadcaster.GetAddress()); seq != 4 {
+			t.Fatalf("expected sequence 4 for validator %d, got: %d", i, seq)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package fairyringclient
+
+import (
+	"sync"
+
+	"fairyringclient/internal/audit"
+	"fairyringclient/internal/chainevents"
+	"fairyringclient/internal/logging"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// keyshareRequestWorkers is the max number of general & encrypted keyshare requests derived & queued at the same time
+	keyshareRequestWorkers = 8
+	// handledRequestCapacity is the number of handled requests remembered to skip the requests repeated in later blocks
+	handledRequestCapacity = 10000
+)
+
+const (
+	requestOutcomeSubmitted = "submitted"
+	requestOutcomeDuplicate = "duplicate"
+	requestOutcomeSkipped   = "skipped"
+	requestOutcomeFailed    = "failed"
+)
+
+var keyshareRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "fairyringclient_keyshare_requests",
+	Help: "The total number of general & encrypted keyshare requests by type & outcome: submitted, duplicate, skipped, failed",
+}, []string{"validator", "type", "outcome"})
+
+// keyshareRequest is a general or encrypted keyshare requested by the chain in a block
+type keyshareRequest struct {
+	Type      audit.SubmissionType
+	Identity  string
+	Requester string
+	Pubkey    string
+}
+
+// key identifies the request, an identity can be requested encrypted by different requesters
+func (r keyshareRequest) key() string {
+	return string(r.Type) + "/" + r.Identity + "/" + r.Requester
+}
+
+// keyshareRequestResult is the outcome of a request, the tx result of submitted requests is in the audit log
+type keyshareRequestResult struct {
+	Request keyshareRequest
+	Outcome string
+}
+
+// requestTracker remembers the latest handled requests and bounds the number of requests handled at the same time
+type requestTracker struct {
+	mu      sync.Mutex
+	handled map[string]struct{}
+	order   []string
+	slots   chan struct{}
+}
+
+func newRequestTracker() *requestTracker {
+	return &requestTracker{
+		handled: make(map[string]struct{}),
+		slots:   make(chan struct{}, keyshareRequestWorkers),
+	}
+}
+
+// claim marks the request as handled, returns false if it is handled already.
+// The oldest request is forgotten once handledRequestCapacity requests are remembered
+func (t *requestTracker) claim(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, found := t.handled[key]; found {
+		return false
+	}
+	if len(t.order) >= handledRequestCapacity {
+		delete(t.handled, t.order[0])
+		t.order = t.order[1:]
+	}
+	t.handled[key] = struct{}{}
+	t.order = append(t.order, key)
+	return true
+}
+
+// release forgets the request, so it is handled again if requested in a later block
+func (t *requestTracker) release(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, found := t.handled[key]; !found {
+		return
+	}
+	delete(t.handled, key)
+	for i, k := range t.order {
+		if k == key {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// blockEventHandlers registers the handlers of the events in a new block, the keyshare requests are collected in requests
+func (v *ValidatorClients) blockEventHandlers(requests *[]keyshareRequest) *chainevents.Registry {
+	r := chainevents.NewRegistry()
+	chainevents.On(r, func(e chainevents.StartSendGeneralKeyshare) {
+		*requests = append(*requests, keyshareRequest{Type: audit.TypeGeneralKeyshare, Identity: e.Identity})
+	})
+	chainevents.On(r, func(e chainevents.StartSendEncryptedKeyshare) {
+		*requests = append(*requests, keyshareRequest{
+			Type:      audit.TypeEncryptedKeyshare,
+			Identity:  e.Identity,
+			Requester: e.Requester,
+			Pubkey:    e.Pubkey,
+		})
+	})
+	chainevents.On(r, v.handleGeneralKeyshareAggregatedEvent)
+	return r
+}
+
+// handleKeyshareRequests handles the requests concurrently, up to keyshareRequestWorkers at a time between all the blocks.
+// A request handled already in this or an earlier block is skipped, the requests that are not submitted can be requested again.
+// Returns the outcome of each request in order
+func (v *ValidatorClients) handleKeyshareRequests(requests []keyshareRequest) []keyshareRequestResult {
+	results := make([]keyshareRequestResult, len(requests))
+
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		results[i].Request = req
+		if !v.handledRequests.claim(req.key()) {
+			results[i].Outcome = requestOutcomeDuplicate
+			continue
+		}
+
+		wg.Add(1)
+		v.handledRequests.slots <- struct{}{}
+		go func(i int, req keyshareRequest) {
+			defer wg.Done()
+			defer func() { <-v.handledRequests.slots }()
+
+			results[i].Outcome = v.handleKeyshareRequest(req)
+			if results[i].Outcome != requestOutcomeSubmitted {
+				v.handledRequests.release(req.key())
+			}
+		}(i, req)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		keyshareRequests.WithLabelValues(v.Broadcaster.GetAddress(), string(result.Request.Type), result.Outcome).Inc()
+		if result.Request.Type == audit.TypeEncryptedKeyshare {
+			logging.Infof("Encrypted KeyShare request for Identity %s Requester %s: %s\n", result.Request.Identity, result.Request.Requester, result.Outcome)
+		} else {
+			logging.Infof("General KeyShare request for Identity %s: %s\n", result.Request.Identity, result.Outcome)
+		}
+	}
+
+	return results
+}
+
+// retryKeyshareRequest handles the request again, such as after its tx was rejected for an outdated account sequence.
+// It goes through the tracker & the slots as the requests of a block, so it counts toward the bounded parallelism
+func (v *ValidatorClients) retryKeyshareRequest(req keyshareRequest) []keyshareRequestResult {
+	v.handledRequests.release(req.key())
+	return v.handleKeyshareRequests([]keyshareRequest{req})
+}
+
+func (v *ValidatorClients) handleKeyshareRequest(req keyshareRequest) string {
+	if req.Type == audit.TypeEncryptedKeyshare {
+		return v.handleStartSubmitEncryptedKeyShareEvent(req.Identity, req.Pubkey, req.Requester)
+	}
+	return v.handleStartSubmitGeneralKeyShareEvent(req.Identity)
+}
@@ -0,0 +1,200 @@
+package fairyringclient
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fairyringclient/config"
+
+	"github.com/Fairblock/fairyring/x/keyshare/types"
+	"github.com/btcsuite/btcd/btcec"
+	abciTypes "github.com/cometbft/cometbft/abci/types"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+)
+
+func generalKeyshareEvent(identity string) abciTypes.Event {
+	return abciTypes.Event{
+		Type:       "start-send-general-keyshare",
+		Attributes: []abciTypes.EventAttribute{{Key: "identity", Value: identity}},
+	}
+}
+
+func encryptedKeyshareEvent(identity, requester, pubkey string) abciTypes.Event {
+	return abciTypes.Event{
+		Type: "start-send-encrypted-keyshare",
+		Attributes: []abciTypes.EventAttribute{
+			{Key: "identity", Value: identity},
+			{Key: "requester", Value: requester},
+			{Key: "secp256k1-pubkey", Value: pubkey},
+		},
+	}
+}
+
+func outcomes(results []keyshareRequestResult) []string {
+	out := make([]string, len(results))
+	for i, result := range results {
+		out[i] = result.Outcome
+	}
+	return out
+}
+
+func submittedIdentities(b *mockBroadcaster) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var identities []string
+	for _, msg := range b.msgs {
+		switch m := msg.(type) {
+		case *types.MsgSubmitGeneralKeyshare:
+			identities = append(identities, m.IdValue)
+		case *types.MsgSubmitEncryptedKeyshare:
+			identities = append(identities, m.Identity+"@"+m.Requester)
+		}
+	}
+	sort.Strings(identities)
+	return identities
+}
+
+func TestHandleEveryKeyshareRequestInBlock(t *testing.T) {
+	setup := newDealerSetup(t)
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("error generating private key: %s", err.Error())
+	}
+	pubKey := base64.StdEncoding.EncodeToString(privKey.PubKey().SerializeCompressed())
+
+	b := &mockBroadcaster{address: "fairy1validator"}
+	v := NewValidatorClients(config.DefaultConfig(false), nil, b, nil)
+	v.CurrentShare = setup.keyShare(t, 1)
+
+	results := v.handleEndBlockEvents([]abciTypes.Event{
+		generalKeyshareEvent("gov-1"),
+		generalKeyshareEvent("gov-2"),
+		generalKeyshareEvent("gov-1"),
+		encryptedKeyshareEvent("id-1", "fairy1alice", pubKey),
+		encryptedKeyshareEvent("id-1", "fairy1bob", pubKey),
+		generalKeyshareEvent("gov-3"),
+	})
+
+	expected := []string{
+		requestOutcomeSubmitted, requestOutcomeSubmitted, requestOutcomeDuplicate,
+		requestOutcomeSubmitted, requestOutcomeSubmitted, requestOutcomeSubmitted,
+	}
+	for i, outcome := range outcomes(results) {
+		if outcome != expected[i] {
+			t.Fatalf("expected outcomes %v, got: %v", expected, outcomes(results))
+		}
+	}
+
+	submitted := submittedIdentities(b)
+	expectedSubmitted := []string{"gov-1", "gov-2", "gov-3", "id-1@fairy1alice", "id-1@fairy1bob"}
+	if len(submitted) != len(expectedSubmitted) {
+		t.Fatalf("expected submitted %v, got: %v", expectedSubmitted, submitted)
+	}
+	for i := range submitted {
+		if submitted[i] != expectedSubmitted[i] {
+			t.Fatalf("expected submitted %v, got: %v", expectedSubmitted, submitted)
+		}
+	}
+
+	// The requests handled in an earlier block are skipped
+	results = v.handleEndBlockEvents([]abciTypes.Event{generalKeyshareEvent("gov-2"), generalKeyshareEvent("gov-4")})
+	if got := outcomes(results); got[0] != requestOutcomeDuplicate || got[1] != requestOutcomeSubmitted {
+		t.Fatalf("expected gov-2 duplicate & gov-4 submitted, got: %v", got)
+	}
+
+	// The requests skipped while optional duties are paused are handled when requested again
+	v.PauseOptionalDuties()
+	results = v.handleEndBlockEvents([]abciTypes.Event{generalKeyshareEvent("gov-5")})
+	if got := outcomes(results); got[0] != requestOutcomeSkipped {
+		t.Fatalf("expected gov-5 skipped, got: %v", got)
+	}
+	v.UnpauseOptionalDuties()
+	results = v.handleEndBlockEvents([]abciTypes.Event{generalKeyshareEvent("gov-5")})
+	if got := outcomes(results); got[0] != requestOutcomeSubmitted {
+		t.Fatalf("expected gov-5 submitted, got: %v", got)
+	}
+
+	if got := len(submittedIdentities(b)); got != 7 {
+		t.Fatalf("expected 7 submissions, got: %d", got)
+	}
+
+	// The requests skipped in dry run mode or as HA standby are not submitted, so they are handled when requested again
+	v.DryRun = true
+	results = v.handleEndBlockEvents([]abciTypes.Event{generalKeyshareEvent("gov-6")})
+	if got := outcomes(results); got[0] != requestOutcomeSkipped {
+		t.Fatalf("expected gov-6 skipped in dry run mode, got: %v", got)
+	}
+	v.DryRun = false
+	results = v.handleEndBlockEvents([]abciTypes.Event{generalKeyshareEvent("gov-6")})
+	if got := outcomes(results); got[0] != requestOutcomeSubmitted {
+		t.Fatalf("expected gov-6 submitted, got: %v", got)
+	}
+}
+
+// sequenceMismatchBroadcaster rejects the first tx for an outdated account sequence
+type sequenceMismatchBroadcaster struct {
+	mockBroadcaster
+	rejected atomic.Bool
+}
+
+func (b *sequenceMismatchBroadcaster) AddTxToQueue(
+	msg cosmostypes.Msg,
+	adjustGas bool,
+	errHandler func(error),
+	successHandler func(*tx.GetTxResponse),
+) {
+	if b.rejected.CompareAndSwap(false, true) {
+		errHandler(errors.New("account sequence mismatch, expected 2, got 1"))
+		return
+	}
+	b.mockBroadcaster.AddTxToQueue(msg, adjustGas, errHandler, successHandler)
+}
+
+func TestRetryKeyshareRequestOnSequenceMismatch(t *testing.T) {
+	setup := newDealerSetup(t)
+
+	b := &sequenceMismatchBroadcaster{mockBroadcaster: mockBroadcaster{address: "fairy1validator"}}
+	v := NewValidatorClients(config.DefaultConfig(false), nil, b, nil)
+	v.CurrentShare = setup.keyShare(t, 1)
+
+	v.handleEndBlockEvents([]abciTypes.Event{generalKeyshareEvent("gov-1")})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(submittedIdentities(&b.mockBroadcaster)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the request to be retried")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The retried request is claimed again, so it is a duplicate if requested in a later block
+	results := v.handleEndBlockEvents([]abciTypes.Event{generalKeyshareEvent("gov-1")})
+	if got := outcomes(results); got[0] != requestOutcomeDuplicate {
+		t.Fatalf("expected gov-1 duplicate after the retry, got: %v", got)
+	}
+}
+
+func TestRequestTrackerForgetsOldest(t *testing.T) {
+	tracker := newRequestTracker()
+	for i := 0; i < handledRequestCapacity; i++ {
+		tracker.claim(strconv.Itoa(i))
+	}
+	if tracker.claim("0") {
+		t.Fatal("expected the request to be remembered")
+	}
+
+	if !tracker.claim("new") {
+		t.Fatal("expected new request to be claimed")
+	}
+	if !tracker.claim("0") {
+		t.Fatal("expected the oldest request to be forgotten")
+	}
+}
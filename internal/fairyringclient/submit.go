@@ -0,0 +1,114 @@
+package fairyringclient
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"fairyringclient/internal/logging"
+
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	dryRunSkippedSubmission = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fairyringclient_dry_run_skipped_submission",
+		Help: "The total number of submissions derived but not broadcast in dry run mode",
+	}, []string{"validator", "type"})
+	standbySkippedSubmission = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "fairyringclient_standby_skipped_submission",
+		Help: "The total number of submissions derived but not broadcast while running as HA standby",
+	}, []string{"validator", "type"})
+	haLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fairyringclient_ha_leader",
+		Help: "1 if the client is the active submitter between the HA replicas, 0 if it is a standby",
+	}, []string{"validator"})
+)
+
+// SubmitTx queues the msg to be broadcast, in dry run mode the msg is only logged and never broadcast,
+// a HA standby skips the msg as the active replica submits it.
+// Returns false if the msg is skipped, the handlers are only called for the queued msgs
+func (v *ValidatorClients) SubmitTx(
+	submissionType string,
+	msg cosmostypes.Msg,
+	errHandler func(error),
+	successHandler func(*tx.GetTxResponse),
+) bool {
+	if v.DryRun {
+		logging.Infof("[DRY RUN] Would submit %s: %s\n", submissionType, msg.String())
+		dryRunSkippedSubmission.WithLabelValues(v.Broadcaster.GetAddress(), submissionType).Inc()
+		return false
+	}
+
+	if !v.Elector.IsLeader() {
+		logging.Infof("[STANDBY] Skip submitting %s, submitted by the active replica\n", submissionType)
+		standbySkippedSubmission.WithLabelValues(v.Broadcaster.GetAddress(), submissionType).Inc()
+		return false
+	}
+
+	v.Broadcaster.AddTxToQueue(msg, true, errHandler, successHandler)
+	return true
+}
+
+// leaseRenewInterval is the interval the HA lease is renewed at, shorter than a block so it is renewed at each height
+const leaseRenewInterval = time.Second
+
+// startElection takes part in the HA election before the validators start, then renews the lease in the background
+// until ctx is done. The elector is shared by the validators of the process, so it is driven once for all of them
+// and the validators only read the cached leadership. The returned channel is closed once the lease is released
+// and the registrations started on a takeover are done
+func startElection(ctx context.Context, validators []*ValidatorClients) (<-chan struct{}, error) {
+	done := make(chan struct{})
+	if len(validators) == 0 || validators[0].Elector == nil {
+		close(done)
+		return done, nil
+	}
+
+	elector := validators[0].Elector
+	querier := validators[0].Querier
+	height, err := querier.GetLatestHeight()
+	if err != nil {
+		close(done)
+		return done, errors.Wrap(err, "error getting latest height for HA election")
+	}
+	wasLeader := elector.Observe(height)
+	setHALeader(validators, wasLeader)
+	log.Printf("HA enabled, Running as replica: %s | Active submitter: %t\n", elector.ID(), wasLeader)
+
+	go func() {
+		defer close(done)
+		var registrations sync.WaitGroup
+		defer registrations.Wait()
+		elector.Run(ctx, leaseRenewInterval, querier.GetLatestHeight, func(isLeader bool) {
+			setHALeader(validators, isLeader)
+			// A standby skipped the registration on start, it registers when it takes over.
+			// It runs in the background so a slow broadcast does not delay the lease renewal
+			if isLeader && !wasLeader {
+				for _, v := range validators {
+					registrations.Add(1)
+					go func(v *ValidatorClients) {
+						defer registrations.Done()
+						v.registerIfUnauthorized()
+					}(v)
+				}
+			}
+			wasLeader = isLeader
+		})
+	}()
+	return done, nil
+}
+
+func setHALeader(validators []*ValidatorClients, isLeader bool) {
+	value := float64(0)
+	if isLeader {
+		value = 1
+	}
+	for _, v := range validators {
+		haLeader.WithLabelValues(v.Broadcaster.GetAddress()).Set(value)
+	}
+}
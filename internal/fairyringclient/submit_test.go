@@ -0,0 +1,67 @@
+package fairyringclient
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fairyringclient/internal/election"
+
+	"github.com/Fairblock/fairyring/x/keyshare/types"
+)
+
+func registrations(f *fakeChainValidator) int {
+	count := 0
+	for _, tx := range f.chain.Broadcasts() {
+		for _, msg := range tx.Msgs {
+			if _, ok := msg.(*types.MsgRegisterValidator); ok {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestStartElectionRegistersOnTakeover(t *testing.T) {
+	f := newFakeChainValidator(t)
+	f.chain.SetAuthorized(f.Broadcaster.GetAddress(), false)
+
+	backend, err := election.NewFileBackend(filepath.Join(t.TempDir(), "lease.json"))
+	if err != nil {
+		t.Fatalf("error creating lease backend: %s", err.Error())
+	}
+	if !election.NewElector(backend, "other", 3).Observe(10) {
+		t.Fatal("expected the other replica to take the lease")
+	}
+	f.Elector = election.NewElector(backend, "replica", 3)
+	f.chain.SetLatestHeight(10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done, err := startElection(ctx, []*ValidatorClients{f.ValidatorClients})
+	if err != nil {
+		t.Fatalf("error starting election: %s", err.Error())
+	}
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	f.registerIfUnauthorized()
+	if count := registrations(f); count != 0 {
+		t.Fatalf("expected standby not to register, got %d registrations", count)
+	}
+
+	// The other replica stops renewing, the lease lapses & this replica takes over
+	f.chain.SetLatestHeight(13)
+	deadline := time.Now().Add(5 * time.Second)
+	for registrations(f) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the replica to register once it became the active submitter")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !f.Elector.IsLeader() {
+		t.Fatal("expected the replica to be the active submitter")
+	}
+}
@@ -2,39 +2,92 @@ package fairyringclient
 
 import (
 	"encoding/hex"
-	"fairyringclient/pkg/cosmosClient"
+	"fairyringclient/internal/audit"
+	"fairyringclient/internal/chainevents"
+	"fairyringclient/internal/election"
+	"fairyringclient/internal/notifier"
+	"fmt"
 	distIBE "github.com/FairBlock/DistributedIBE"
 	"github.com/Fairblock/fairyring/x/keyshare/types"
 	"github.com/drand/kyber"
 	bls "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/pairing"
 	"github.com/pkg/errors"
 	"log"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
+var ErrInvalidDerivedKeyShare = errors.New("derived keyshare failed verification")
+
+// suite is stateless and safe for concurrent use, so it is shared instead of being created on each derivation
+var suite = bls.NewBLS12381Suite()
+
 type KeyShare struct {
-	Share *distIBE.Share
-	Index uint64
+	Share      *distIBE.Share
+	Index      uint64
+	Commitment kyber.Point
 }
 
+// ValidatorClients submits the keyshares of one validator.
+// The shares, their expiry & the commitments are guarded by sharesMu once the client runs, they are read & swapped through the methods.
+// shareRefreshMu serializes fetching the shares from chain & swapping them in.
+// The notifier is replaced on config reload, it is read & set through the methods
 type ValidatorClients struct {
-	CosmosClient            *cosmosClient.CosmosClient
+	Name                    string
+	Querier                 ChainQuerier
+	Broadcaster             TxBroadcaster
+	Events                  EventSource
 	Commitments             *types.QueryCommitmentsResponse
 	CurrentShare            *KeyShare
 	PendingShare            *KeyShare
 	CurrentShareExpiryBlock uint64
 	PendingShareExpiryBlock uint64
 	InvalidShareInARow      uint64
-	Paused                  bool
+	BalanceMonitor          *BalanceMonitor
+	AuditLog                *audit.Log
+	DryRun                  bool
+	FailedSubmissionInARow  uint64
+	AggregatedKeyVerifier   *AggregatedKeyVerifier
+	Precomputer             *KeySharePrecomputer
+	Elector                 *election.Elector
+	Policy                  *PolicyEngine
+	TxEventHandlers         *chainevents.Registry
+	notifier                atomic.Pointer[notifier.Notifier]
+	shareRefreshing         atomic.Bool
+	shareRefreshMu          sync.Mutex
+	sharesMu                sync.RWMutex
+	paused                  atomic.Bool
+	optionalDutiesPaused    atomic.Bool
+	handledRequests         *requestTracker
 }
 
 func (v *ValidatorClients) IsAccountAuthorized() bool {
-	return v.CosmosClient.IsAddrAuthorized(v.CosmosClient.GetAddress())
+	return v.Querier.IsAddrAuthorized(v.Broadcaster.GetAddress())
+}
+
+// registerIfUnauthorized registers the account in keyshare module if it is not authorized yet.
+// A HA standby skips it, the registration runs again once it becomes the active submitter
+func (v *ValidatorClients) registerIfUnauthorized() {
+	if v.IsAccountAuthorized() {
+		log.Println("Account is Authorized, skip registering in keyshare module.")
+		return
+	}
+	if v.DryRun {
+		log.Println("Account is not Authorized, skip registering in keyshare module in dry run mode.")
+		return
+	}
+	if !v.Elector.IsLeader() {
+		log.Println("Account is not Authorized, skip registering in keyshare module as HA standby.")
+		return
+	}
+	v.RegisterValidatorSet()
 }
 
 func (v *ValidatorClients) RegisterValidatorSet() {
-	addr := v.CosmosClient.GetAddress()
-	_, err := validatorCosmosClient.CosmosClient.BroadcastTx(&types.MsgRegisterValidator{
+	addr := v.Broadcaster.GetAddress()
+	_, err := v.Broadcaster.BroadcastTx(&types.MsgRegisterValidator{
 		Creator: addr,
 	}, true)
 	if err != nil {
@@ -47,8 +100,8 @@ func (v *ValidatorClients) RegisterValidatorSet() {
 
 //
 //func (v *ValidatorClients) UnregisterValidatorSet() {
-//	addr := v.CosmosClient.GetAddress()
-//	_, err := validatorCosmosClient.CosmosClient.BroadcastTx(&types.MsgUnregisterValidator{
+//	addr := v.Broadcaster.GetAddress()
+//	_, err := v.Broadcaster.BroadcastTx(&types.MsgUnregisterValidator{
 //		Creator: addr,
 //	}, true)
 //	if err != nil {
@@ -59,27 +112,121 @@ func (v *ValidatorClients) RegisterValidatorSet() {
 //	log.Printf("%s Unregistered Validator", addr)
 //}
 
-func (v *ValidatorClients) Pause() {
-	v.Paused = true
+// Notifier returns the notifier of the validator, nil if notifications are disabled
+func (v *ValidatorClients) Notifier() *notifier.Notifier {
+	return v.notifier.Load()
+}
+
+// SetNotifier replaces the notifier of the validator, nil disables notifications
+func (v *ValidatorClients) SetNotifier(n *notifier.Notifier) {
+	v.notifier.Store(n)
+}
+
+// Pause stops submitting keyshares, it returns false if the client was already paused
+func (v *ValidatorClients) Pause() bool {
+	return v.paused.CompareAndSwap(false, true)
+}
+
+// Unpause resumes submitting keyshares, it returns false if the client was not paused
+func (v *ValidatorClients) Unpause() bool {
+	return v.paused.CompareAndSwap(true, false)
+}
+
+// Paused returns true if submitting keyshares is paused
+func (v *ValidatorClients) Paused() bool {
+	return v.paused.Load()
 }
 
-func (v *ValidatorClients) Unpause() {
-	v.Paused = false
+// PauseOptionalDuties stops submitting general & encrypted keyshares, block keyshares are still submitted.
+// It returns false if the optional duties were already paused
+func (v *ValidatorClients) PauseOptionalDuties() bool {
+	return v.optionalDutiesPaused.CompareAndSwap(false, true)
+}
+
+// UnpauseOptionalDuties resumes submitting general & encrypted keyshares, it returns false if they were not paused
+func (v *ValidatorClients) UnpauseOptionalDuties() bool {
+	return v.optionalDutiesPaused.CompareAndSwap(true, false)
+}
+
+// OptionalDutiesPaused returns true if submitting general & encrypted keyshares is paused
+func (v *ValidatorClients) OptionalDutiesPaused() bool {
+	return v.optionalDutiesPaused.Load()
 }
 
 func (v *ValidatorClients) SetCommitments(c *types.QueryCommitmentsResponse) {
+	v.sharesMu.Lock()
+	defer v.sharesMu.Unlock()
 	v.Commitments = c
 }
 
-func (v *ValidatorClients) IncreaseInvalidShareNum() {
-	v.InvalidShareInARow = v.InvalidShareInARow + 1
+// IncreaseInvalidShareNum counts an invalid keyshare, the counters are updated atomically
+// since the tx results of the keyshares & requests are handled concurrently. Returns the new count
+func (v *ValidatorClients) IncreaseInvalidShareNum() uint64 {
+	return atomic.AddUint64(&v.InvalidShareInARow, 1)
 }
 
 func (v *ValidatorClients) ResetInvalidShareNum() {
-	v.InvalidShareInARow = 0
+	atomic.StoreUint64(&v.InvalidShareInARow, 0)
+}
+
+// InvalidShareNum returns the number of invalid keyshares in a row
+func (v *ValidatorClients) InvalidShareNum() uint64 {
+	return atomic.LoadUint64(&v.InvalidShareInARow)
+}
+
+func (v *ValidatorClients) IncreaseFailedSubmissionNum() uint64 {
+	return atomic.AddUint64(&v.FailedSubmissionInARow, 1)
 }
 
+func (v *ValidatorClients) ResetFailedSubmissionNum() {
+	atomic.StoreUint64(&v.FailedSubmissionInARow, 0)
+}
+
+// RecordSubmissionFailure counts a failed submission and notifies once the number of failures in a row reaches the alert threshold
+func (v *ValidatorClients) RecordSubmissionFailure(height uint64, reason string) {
+	failed := v.IncreaseFailedSubmissionNum()
+	if failed < v.Notifier().SubmissionFailuresToAlert() {
+		return
+	}
+	v.notify(notifier.Event{
+		Type:    notifier.EventSubmissionFailures,
+		Message: fmt.Sprintf("%d submissions failed in a row", failed),
+		Height:  height,
+		Fields:  map[string]string{"reason": reason},
+	})
+}
+
+// notify sends the event with the address of the validator, so the events of the validators run in the same process can be told apart
+func (v *ValidatorClients) notify(e notifier.Event) {
+	fields := make(map[string]string, len(e.Fields)+1)
+	for k, val := range e.Fields {
+		fields[k] = val
+	}
+	fields["address"] = v.Broadcaster.GetAddress()
+	e.Fields = fields
+
+	v.Notifier().Notify(e)
+}
+
+// GetCurrentShare returns the current share & its expiry block, the share is nil if not found
+func (v *ValidatorClients) GetCurrentShare() (*KeyShare, uint64) {
+	v.sharesMu.RLock()
+	defer v.sharesMu.RUnlock()
+	return v.CurrentShare, v.CurrentShareExpiryBlock
+}
+
+// GetPendingShare returns the share of the next round & its expiry block, the share is nil if not found
+func (v *ValidatorClients) GetPendingShare() (*KeyShare, uint64) {
+	v.sharesMu.RLock()
+	defer v.sharesMu.RUnlock()
+	return v.PendingShare, v.PendingShareExpiryBlock
+}
+
+// ActivatePendingShare switches to the share of the next round
 func (v *ValidatorClients) ActivatePendingShare() {
+	v.sharesMu.Lock()
+	defer v.sharesMu.Unlock()
+
 	v.CurrentShare = v.PendingShare
 	v.CurrentShareExpiryBlock = v.PendingShareExpiryBlock
 	v.PendingShare = nil
@@ -87,107 +234,219 @@ func (v *ValidatorClients) ActivatePendingShare() {
 }
 
 func (v *ValidatorClients) RemoveCurrentShare() {
+	v.sharesMu.Lock()
+	defer v.sharesMu.Unlock()
+
 	v.CurrentShare = nil
 	v.CurrentShareExpiryBlock = 0
 }
 
 func (v *ValidatorClients) RemovePendingShare() {
+	v.sharesMu.Lock()
+	defer v.sharesMu.Unlock()
+
 	v.PendingShare = nil
 	v.PendingShareExpiryBlock = 0
 }
 
+// setShare swaps in a verified share with the commitments it is verified against
+func (v *ValidatorClients) setShare(forNextRound bool, share *KeyShare, expiry uint64, commits *types.QueryCommitmentsResponse) {
+	v.sharesMu.Lock()
+	defer v.sharesMu.Unlock()
+
+	if forNextRound {
+		v.PendingShare = share
+		v.PendingShareExpiryBlock = expiry
+	} else {
+		v.CurrentShare = share
+		v.CurrentShareExpiryBlock = expiry
+	}
+	v.Commitments = commits
+}
+
+// UpdateKeyShareFromChain fetches the current or pending share & the commitments, the share is only swapped in once it is verified
 func (v *ValidatorClients) UpdateKeyShareFromChain(forNextRound bool) error {
-	share, shareIndex, expiry, err := v.CosmosClient.GetKeyShare(forNextRound)
+	v.shareRefreshMu.Lock()
+	defer v.shareRefreshMu.Unlock()
+
+	return v.updateKeyShareFromChain(forNextRound)
+}
+
+func (v *ValidatorClients) updateKeyShareFromChain(forNextRound bool) error {
+	share, shareIndex, expiry, err := v.Querier.GetKeyShare(forNextRound)
 	if err != nil {
 		return err
 	}
 
-	commits, err := v.CosmosClient.GetCommitments()
-	for err != nil {
+	commits, err := v.Querier.GetCommitments()
+	if err != nil {
 		return err
 	}
 
+	targetCommits := commits.ActiveCommitments
+	if forNextRound {
+		targetCommits = commits.QueuedCommitments
+	}
+
 	keyShare := &KeyShare{
 		Share: share,
 		Index: shareIndex,
 	}
 
-	if forNextRound {
-		v.PendingShare = keyShare
-		v.PendingShareExpiryBlock = expiry
-	} else {
-		v.CurrentShare = keyShare
-		v.CurrentShareExpiryBlock = expiry
+	valid, err := verifyShare(keyShare, targetCommits)
+	if err != nil {
+		return err
 	}
 
-	targetCommits := commits.ActiveCommitments
-	if forNextRound {
-		targetCommits = commits.QueuedCommitments
+	v.Policy.RecordShareVerification(valid)
+	if !valid {
+		return errors.New("got invalid share on chain")
 	}
 
-	valid, err := v.VerifyShare(targetCommits, forNextRound)
+	keyShare.Commitment, err = parseCommitment(suite, targetCommits.Commitments[shareIndex-1])
 	if err != nil {
 		return err
 	}
 
-	if !valid {
-		return errors.New("got invalid share on chain")
+	v.setShare(forNextRound, keyShare, expiry, commits)
+
+	return nil
+}
+
+// TriggerShareRefresh re-fetches & verifies the shares from chain in the background,
+// only one refresh runs at a time
+func (v *ValidatorClients) TriggerShareRefresh() {
+	if !v.shareRefreshing.CompareAndSwap(false, true) {
+		return
 	}
 
-	v.Commitments = commits
+	go func() {
+		defer v.shareRefreshing.Store(false)
 
-	return nil
+		v.shareRefreshMu.Lock()
+		defer v.shareRefreshMu.Unlock()
+
+		log.Println("Refreshing shares from FairyRing")
+		if err := v.updateKeyShareFromChain(false); err != nil {
+			log.Printf("Error refreshing current share: %s\n", err.Error())
+			return
+		}
+		current, _ := v.GetCurrentShare()
+		log.Printf("Successfully refreshed current share | Index: %d\n", current.Index)
+		v.Precomputer.Invalidate()
+
+		if pending, _ := v.GetPendingShare(); pending == nil {
+			return
+		}
+		if err := v.updateKeyShareFromChain(true); err != nil {
+			log.Printf("Error refreshing pending share: %s\n", err.Error())
+		}
+	}()
 }
 
+// DeriveKeyShare derives the keyshare for id from the current share, the derived keyshare is verified
+// against the commitment of the current share before returning, so an invalid keyshare is never submitted
 func (v *ValidatorClients) DeriveKeyShare(id []byte) (string, uint64, error) {
-	s := bls.NewBLS12381Suite()
-	extractedKey := distIBE.Extract(s, v.CurrentShare.Share.Value, uint32(v.CurrentShare.Index), id)
+	share, _ := v.GetCurrentShare()
+	return v.DeriveKeyShareFrom(share, id)
+}
+
+// DeriveKeyShareFrom derives & verifies the keyshare for id from share, the result of the verification is recorded for the policies
+func (v *ValidatorClients) DeriveKeyShareFrom(share *KeyShare, id []byte) (string, uint64, error) {
+	keyShare, index, err := deriveKeyShare(share, id)
+	if err == nil || errors.Is(err, ErrInvalidDerivedKeyShare) {
+		v.Policy.RecordShareVerification(err == nil)
+	}
+	return keyShare, index, err
+}
+
+func deriveKeyShare(share *KeyShare, id []byte) (string, uint64, error) {
+	if share == nil {
+		return "", 0, errors.New("active share not found")
+	}
+
+	extractedKey := distIBE.Extract(suite, share.Share.Value, uint32(share.Index), id)
+
+	if share.Commitment == nil {
+		return "", 0, errors.Wrap(ErrInvalidDerivedKeyShare, "commitment of the share not found")
+	}
+
+	valid, err := verifyExtractedKey(suite, share.Commitment, share.Index, extractedKey, id)
+	if err != nil {
+		return "", 0, err
+	}
+	if !valid {
+		return "", 0, errors.Wrapf(ErrInvalidDerivedKeyShare, "derived keyshare for id '%s' does not match commitment", string(id))
+	}
+
 	extractedKeyBinary, err := extractedKey.SK.MarshalBinary()
 	if err != nil {
 		return "", 0, err
 	}
 	extractedKeyHex := hex.EncodeToString(extractedKeyBinary)
-	return extractedKeyHex, v.CurrentShare.Index, nil
+	return extractedKeyHex, share.Index, nil
 }
 
 func (v *ValidatorClients) VerifyShare(commitments *types.Commitments, verifyPendingShare bool) (bool, error) {
-	s := bls.NewBLS12381Suite()
-
-	if len(commitments.Commitments) == 0 {
-		return false, errors.New("Commitment provided is empty")
-	}
-
-	targetShare := v.CurrentShare
+	targetShare, _ := v.GetCurrentShare()
 
 	if targetShare == nil {
 		return false, errors.New("active share not found")
 	}
 
 	if verifyPendingShare {
-		if v.PendingShare == nil {
+		pending, _ := v.GetPendingShare()
+		if pending == nil {
 			return false, errors.New("verify pending share but pending share not found")
 		}
-		targetShare = v.PendingShare
+		targetShare = pending
 	}
 
-	targetCommitments := commitments.Commitments
+	return verifyShare(targetShare, commitments)
+}
 
-	extracted := distIBE.Extract(s, targetShare.Share.Value, uint32(targetShare.Index), []byte("verifying"))
+// verifyShare checks the share against the commitment of its index
+func verifyShare(share *KeyShare, commitments *types.Commitments) (bool, error) {
+	s := suite
 
-	newByteCommitment, err := hex.DecodeString(targetCommitments[targetShare.Index-1])
+	if len(commitments.Commitments) == 0 {
+		return false, errors.New("Commitment provided is empty")
+	}
+
+	if share.Index == 0 || share.Index > uint64(len(commitments.Commitments)) {
+		return false, errors.Errorf("commitment for share index %d not found", share.Index)
+	}
+
+	newCommitmentPoint, err := parseCommitment(s, commitments.Commitments[share.Index-1])
 	if err != nil {
 		return false, err
 	}
 
+	extracted := distIBE.Extract(s, share.Share.Value, uint32(share.Index), []byte("verifying"))
+
+	return verifyExtractedKey(s, newCommitmentPoint, share.Index, extracted, []byte("verifying"))
+}
+
+func parseCommitment(s pairing.Suite, commitmentHex string) (kyber.Point, error) {
+	newByteCommitment, err := hex.DecodeString(commitmentHex)
+	if err != nil {
+		return nil, err
+	}
+
 	newCommitmentPoint := s.G1().Point()
 	err = newCommitmentPoint.UnmarshalBinary(newByteCommitment)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	return newCommitmentPoint, nil
+}
 
+// verifyExtractedKey checks e(commitment, H(id)) == e(G1, extracted key) for the share at index
+func verifyExtractedKey(s pairing.Suite, commitment kyber.Point, index uint64, extracted distIBE.ExtractedKey, id []byte) (bool, error) {
+	// The pairing normalizes the point in place, the commitment is cloned since the share is derived from concurrently
 	newCommitment := distIBE.Commitment{
-		SP:    newCommitmentPoint,
-		Index: uint32(targetShare.Index),
+		SP:    commitment.Clone(),
+		Index: uint32(index),
 	}
 
 	hG2, ok := s.G2().Point().(kyber.HashablePoint)
@@ -195,7 +454,7 @@ func (v *ValidatorClients) VerifyShare(commitments *types.Commitments, verifyPen
 		return false, errors.New("unable to create hashable G2 point")
 	}
 
-	Qid := hG2.Hash([]byte("verifying"))
+	Qid := hG2.Hash(id)
 
 	return distIBE.VerifyShare(s, newCommitment, extracted, Qid), nil
 }
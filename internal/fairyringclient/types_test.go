@@ -0,0 +1,162 @@
+package fairyringclient
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"fairyringclient/config"
+	"fairyringclient/pkg/cosmosClient"
+	"fairyringclient/pkg/cosmosClient/fakechain"
+
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+// fakeChainValidator is a client of the first validator of the rounds created by newRound
+type fakeChainValidator struct {
+	*ValidatorClients
+	chain      *fakechain.FakeChain
+	validators []fakechain.Validator
+}
+
+func newFakeChainValidator(t *testing.T) *fakeChainValidator {
+	t.Helper()
+	cosmostypes.GetConfig().SetBech32PrefixForAccount("fairy", "fairypub")
+
+	validators := make([]fakechain.Validator, 4)
+	for i := range validators {
+		validators[i] = fakechain.NewValidator()
+	}
+
+	chain := fakechain.New()
+	t.Cleanup(chain.Close)
+	chain.AddAccount(validators[0].Address, 0, 0)
+
+	client, err := cosmosClient.NewCosmosClient(fakechain.Endpoint, validators[0].PrivateKeyHex, "fairyring-test", chain.DialOption())
+	if err != nil {
+		t.Fatalf("error creating cosmos client: %s", err.Error())
+	}
+
+	return &fakeChainValidator{
+		ValidatorClients: NewValidatorClients(config.DefaultConfig(false), client, client, nil),
+		chain:            chain,
+		validators:       validators,
+	}
+}
+
+func (f *fakeChainValidator) newRound(t *testing.T) *fakechain.Round {
+	t.Helper()
+
+	round, err := fakechain.NewRound(f.validators, 3)
+	if err != nil {
+		t.Fatalf("error creating round: %s", err.Error())
+	}
+	return round
+}
+
+func TestUpdateKeyShareFromChain(t *testing.T) {
+	f := newFakeChainValidator(t)
+	first := f.newRound(t)
+	f.chain.SetRound(first, 100, false)
+
+	if err := f.UpdateKeyShareFromChain(false); err != nil {
+		t.Fatalf("error fetching share: %s", err.Error())
+	}
+	current, expiry := f.GetCurrentShare()
+	if current == nil || current.Commitment == nil {
+		t.Fatal("expected the current share to be set with its commitment")
+	}
+	if expiry != 100 || !current.Share.Value.Equal(first.Shares[current.Index-1].Value) {
+		t.Fatalf("expected the share on chain expiring at 100, got expiry: %d", expiry)
+	}
+	if _, _, err := f.DeriveKeyShare([]byte("10")); err != nil {
+		t.Fatalf("error deriving keyshare from the fetched share: %s", err.Error())
+	}
+
+	// The share on chain does not match the commitments, the verified share is kept
+	second := f.newRound(t)
+	f.chain.SetActivePubkey(second.PubKey, 200, second.EncryptedKeyshares, first.Commitments)
+	if err := f.UpdateKeyShareFromChain(false); err == nil {
+		t.Fatal("expected error for the share not matching the commitments")
+	}
+	if kept, keptExpiry := f.GetCurrentShare(); kept != current || keptExpiry != 100 {
+		t.Fatal("expected the current share to be kept when the share on chain is invalid")
+	}
+	if f.Commitments.ActiveCommitments.Commitments[0] != first.Commitments[0] {
+		t.Fatal("expected the commitments to be kept when the share on chain is invalid")
+	}
+
+	// The pending share is verified against the queued commitments
+	f.chain.SetRound(second, 200, true)
+	if err := f.UpdateKeyShareFromChain(true); err != nil {
+		t.Fatalf("error fetching pending share: %s", err.Error())
+	}
+	pending, pendingExpiry := f.GetPendingShare()
+	if pending == nil || pending.Commitment == nil || pendingExpiry != 200 {
+		t.Fatal("expected the pending share to be set with its commitment")
+	}
+
+	f.ActivatePendingShare()
+	if activated, _ := f.GetCurrentShare(); activated != pending {
+		t.Fatal("expected the pending share to be activated")
+	}
+	if stillPending, _ := f.GetPendingShare(); stillPending != nil {
+		t.Fatal("expected no pending share once activated")
+	}
+}
+
+func TestDeriveKeyShareErrorRefreshesShares(t *testing.T) {
+	f := newFakeChainValidator(t)
+	f.Broadcaster = &mockBroadcaster{address: f.Broadcaster.GetAddress()}
+	f.chain.SetRound(f.newRound(t), 100, false)
+
+	// The active share is not fetched yet, the request is skipped instead of exiting
+	if outcome := f.handleStartSubmitGeneralKeyShareEvent("gov-1"); outcome != requestOutcomeFailed {
+		t.Fatalf("expected outcome %s without active share, got: %s", requestOutcomeFailed, outcome)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if current, _ := f.GetCurrentShare(); current != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the shares to be refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if outcome := f.handleStartSubmitGeneralKeyShareEvent("gov-1"); outcome != requestOutcomeSubmitted {
+		t.Fatalf("expected outcome %s once the share is refreshed, got: %s", requestOutcomeSubmitted, outcome)
+	}
+}
+
+func TestSharesSwappedWhileDeriving(t *testing.T) {
+	setup := newDealerSetup(t)
+	next := newDealerSetup(t)
+
+	v := &ValidatorClients{}
+	v.setShare(false, setup.keyShare(t, 1), 100, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for height := 0; height < 10; height++ {
+				if _, _, err := v.DeriveKeyShare([]byte(strconv.Itoa(i*10 + height))); err != nil {
+					t.Errorf("error deriving keyshare: %s", err.Error())
+					return
+				}
+			}
+		}(i)
+	}
+
+	for i := 0; i < 10; i++ {
+		v.setShare(true, next.keyShare(t, 1), 200, nil)
+		v.ActivatePendingShare()
+		v.setShare(false, setup.keyShare(t, 1), 100, nil)
+	}
+	wg.Wait()
+}
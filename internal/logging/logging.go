@@ -0,0 +1,69 @@
+// Package logging filters the routine logs of the client by a level that can be changed while running.
+// The errors & warnings are logged with the standard logger, so they are logged at any level.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+)
+
+type Level int32
+
+const (
+	// LevelDebug also logs the details of each derivation & submission, such as the derived key shares
+	LevelDebug Level = -1
+	// LevelInfo logs the handling of each block & request, it is the default level
+	LevelInfo Level = 0
+	// LevelWarn only logs the errors & warnings
+	LevelWarn Level = 1
+)
+
+var levelNames = map[string]Level{
+	"debug": LevelDebug,
+	"info":  LevelInfo,
+	"warn":  LevelWarn,
+}
+
+var level atomic.Int32
+
+// ParseLevel returns the level of the name: debug, info or warn. Empty is the default info level
+func ParseLevel(name string) (Level, error) {
+	if len(name) == 0 {
+		return LevelInfo, nil
+	}
+	l, found := levelNames[name]
+	if !found {
+		return 0, fmt.Errorf("invalid log level %q, must be debug, info or warn", name)
+	}
+	return l, nil
+}
+
+// SetLevel sets the level of the logs, it can be changed while logging
+func SetLevel(name string) error {
+	l, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Store(int32(l))
+	return nil
+}
+
+// Enabled reports if the logs of level l are logged at the current level
+func Enabled(l Level) bool {
+	return Level(level.Load()) <= l
+}
+
+// Debugf logs with the standard logger at the debug level
+func Debugf(format string, v ...interface{}) {
+	if Enabled(LevelDebug) {
+		_ = log.Output(2, fmt.Sprintf(format, v...))
+	}
+}
+
+// Infof logs with the standard logger at the info level
+func Infof(format string, v ...interface{}) {
+	if Enabled(LevelInfo) {
+		_ = log.Output(2, fmt.Sprintf(format, v...))
+	}
+}
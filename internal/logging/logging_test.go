@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		_ = SetLevel("")
+	}()
+
+	if err := SetLevel("verbose"); err == nil {
+		t.Fatal("expected error setting unknown level")
+	}
+
+	for name, expected := range map[string][]string{
+		"debug": {"debug", "info"},
+		"info":  {"info"},
+		"":      {"info"},
+		"warn":  {},
+	} {
+		out.Reset()
+		if err := SetLevel(name); err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+		Debugf("debug")
+		Infof("info")
+
+		logged := strings.Fields(strings.TrimSpace(out.String()))
+		var messages []string
+		for _, field := range logged {
+			if field == "debug" || field == "info" {
+				messages = append(messages, field)
+			}
+		}
+		if strings.Join(messages, ",") != strings.Join(expected, ",") {
+			t.Fatalf("expected %v logged at level %q, got: %v", expected, name, messages)
+		}
+	}
+}
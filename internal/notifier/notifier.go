@@ -0,0 +1,254 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fairyringclient/config"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventInvalidShare       EventType = "invalid_share"
+	EventClientPaused       EventType = "client_paused"
+	EventClientUnpaused     EventType = "client_unpaused"
+	EventShareRotation      EventType = "share_rotation"
+	EventShareMissing       EventType = "share_missing"
+	EventLowBalance         EventType = "low_balance"
+	EventSubmissionFailures EventType = "submission_failures"
+	// EventPolicyTriggered is sent by the policies with the notify action, it is always enabled as the policy opts in to it
+	EventPolicyTriggered EventType = "policy_triggered"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatSlack   = "slack"
+	FormatDiscord = "discord"
+)
+
+const (
+	queueSize    = 100
+	sendTimeout  = 10 * time.Second
+	retryBackoff = time.Second
+)
+
+type Event struct {
+	Type    EventType         `json:"event"`
+	Message string            `json:"message"`
+	Height  uint64            `json:"height,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	Time    time.Time         `json:"timestamp"`
+}
+
+// Notifier sends operational events to a webhook, events are sent in the background
+// so a slow or unavailable webhook never blocks submitting keyshares
+type Notifier struct {
+	cfg          config.Notifier
+	httpClient   *http.Client
+	retryBackoff time.Duration
+	queue        chan Event
+	done         chan struct{}
+	mu           sync.Mutex
+	closed       bool
+	lastSent     map[rateLimitKey]time.Time
+}
+
+// rateLimitKey rate limits each event type per address, so the validators run in the same process are not limited by each other
+type rateLimitKey struct {
+	eventType EventType
+	address   string
+}
+
+// New returns nil if no webhook is configured, calling Notify on a nil Notifier is a no-op
+func New(cfg config.Notifier) (*Notifier, error) {
+	if len(cfg.WebhookURL) == 0 {
+		return nil, nil
+	}
+
+	switch cfg.Format {
+	case "":
+		cfg.Format = FormatJSON
+	case FormatJSON, FormatSlack, FormatDiscord:
+	default:
+		return nil, fmt.Errorf("unknown notifier format: %s, expected one of %s, %s, %s", cfg.Format, FormatJSON, FormatSlack, FormatDiscord)
+	}
+
+	n := &Notifier{
+		cfg:          cfg,
+		httpClient:   &http.Client{Timeout: sendTimeout},
+		retryBackoff: retryBackoff,
+		queue:        make(chan Event, queueSize),
+		done:         make(chan struct{}),
+		lastSent:     make(map[rateLimitKey]time.Time),
+	}
+
+	go n.run()
+
+	return n, nil
+}
+
+func (n *Notifier) IsEnabled(t EventType) bool {
+	if n == nil {
+		return false
+	}
+	switch t {
+	case EventInvalidShare:
+		return n.cfg.InvalidShare
+	case EventClientPaused, EventClientUnpaused:
+		return n.cfg.ClientPaused
+	case EventShareRotation:
+		return n.cfg.ShareRotation
+	case EventShareMissing:
+		return n.cfg.ShareMissing
+	case EventLowBalance:
+		return n.cfg.LowBalance
+	case EventSubmissionFailures:
+		return n.cfg.SubmissionFailures
+	case EventPolicyTriggered:
+		return true
+	}
+	return false
+}
+
+// SubmissionFailuresToAlert returns the number of failed submissions in a row before notifying
+func (n *Notifier) SubmissionFailuresToAlert() uint64 {
+	if n == nil || n.cfg.SubmissionFailuresToAlert == 0 {
+		return config.DefaultSubmissionFailuresToAlert
+	}
+	return n.cfg.SubmissionFailuresToAlert
+}
+
+// Notify queues the event if it is enabled and not rate limited, it never blocks
+func (n *Notifier) Notify(e Event) {
+	if !n.IsEnabled(e.Type) {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	key := rateLimitKey{eventType: e.Type, address: e.Fields["address"]}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		log.Printf("Notifier is closed, dropping %s notification\n", e.Type)
+		return
+	}
+	last, found := n.lastSent[key]
+	if found && e.Time.Sub(last) < time.Duration(n.cfg.RateLimit)*time.Second {
+		return
+	}
+	n.lastSent[key] = e.Time
+
+	select {
+	case n.queue <- e:
+	default:
+		log.Printf("Notifier queue is full, dropping %s notification\n", e.Type)
+	}
+}
+
+// Send sends the event right away without rate limit, for the events that must be delivered before the client exits
+func (n *Notifier) Send(e Event) error {
+	if !n.IsEnabled(e.Type) {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	return n.send(e)
+}
+
+// Close stops queueing events and waits for the queued events to be sent, it can be called more than once.
+// Calling Close on a nil Notifier is a no-op
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	<-n.done
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+	for e := range n.queue {
+		if err := n.send(e); err != nil {
+			log.Printf("Error sending %s notification: %s\n", e.Type, err.Error())
+		}
+	}
+}
+
+func (n *Notifier) send(e Event) error {
+	payload, err := n.buildPayload(e)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.retryBackoff
+	for attempt := uint64(0); ; attempt++ {
+		err = n.post(payload)
+		if err == nil || attempt >= n.cfg.MaxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff = backoff * 2
+	}
+}
+
+func (n *Notifier) post(payload []byte) error {
+	resp, err := n.httpClient.Post(n.cfg.WebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status: %s", resp.Status)
+	}
+	return nil
+}
+
+func (n *Notifier) buildPayload(e Event) ([]byte, error) {
+	switch n.cfg.Format {
+	case FormatSlack:
+		return json.Marshal(map[string]string{"text": e.Text()})
+	case FormatDiscord:
+		return json.Marshal(map[string]string{"content": e.Text()})
+	default:
+		return json.Marshal(e)
+	}
+}
+
+// Text formats the event as a human-readable message for chat webhooks
+func (e Event) Text() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[fairyringclient] %s: %s", e.Type, e.Message))
+	if e.Height != 0 {
+		sb.WriteString(fmt.Sprintf(" | height: %d", e.Height))
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf(" | %s: %s", k, e.Fields[k]))
+	}
+	return sb.String()
+}
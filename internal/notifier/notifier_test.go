@@ -0,0 +1,242 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"fairyringclient/config"
+)
+
+// webhookServer records the bodies of the requests, the first failures requests are answered with 500
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	bodies   [][]byte
+	failures int
+}
+
+func newWebhookServer(t *testing.T, failures int) *webhookServer {
+	t.Helper()
+
+	s := &webhookServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.bodies = append(s.bodies, body)
+		if len(s.bodies) <= s.failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) requests() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte{}, s.bodies...)
+}
+
+func newTestNotifier(t *testing.T, cfg config.Notifier) *Notifier {
+	t.Helper()
+
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatalf("error creating notifier: %s", err.Error())
+	}
+	n.retryBackoff = time.Millisecond
+	return n
+}
+
+func TestNew(t *testing.T) {
+	n, err := New(config.Notifier{})
+	if err != nil || n != nil {
+		t.Fatalf("expected nil notifier without webhook, got: %v, %v", n, err)
+	}
+	n.Notify(Event{Type: EventInvalidShare})
+	if n.IsEnabled(EventPolicyTriggered) {
+		t.Fatal("expected nil notifier to have no event enabled")
+	}
+
+	if _, err = New(config.Notifier{WebhookURL: "http://localhost", Format: "xml"}); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestPayloadFormats(t *testing.T) {
+	event := Event{
+		Type:    EventInvalidShare,
+		Message: "Submitted keyshare is invalid",
+		Height:  10,
+		Fields:  map[string]string{"txHash": "ABC", "address": "fairy1validator"},
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	text := "[fairyringclient] invalid_share: Submitted keyshare is invalid | height: 10 | address: fairy1validator | txHash: ABC"
+
+	tests := []struct {
+		format   string
+		expected map[string]interface{}
+	}{
+		{format: FormatSlack, expected: map[string]interface{}{"text": text}},
+		{format: FormatDiscord, expected: map[string]interface{}{"content": text}},
+		{format: FormatJSON, expected: map[string]interface{}{
+			"event":     "invalid_share",
+			"message":   "Submitted keyshare is invalid",
+			"height":    float64(10),
+			"fields":    map[string]interface{}{"txHash": "ABC", "address": "fairy1validator"},
+			"timestamp": "2024-01-02T03:04:05Z",
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			server := newWebhookServer(t, 0)
+			n := newTestNotifier(t, config.Notifier{WebhookURL: server.URL, Format: tc.format, InvalidShare: true})
+
+			if err := n.Send(event); err != nil {
+				t.Fatalf("error sending: %s", err.Error())
+			}
+
+			requests := server.requests()
+			if len(requests) != 1 {
+				t.Fatalf("expected 1 request, got: %d", len(requests))
+			}
+			var payload map[string]interface{}
+			if err := json.Unmarshal(requests[0], &payload); err != nil {
+				t.Fatalf("invalid payload: %s", err.Error())
+			}
+			expected, _ := json.Marshal(tc.expected)
+			got, _ := json.Marshal(payload)
+			if string(expected) != string(got) {
+				t.Fatalf("expected payload %s, got: %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	server := newWebhookServer(t, 2)
+	n := newTestNotifier(t, config.Notifier{WebhookURL: server.URL, MaxRetries: 2, InvalidShare: true})
+	if err := n.Send(Event{Type: EventInvalidShare}); err != nil {
+		t.Fatalf("expected success on the last retry, got: %s", err.Error())
+	}
+	if got := len(server.requests()); got != 3 {
+		t.Fatalf("expected 3 attempts, got: %d", got)
+	}
+
+	server = newWebhookServer(t, 2)
+	n = newTestNotifier(t, config.Notifier{WebhookURL: server.URL, MaxRetries: 1, InvalidShare: true})
+	if err := n.Send(Event{Type: EventInvalidShare}); err == nil {
+		t.Fatal("expected error once the retries are exhausted")
+	}
+	if got := len(server.requests()); got != 2 {
+		t.Fatalf("expected 2 attempts, got: %d", got)
+	}
+}
+
+// newQueueNotifier returns a notifier without the sending goroutine, so the queued events can be inspected
+func newQueueNotifier(cfg config.Notifier, size int) *Notifier {
+	return &Notifier{
+		cfg:      cfg,
+		queue:    make(chan Event, size),
+		lastSent: make(map[rateLimitKey]time.Time),
+	}
+}
+
+func TestNotifyRateLimit(t *testing.T) {
+	n := newQueueNotifier(config.Notifier{RateLimit: 60, InvalidShare: true, LowBalance: true}, 10)
+	start := time.Now()
+
+	event := func(eventType EventType, address string, after time.Duration) Event {
+		return Event{Type: eventType, Fields: map[string]string{"address": address}, Time: start.Add(after)}
+	}
+
+	n.Notify(event(EventInvalidShare, "fairy1first", 0))
+	n.Notify(event(EventInvalidShare, "fairy1first", 30*time.Second))
+	n.Notify(event(EventInvalidShare, "fairy1second", 30*time.Second))
+	n.Notify(event(EventLowBalance, "fairy1first", 30*time.Second))
+	n.Notify(event(EventInvalidShare, "fairy1first", 61*time.Second))
+	n.Notify(event(EventShareRotation, "fairy1first", 0))
+
+	var got []string
+	for len(n.queue) > 0 {
+		e := <-n.queue
+		got = append(got, string(e.Type)+"@"+e.Fields["address"])
+	}
+	expected := []string{
+		"invalid_share@fairy1first",
+		"invalid_share@fairy1second",
+		"low_balance@fairy1first",
+		"invalid_share@fairy1first",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got: %v", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got: %v", expected, got)
+		}
+	}
+}
+
+func TestNotifyQueueOverflow(t *testing.T) {
+	n := newQueueNotifier(config.Notifier{InvalidShare: true}, 1)
+
+	n.Notify(Event{Type: EventInvalidShare, Fields: map[string]string{"address": "fairy1first"}})
+	n.Notify(Event{Type: EventInvalidShare, Fields: map[string]string{"address": "fairy1second"}})
+
+	if len(n.queue) != 1 {
+		t.Fatalf("expected the event over the queue size to be dropped, got: %d queued", len(n.queue))
+	}
+	if e := <-n.queue; e.Fields["address"] != "fairy1first" {
+		t.Fatalf("expected the first event to be kept, got: %v", e)
+	}
+}
+
+func TestNotifySendsInBackground(t *testing.T) {
+	server := newWebhookServer(t, 0)
+	n := newTestNotifier(t, config.Notifier{WebhookURL: server.URL, ShareMissing: true})
+
+	n.Notify(Event{Type: EventShareMissing, Message: "missing"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(server.requests()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the notification")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloseDrainsQueue(t *testing.T) {
+	server := newWebhookServer(t, 0)
+	n := newTestNotifier(t, config.Notifier{WebhookURL: server.URL, InvalidShare: true})
+
+	for _, address := range []string{"fairy1first", "fairy1second", "fairy1third"} {
+		n.Notify(Event{Type: EventInvalidShare, Fields: map[string]string{"address": address}})
+	}
+	n.Close()
+
+	if got := len(server.requests()); got != 3 {
+		t.Fatalf("expected the queued events sent before Close returns, got: %d", got)
+	}
+
+	// Events after Close are dropped, and closing again or a nil notifier does not block
+	n.Notify(Event{Type: EventInvalidShare, Fields: map[string]string{"address": "fairy1fourth"}})
+	n.Close()
+	var disabled *Notifier
+	disabled.Close()
+
+	if got := len(server.requests()); got != 3 {
+		t.Fatalf("expected no event sent after Close, got: %d", got)
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/skip-mev/block-sdk/v2/testutils"
 	"log"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"cosmossdk.io/math"
@@ -21,6 +23,7 @@ import (
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
 	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
 	"github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
@@ -33,6 +36,9 @@ import (
 const (
 	defaultGasAdjustment = 3
 	defaultGasLimit      = 300000
+
+	redialTimeout    = 10 * time.Second
+	redialCloseDelay = 30 * time.Second
 )
 
 type QueuedTx struct {
@@ -42,40 +48,65 @@ type QueuedTx struct {
 	AdjustGas          bool
 }
 
-type CosmosClient struct {
+// nodeConn is the gRPC connection to the node with the clients of the modules queried over it,
+// it is replaced as a whole when the client is redialed
+type nodeConn struct {
+	grpcConn            *grpc.ClientConn
 	authClient          authtypes.QueryClient
 	txClient            tx.ServiceClient
-	grpcConn            *grpc.ClientConn
 	bankQueryClient     banktypes.QueryClient
 	pepQueryClient      peptypes.QueryClient
 	keyshareQueryClient keysharetypes.QueryClient
-	privateKey          secp256k1.PrivKey
-	dcrdPrivKey         dcrdSecp256k1.PrivateKey
-	publicKey           cryptotypes.PubKey
-	account             authtypes.BaseAccount
-	accAddress          cosmostypes.AccAddress
-	chainID             string
-	txQueue             chan QueuedTx
 }
 
+func dialNode(endpoint string, dialOpts ...grpc.DialOption) (*nodeConn, error) {
+	grpcConn, err := grpc.Dial(
+		endpoint,
+		append([]grpc.DialOption{grpc.WithInsecure()}, dialOpts...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodeConn{
+		grpcConn:            grpcConn,
+		authClient:          authtypes.NewQueryClient(grpcConn),
+		txClient:            tx.NewServiceClient(grpcConn),
+		bankQueryClient:     banktypes.NewQueryClient(grpcConn),
+		pepQueryClient:      peptypes.NewQueryClient(grpcConn),
+		keyshareQueryClient: keysharetypes.NewQueryClient(grpcConn),
+	}, nil
+}
+
+type CosmosClient struct {
+	node        atomic.Pointer[nodeConn]
+	privateKey  secp256k1.PrivKey
+	dcrdPrivKey dcrdSecp256k1.PrivateKey
+	publicKey   cryptotypes.PubKey
+	address     string
+	accAddress  cosmostypes.AccAddress
+	// accountMu guards account & sequenceSynced, it serializes signing & broadcasting so each tx gets its own sequence
+	accountMu      sync.Mutex
+	account        authtypes.BaseAccount
+	sequenceSynced bool
+	chainID        string
+	gasPrice       atomic.Pointer[cosmostypes.DecCoin]
+	txQueue        chan QueuedTx
+}
+
+// NewCosmosClient connects to the gRPC endpoint of the node, extra dial options
+// can be provided to use another transport, such as the in-process fake chain in tests
 func NewCosmosClient(
 	endpoint string,
 	privateKeyHex string,
 	chainID string,
+	dialOpts ...grpc.DialOption,
 ) (*CosmosClient, error) {
-	grpcConn, err := grpc.Dial(
-		endpoint,
-		grpc.WithInsecure(),
-	)
+	node, err := dialNode(endpoint, dialOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	authClient := authtypes.NewQueryClient(grpcConn)
-	bankClient := banktypes.NewQueryClient(grpcConn)
-	pepeClient := peptypes.NewQueryClient(grpcConn)
-	keyshareClient := keysharetypes.NewQueryClient(grpcConn)
-
 	keyBytes, err := hex.DecodeString(privateKeyHex)
 	if err != nil {
 		return nil, err
@@ -87,17 +118,14 @@ func NewCosmosClient(
 
 	dcrdPrivKey, _ := dcrdSecp256k1.PrivKeyFromBytes(keyBytes)
 
-	cfg := cosmostypes.GetConfig()
-	cfg.SetBech32PrefixForAccount("fairy", "fairypub")
-	cfg.SetBech32PrefixForValidator("fairyvaloper", "fairyvaloperpub")
-	cfg.SetBech32PrefixForConsensusNode("fairyvalcons", "fairyrvalconspub")
+	setBech32Prefixes()
 
 	accAddr := cosmostypes.AccAddress(address)
 	addr := accAddr.String()
 
 	var baseAccount authtypes.BaseAccount
 
-	resp, err := authClient.Account(
+	resp, err := node.authClient.Account(
 		context.Background(),
 		&authtypes.QueryAccountRequest{Address: addr},
 	)
@@ -112,25 +140,118 @@ func NewCosmosClient(
 		return nil, err
 	}
 
-	return &CosmosClient{
-		bankQueryClient:     bankClient,
-		authClient:          authClient,
-		txClient:            tx.NewServiceClient(grpcConn),
-		pepQueryClient:      pepeClient,
-		keyshareQueryClient: keyshareClient,
-		grpcConn:            grpcConn,
-		privateKey:          privateKey,
-		dcrdPrivKey:         *dcrdPrivKey,
-		account:             baseAccount,
-		accAddress:          accAddr,
-		publicKey:           pubKey,
-		chainID:             chainID,
-		txQueue:             make(chan QueuedTx, 1),
-	}, nil
+	c := &CosmosClient{
+		privateKey:     privateKey,
+		dcrdPrivKey:    *dcrdPrivKey,
+		address:        baseAccount.Address,
+		account:        baseAccount,
+		sequenceSynced: true,
+		accAddress:     accAddr,
+		publicKey:      pubKey,
+		chainID:        chainID,
+		txQueue:        make(chan QueuedTx, 1),
+	}
+	c.node.Store(node)
+	return c, nil
+}
+
+// Redial connects the client to the gRPC endpoint of another node of the chain, the account is queried on the node
+// before switching, so the connection in use is kept if the node is unreachable. The requests in progress
+// on the previous connection are given redialCloseDelay to complete before it is closed
+func (c *CosmosClient) Redial(endpoint string, dialOpts ...grpc.DialOption) error {
+	node, err := dialNode(endpoint, dialOpts...)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redialTimeout)
+	defer cancel()
+	if _, err = node.authClient.Account(ctx, &authtypes.QueryAccountRequest{Address: c.address}); err != nil {
+		_ = node.grpcConn.Close()
+		return errors.Wrapf(err, "error querying account on %s", endpoint)
+	}
+
+	previous := c.node.Swap(node)
+
+	// The sequence is synced from the new node before the next tx, in case the nodes are not at the same height
+	c.accountMu.Lock()
+	c.sequenceSynced = false
+	c.accountMu.Unlock()
+
+	time.AfterFunc(redialCloseDelay, func() {
+		_ = previous.grpcConn.Close()
+	})
+	return nil
+}
+
+// AddressFromPrivateKey returns the account address of the private key in hex
+func AddressFromPrivateKey(privateKeyHex string) (string, error) {
+	keyBytes, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		return "", err
+	}
+
+	// Encoded directly instead of AccAddress.String, which caches the address with the prefix it is first encoded with
+	privateKey := secp256k1.PrivKey{Key: keyBytes}
+	return bech32.ConvertAndEncode(Bech32Prefix(), privateKey.PubKey().Address())
+}
+
+// SetGasPrice sets the gas price the fee of the txs is paid with, in the format of 0.1ufairy.
+// Txs are submitted without fee if the gas price is empty, it can be changed while txs are being submitted
+func (c *CosmosClient) SetGasPrice(gasPrice string) error {
+	if len(gasPrice) == 0 {
+		c.gasPrice.Store(nil)
+		return nil
+	}
+
+	price, err := cosmostypes.ParseDecCoin(gasPrice)
+	if err != nil {
+		return fmt.Errorf("invalid gas price %s: %v", gasPrice, err)
+	}
+	c.gasPrice.Store(&price)
+	return nil
+}
+
+// DefaultBech32Prefix is the account address prefix of FairyRing
+const DefaultBech32Prefix = "fairy"
+
+var (
+	bech32PrefixMu sync.Mutex
+	bech32Prefix   = DefaultBech32Prefix
+)
+
+// SetBech32Prefix sets the account address prefix of the chain, such as fairy, on the global SDK config.
+// The validator & consensus prefixes are derived from it, fairyvaloper & fairyvalcons. Empty resets it to DefaultBech32Prefix.
+// It must be set before the first client is created, the SDK caches the addresses encoded
+func SetBech32Prefix(prefix string) {
+	bech32PrefixMu.Lock()
+	if len(prefix) == 0 {
+		prefix = DefaultBech32Prefix
+	}
+	bech32Prefix = prefix
+	bech32PrefixMu.Unlock()
+
+	setBech32Prefixes()
+}
+
+// Bech32Prefix returns the account address prefix in use
+func Bech32Prefix() string {
+	bech32PrefixMu.Lock()
+	defer bech32PrefixMu.Unlock()
+	return bech32Prefix
 }
 
+func setBech32Prefixes() {
+	prefix := Bech32Prefix()
+	cfg := cosmostypes.GetConfig()
+	cfg.SetBech32PrefixForAccount(prefix, prefix+"pub")
+	cfg.SetBech32PrefixForValidator(prefix+"valoper", prefix+"valoperpub")
+	cfg.SetBech32PrefixForConsensusNode(prefix+"valcons", prefix+"valconspub")
+}
+
+// updateAccSequence syncs the account & its sequence from chain, accountMu must be held
 func (c *CosmosClient) updateAccSequence() error {
-	out, err := c.authClient.Account(context.Background(),
+	out, err := c.node.Load().authClient.Account(context.Background(),
 		&authtypes.QueryAccountRequest{Address: c.accAddress.String()})
 	if err != nil {
 		return err
@@ -142,11 +263,51 @@ func (c *CosmosClient) updateAccSequence() error {
 	}
 
 	c.account = baseAccount
+	c.sequenceSynced = true
 	return nil
 }
 
+// signAndBroadcast signs the msg with the next sequence of the account and broadcasts it in sync mode.
+// The sequence is counted locally and synced from chain again after a tx is not accepted
+func (c *CosmosClient) signAndBroadcast(msg cosmostypes.Msg, adjustGas bool) (*cosmostypes.TxResponse, error) {
+	c.accountMu.Lock()
+	defer c.accountMu.Unlock()
+
+	if !c.sequenceSynced {
+		if err := c.updateAccSequence(); err != nil {
+			log.Printf("Error updating Account sequence: %v", err)
+			return nil, err
+		}
+	}
+
+	txBytes, err := c.signTxMsg(msg, adjustGas)
+	if err != nil {
+		// The gas is simulated with the sequence, so it may be the one out of date
+		c.sequenceSynced = false
+		return nil, errors.Wrap(err, "error signing tx")
+	}
+
+	resp, err := c.node.Load().txClient.BroadcastTx(
+		context.Background(),
+		&tx.BroadcastTxRequest{
+			TxBytes: txBytes,
+			Mode:    tx.BroadcastMode_BROADCAST_MODE_SYNC,
+		},
+	)
+	if err != nil || resp.TxResponse.Code != 0 {
+		c.sequenceSynced = false
+		if err != nil {
+			return nil, err
+		}
+		return resp.TxResponse, nil
+	}
+
+	c.account.Sequence++
+	return resp.TxResponse, nil
+}
+
 func (c *CosmosClient) IsAddrAuthorized(target string) bool {
-	resp, err := c.keyshareQueryClient.AuthorizedAddress(
+	resp, err := c.node.Load().keyshareQueryClient.AuthorizedAddress(
 		context.Background(),
 		&keysharetypes.QueryAuthorizedAddressRequest{
 			Target: target,
@@ -159,7 +320,7 @@ func (c *CosmosClient) IsAddrAuthorized(target string) bool {
 }
 
 func (c *CosmosClient) GetCommitments() (*keysharetypes.QueryCommitmentsResponse, error) {
-	resp, err := c.keyshareQueryClient.Commitments(
+	resp, err := c.node.Load().keyshareQueryClient.Commitments(
 		context.Background(),
 		&keysharetypes.QueryCommitmentsRequest{},
 	)
@@ -170,7 +331,7 @@ func (c *CosmosClient) GetCommitments() (*keysharetypes.QueryCommitmentsResponse
 }
 
 func (c *CosmosClient) GetActivePubKey() (*keysharetypes.QueryPubkeyResponse, error) {
-	resp, err := c.keyshareQueryClient.Pubkey(
+	resp, err := c.node.Load().keyshareQueryClient.Pubkey(
 		context.Background(),
 		&keysharetypes.QueryPubkeyRequest{},
 	)
@@ -180,6 +341,28 @@ func (c *CosmosClient) GetActivePubKey() (*keysharetypes.QueryPubkeyResponse, er
 	return resp, nil
 }
 
+func (c *CosmosClient) GetPepPubKey() (*peptypes.QueryPubkeyResponse, error) {
+	resp, err := c.node.Load().pepQueryClient.Pubkey(
+		context.Background(),
+		&peptypes.QueryPubkeyRequest{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *CosmosClient) GetDecryptionKey(height uint64) (*keysharetypes.DecryptionKey, error) {
+	resp, err := c.node.Load().keyshareQueryClient.DecryptionKey(
+		context.Background(),
+		&keysharetypes.QueryDecryptionKeyRequest{Height: height},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.DecryptionKey, nil
+}
+
 func (c *CosmosClient) GetKeyShare(getPendingShare bool) (*distIBE.Share, uint64, uint64, error) {
 	pubKey, err := c.GetActivePubKey()
 	if err != nil {
@@ -221,7 +404,7 @@ func (c *CosmosClient) GetKeyShare(getPendingShare bool) (*distIBE.Share, uint64
 }
 
 func (c *CosmosClient) GetLatestHeight() (uint64, error) {
-	resp, err := c.pepQueryClient.LatestHeight(
+	resp, err := c.node.Load().pepQueryClient.LatestHeight(
 		context.Background(),
 		&peptypes.QueryLatestHeightRequest{},
 	)
@@ -232,7 +415,7 @@ func (c *CosmosClient) GetLatestHeight() (uint64, error) {
 }
 
 func (c *CosmosClient) GetBalance(denom string) (*math.Int, error) {
-	resp, err := c.bankQueryClient.Balance(
+	resp, err := c.node.Load().bankQueryClient.Balance(
 		context.Background(),
 		&banktypes.QueryBalanceRequest{
 			Address: c.GetAddress(),
@@ -246,7 +429,7 @@ func (c *CosmosClient) GetBalance(denom string) (*math.Int, error) {
 }
 
 func (c *CosmosClient) GetAddress() string {
-	return c.account.Address
+	return c.address
 }
 
 func (c *CosmosClient) GetAccAddress() cosmostypes.AccAddress {
@@ -289,18 +472,7 @@ func (c *CosmosClient) HandleTxQueue() error {
 		}
 
 		go func(qTx QueuedTx) {
-			txBytes, err := c.signTxMsg(*qTx.Tx, qTx.AdjustGas)
-			if err != nil {
-				qTx.TxResultErrHandler(errors.New(fmt.Sprintf("Error signing tx: %s", err.Error())))
-				return
-			}
-			resp, err := c.txClient.BroadcastTx(
-				context.Background(),
-				&tx.BroadcastTxRequest{
-					TxBytes: txBytes,
-					Mode:    tx.BroadcastMode_BROADCAST_MODE_SYNC,
-				},
-			)
+			resp, err := c.signAndBroadcast(*qTx.Tx, qTx.AdjustGas)
 			if err != nil {
 				log.Printf("Error broadcasting tx in Tx queue handler: %v", err)
 				if qTx.TxResultErrHandler != nil {
@@ -308,11 +480,11 @@ func (c *CosmosClient) HandleTxQueue() error {
 				}
 				return
 			}
-			if resp.TxResponse.Code != 0 {
-				qTx.TxResultErrHandler(errors.New(fmt.Sprintf("Error broadcasting tx: %s", resp.TxResponse.RawLog)))
+			if resp.Code != 0 {
+				qTx.TxResultErrHandler(errors.New(fmt.Sprintf("Error broadcasting tx: %s", resp.RawLog)))
 				return
 			}
-			c.WaitForQueuedTx(qTx, resp.TxResponse.TxHash)
+			c.WaitForQueuedTx(qTx, resp.TxHash)
 		}(queuedTx)
 
 	}
@@ -331,28 +503,13 @@ func (c *CosmosClient) WaitForQueuedTx(q QueuedTx, txHash string) {
 }
 
 func (c *CosmosClient) BroadcastTx(msg cosmostypes.Msg, adjustGas bool) (*tx.GetTxResponse, error) {
-	if err := c.updateAccSequence(); err != nil {
-		return nil, err
-	}
-
-	txBytes, err := c.signTxMsg(msg, adjustGas)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.txClient.BroadcastTx(
-		context.Background(),
-		&tx.BroadcastTxRequest{
-			TxBytes: txBytes,
-			Mode:    tx.BroadcastMode_BROADCAST_MODE_SYNC,
-		},
-	)
-	if err != nil {
+	resp, err := c.signAndBroadcast(msg, adjustGas)
+	if err = c.handleBroadcastResult(resp, err); err != nil {
 		return nil, err
 	}
 
 	for {
-		getTxResp, err := c.txClient.GetTx(context.Background(), &tx.GetTxRequest{Hash: resp.TxResponse.TxHash})
+		getTxResp, err := c.node.Load().txClient.GetTx(context.Background(), &tx.GetTxRequest{Hash: resp.TxHash})
 		if err != nil {
 			if strings.Contains(err.Error(), "not found") {
 				time.Sleep(time.Second)
@@ -392,7 +549,7 @@ func (c *CosmosClient) parseShare(shareByte []byte, index int64) (*distIBE.Share
 
 func (c *CosmosClient) WaitForTx(hash string, rate time.Duration) (*tx.GetTxResponse, error) {
 	for {
-		resp, err := c.txClient.GetTx(context.Background(), &tx.GetTxRequest{Hash: hash})
+		resp, err := c.node.Load().txClient.GetTx(context.Background(), &tx.GetTxRequest{Hash: hash})
 		if err != nil {
 			if strings.Contains(err.Error(), "not found") {
 				time.Sleep(rate)
@@ -404,6 +561,7 @@ func (c *CosmosClient) WaitForTx(hash string, rate time.Duration) (*tx.GetTxResp
 	}
 }
 
+// signTxMsg signs the msg with the sequence of the account, accountMu must be held
 func (c *CosmosClient) signTxMsg(msg cosmostypes.Msg, adjustGas bool) ([]byte, error) {
 	encodingCfg := testutils.CreateTestEncodingConfig()
 	txBuilder := encodingCfg.TxConfig.NewTxBuilder()
@@ -413,11 +571,6 @@ func (c *CosmosClient) signTxMsg(msg cosmostypes.Msg, adjustGas bool) ([]byte, e
 	if err != nil {
 		return nil, err
 	}
-	
-	if err := c.updateAccSequence(); err != nil {
-		log.Printf("Error updating Account sequence in Tx queue handler: %v", err)
-		return nil, err
-	}
 
 	var newGasLimit uint64 = defaultGasLimit
 	if adjustGas {
@@ -430,7 +583,7 @@ func (c *CosmosClient) signTxMsg(msg cosmostypes.Msg, adjustGas bool) ([]byte, e
 			WithSequence(c.account.Sequence).
 			WithGasAdjustment(defaultGasAdjustment)
 
-		_, newGasLimit, err = clienttx.CalculateGas(c.grpcConn, txf, msg)
+		_, newGasLimit, err = clienttx.CalculateGas(c.node.Load().grpcConn, txf, msg)
 		if err != nil {
 			return nil, err
 		}
@@ -438,6 +591,11 @@ func (c *CosmosClient) signTxMsg(msg cosmostypes.Msg, adjustGas bool) ([]byte, e
 
 	txBuilder.SetGasLimit(newGasLimit)
 
+	if gasPrice := c.gasPrice.Load(); gasPrice != nil {
+		fee := gasPrice.Amount.MulInt64(int64(newGasLimit)).Ceil().TruncateInt()
+		txBuilder.SetFeeAmount(cosmostypes.NewCoins(cosmostypes.NewCoin(gasPrice.Denom, fee)))
+	}
+
 	signerData := authsigning.SignerData{
 		ChainID:       c.chainID,
 		AccountNumber: c.account.AccountNumber,
@@ -0,0 +1,445 @@
+package cosmosClient
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cosmossdk.io/math"
+	"fairyringclient/pkg/cosmosClient/fakechain"
+	keysharetypes "github.com/Fairblock/fairyring/x/keyshare/types"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testChainID = "fairyring-test"
+	testDenom   = "ufairy"
+)
+
+type fakeChainSetup struct {
+	chain      *fakechain.FakeChain
+	client     *CosmosClient
+	validators []fakechain.Validator
+	active     *fakechain.Round
+	queued     *fakechain.Round
+}
+
+// newFakeChainSetup starts a fake chain with 4 validators in the active & queued round,
+// and connects a client with the key of the first validator
+func newFakeChainSetup(t *testing.T) fakeChainSetup {
+	t.Helper()
+
+	setBech32Prefixes()
+
+	validators := make([]fakechain.Validator, 4)
+	for i := range validators {
+		validators[i] = fakechain.NewValidator()
+	}
+
+	active, err := fakechain.NewRound(validators, 3)
+	if err != nil {
+		t.Fatalf("error creating active round: %s", err.Error())
+	}
+	queued, err := fakechain.NewRound(validators, 3)
+	if err != nil {
+		t.Fatalf("error creating queued round: %s", err.Error())
+	}
+
+	chain := fakechain.New()
+	t.Cleanup(chain.Close)
+
+	for i, v := range validators {
+		chain.AddAccount(v.Address, uint64(i), 0)
+	}
+	chain.SetBalance(validators[0].Address, testDenom, math.NewInt(1000000))
+	chain.SetRound(active, 100, false)
+	chain.SetRound(queued, 200, true)
+
+	client, err := NewCosmosClient(fakechain.Endpoint, validators[0].PrivateKeyHex, testChainID, chain.DialOption())
+	if err != nil {
+		t.Fatalf("error creating cosmos client: %s", err.Error())
+	}
+
+	return fakeChainSetup{
+		chain:      chain,
+		client:     client,
+		validators: validators,
+		active:     active,
+		queued:     queued,
+	}
+}
+
+func TestNewCosmosClientWithFakeChain(t *testing.T) {
+	setup := newFakeChainSetup(t)
+
+	if setup.client.GetAddress() != setup.validators[0].Address {
+		t.Fatalf("expected address %s, got: %s", setup.validators[0].Address, setup.client.GetAddress())
+	}
+
+	bal, err := setup.client.GetBalance(testDenom)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !bal.Equal(math.NewInt(1000000)) {
+		t.Fatalf("expected balance 1000000, got: %s", bal.String())
+	}
+}
+
+func TestNewCosmosClientAccountNotFound(t *testing.T) {
+	setBech32Prefixes()
+
+	chain := fakechain.New()
+	t.Cleanup(chain.Close)
+
+	v := fakechain.NewValidator()
+	_, err := NewCosmosClient(fakechain.Endpoint, v.PrivateKeyHex, testChainID, chain.DialOption())
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+}
+
+func TestGetKeyShareFromFakeChain(t *testing.T) {
+	setup := newFakeChainSetup(t)
+
+	share, index, expiry, err := setup.client.GetKeyShare(false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if index != 1 || expiry != 100 {
+		t.Fatalf("expected index 1 expiry 100, got index %d expiry %d", index, expiry)
+	}
+	if !share.Value.Equal(setup.active.Shares[0].Value) {
+		t.Fatal("decrypted active share does not match the dealt share")
+	}
+
+	share, index, expiry, err = setup.client.GetKeyShare(true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if index != 1 || expiry != 200 {
+		t.Fatalf("expected index 1 expiry 200, got index %d expiry %d", index, expiry)
+	}
+	if !share.Value.Equal(setup.queued.Shares[0].Value) {
+		t.Fatal("decrypted queued share does not match the dealt share")
+	}
+
+	commits, err := setup.client.GetCommitments()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if commits.ActiveCommitments.Commitments[0] != setup.active.Commitments[0] {
+		t.Fatal("active commitments do not match the round")
+	}
+}
+
+func TestGetKeyShareNotInRound(t *testing.T) {
+	setup := newFakeChainSetup(t)
+
+	others := []fakechain.Validator{fakechain.NewValidator(), fakechain.NewValidator()}
+	round, err := fakechain.NewRound(others, 2)
+	if err != nil {
+		t.Fatalf("error creating round: %s", err.Error())
+	}
+	setup.chain.SetRound(round, 100, false)
+
+	if _, _, _, err = setup.client.GetKeyShare(false); err == nil {
+		t.Fatal("expected error when the validator has no share in the round")
+	}
+
+	setup.chain.SetActivePubkey("", 0, nil, nil)
+	if _, _, _, err = setup.client.GetKeyShare(false); err == nil {
+		t.Fatal("expected error when the round has no share")
+	}
+}
+
+func TestIsAddrAuthorized(t *testing.T) {
+	setup := newFakeChainSetup(t)
+
+	if setup.client.IsAddrAuthorized(setup.client.GetAddress()) {
+		t.Fatal("expected unknown address not authorized")
+	}
+	setup.chain.SetAuthorized(setup.client.GetAddress(), true)
+	if !setup.client.IsAddrAuthorized(setup.client.GetAddress()) {
+		t.Fatal("expected address authorized")
+	}
+}
+
+func TestBroadcastTxWithFakeChain(t *testing.T) {
+	setup := newFakeChainSetup(t)
+	setup.chain.SetTxResultFunc(func([]cosmostypes.Msg) fakechain.TxResult {
+		return fakechain.TxResult{PendingPolls: 1}
+	})
+
+	resp, err := setup.client.BroadcastTx(&keysharetypes.MsgSendKeyshare{
+		Creator:       setup.client.GetAddress(),
+		Message:       "keyshare",
+		KeyshareIndex: 1,
+		BlockHeight:   10,
+	}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if resp.TxResponse.Code != 0 {
+		t.Fatalf("expected code 0, got: %d", resp.TxResponse.Code)
+	}
+
+	broadcasts := setup.chain.Broadcasts()
+	if len(broadcasts) != 1 {
+		t.Fatalf("expected 1 broadcast tx, got: %d", len(broadcasts))
+	}
+	msg, ok := broadcasts[0].Msgs[0].(*keysharetypes.MsgSendKeyshare)
+	if !ok {
+		t.Fatalf("expected MsgSendKeyshare, got: %T", broadcasts[0].Msgs[0])
+	}
+	if msg.BlockHeight != 10 || msg.Creator != setup.client.GetAddress() {
+		t.Fatalf("unexpected msg: %s", msg.String())
+	}
+	if resp.TxResponse.TxHash != broadcasts[0].Hash {
+		t.Fatalf("expected tx hash %s, got: %s", broadcasts[0].Hash, resp.TxResponse.TxHash)
+	}
+
+	if seq := setup.chain.Sequence(setup.client.GetAddress()); seq != 1 {
+		t.Fatalf("expected sequence 1, got: %d", seq)
+	}
+
+	// The sequence is updated from chain before signing the next tx
+	if _, err = setup.client.BroadcastTx(&keysharetypes.MsgSendKeyshare{
+		Creator:       setup.client.GetAddress(),
+		Message:       "keyshare",
+		KeyshareIndex: 1,
+		BlockHeight:   11,
+	}, false); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if seq := setup.chain.Sequence(setup.client.GetAddress()); seq != 2 {
+		t.Fatalf("expected sequence 2, got: %d", seq)
+	}
+}
+
+func TestBroadcastTxWithGasPrice(t *testing.T) {
+	setup := newFakeChainSetup(t)
+
+	if err := setup.client.SetGasPrice("invalid"); err == nil {
+		t.Fatal("expected error setting invalid gas price")
+	}
+	if err := setup.client.SetGasPrice("0.025" + testDenom); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	msg := &keysharetypes.MsgSendKeyshare{
+		Creator:       setup.client.GetAddress(),
+		Message:       "keyshare",
+		KeyshareIndex: 1,
+		BlockHeight:   10,
+	}
+	if _, err := setup.client.BroadcastTx(msg, false); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	// 0.025 * the default gas limit of 300000
+	expected := cosmostypes.NewCoins(cosmostypes.NewInt64Coin(testDenom, 7500))
+	if fee := setup.chain.Broadcasts()[0].Fee; !fee.Equal(expected) {
+		t.Fatalf("expected fee %s, got: %s", expected, fee)
+	}
+
+	if err := setup.client.SetGasPrice(""); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	msg.BlockHeight = 11
+	if _, err := setup.client.BroadcastTx(msg, false); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if fee := setup.chain.Broadcasts()[1].Fee; !fee.IsZero() {
+		t.Fatalf("expected no fee without gas price, got: %s", fee)
+	}
+}
+
+func TestTxQueueWithFakeChain(t *testing.T) {
+	setup := newFakeChainSetup(t)
+	setup.chain.SetTxResultFunc(func(msgs []cosmostypes.Msg) fakechain.TxResult {
+		msg := msgs[0].(*keysharetypes.MsgSendKeyshare)
+		switch msg.BlockHeight {
+		case 1:
+			return fakechain.TxResult{}
+		case 2:
+			return fakechain.TxResult{Events: fakechain.SlashedEvents(msg.Creator)}
+		default:
+			return fakechain.TxResult{CheckTxCode: 32, RawLog: "account sequence mismatch"}
+		}
+	})
+
+	go func() {
+		_ = setup.client.HandleTxQueue()
+	}()
+
+	type result struct {
+		resp *tx.GetTxResponse
+		err  error
+	}
+
+	submit := func(height uint64) result {
+		done := make(chan result, 1)
+		setup.client.AddTxToQueue(&keysharetypes.MsgSendKeyshare{
+			Creator:       setup.client.GetAddress(),
+			Message:       "keyshare",
+			KeyshareIndex: 1,
+			BlockHeight:   height,
+		}, true,
+			func(err error) { done <- result{err: err} },
+			func(resp *tx.GetTxResponse) { done <- result{resp: resp} },
+		)
+
+		select {
+		case r := <-done:
+			return r
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout waiting for tx result of height %d", height)
+		}
+		return result{}
+	}
+
+	r := submit(1)
+	if r.err != nil || r.resp.TxResponse.Code != 0 || len(r.resp.TxResponse.Events) != 0 {
+		t.Fatalf("expected confirmed tx, got: %+v", r)
+	}
+
+	r = submit(2)
+	if r.err != nil || len(r.resp.TxResponse.Events) != 1 || r.resp.TxResponse.Events[0].Type != "coin_spent" {
+		t.Fatalf("expected slashed tx with coin_spent event, got: %+v", r)
+	}
+
+	r = submit(3)
+	if r.err == nil || !strings.Contains(r.err.Error(), "account sequence mismatch") {
+		t.Fatalf("expected broadcast error, got: %+v", r)
+	}
+}
+
+func TestGetDecryptionKeyFromFakeChain(t *testing.T) {
+	setup := newFakeChainSetup(t)
+
+	if _, err := setup.client.GetDecryptionKey(10); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+
+	setup.chain.SetDecryptionKey(10, "aggregated")
+	key, err := setup.client.GetDecryptionKey(10)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if key.Data != "aggregated" || key.Height != 10 {
+		t.Fatalf("unexpected decryption key: %s", key.String())
+	}
+
+	pubKey, err := setup.client.GetPepPubKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if pubKey.ActivePubkey.PublicKey != setup.active.PubKey {
+		t.Fatal("pep active pubkey does not match the round")
+	}
+}
+
+func TestTxQueueSequences(t *testing.T) {
+	setup := newFakeChainSetup(t)
+
+	go func() {
+		_ = setup.client.HandleTxQueue()
+	}()
+
+	submit := func(height uint64) <-chan error {
+		done := make(chan error, 1)
+		setup.client.AddTxToQueue(&keysharetypes.MsgSendKeyshare{
+			Creator:       setup.client.GetAddress(),
+			Message:       "keyshare",
+			KeyshareIndex: 1,
+			BlockHeight:   height,
+		}, false,
+			func(err error) { done <- err },
+			func(*tx.GetTxResponse) { done <- nil },
+		)
+		return done
+	}
+	wait := func(done <-chan error) error {
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for tx result")
+		}
+		return nil
+	}
+
+	// The txs signed concurrently each get their own sequence
+	results := make([]<-chan error, 5)
+	for i := range results {
+		results[i] = submit(uint64(i + 1))
+	}
+	for _, done := range results {
+		if err := wait(done); err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+	}
+
+	sequences := make(map[uint64]bool)
+	for _, b := range setup.chain.Broadcasts() {
+		sequences[b.Sequence] = true
+	}
+	if len(sequences) != 5 || setup.chain.Sequence(setup.client.GetAddress()) != 5 {
+		t.Fatalf("expected 5 distinct sequences, got: %v", sequences)
+	}
+
+	// The sequence is synced from chain again after a tx is rejected
+	setup.chain.AddAccount(setup.client.GetAddress(), 0, 10)
+	if err := wait(submit(6)); err == nil || !strings.Contains(err.Error(), "account sequence mismatch") {
+		t.Fatalf("expected sequence mismatch, got: %v", err)
+	}
+	if err := wait(submit(7)); err != nil {
+		t.Fatalf("expected tx accepted after syncing the sequence, got: %s", err.Error())
+	}
+	if seq := setup.chain.Sequence(setup.client.GetAddress()); seq != 11 {
+		t.Fatalf("expected sequence 11, got: %d", seq)
+	}
+}
+
+func TestRedial(t *testing.T) {
+	setup := newFakeChainSetup(t)
+	setup.chain.SetLatestHeight(10)
+
+	// The account does not exist on the node, the connection in use is kept
+	empty := fakechain.New()
+	t.Cleanup(empty.Close)
+	if err := setup.client.Redial(fakechain.Endpoint, empty.DialOption()); status.Code(errors.Cause(err)) != codes.NotFound {
+		t.Fatalf("expected not found error, got: %v", err)
+	}
+	if height, err := setup.client.GetLatestHeight(); err != nil || height != 10 {
+		t.Fatalf("expected height 10 of the node in use, got: %d, %v", height, err)
+	}
+
+	other := fakechain.New()
+	t.Cleanup(other.Close)
+	other.AddAccount(setup.client.GetAddress(), 0, 5)
+	other.SetLatestHeight(20)
+	if err := setup.client.Redial(fakechain.Endpoint, other.DialOption()); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if height, err := setup.client.GetLatestHeight(); err != nil || height != 20 {
+		t.Fatalf("expected height 20 of the new node, got: %d, %v", height, err)
+	}
+
+	// The sequence is synced from the new node before signing
+	if _, err := setup.client.BroadcastTx(&keysharetypes.MsgSendKeyshare{
+		Creator:       setup.client.GetAddress(),
+		Message:       "keyshare",
+		KeyshareIndex: 1,
+		BlockHeight:   21,
+	}, false); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(setup.chain.Broadcasts()) != 0 || other.Sequence(setup.client.GetAddress()) != 6 {
+		t.Fatalf("expected tx broadcast to the new node with sequence 5, got sequence: %d", other.Sequence(setup.client.GetAddress()))
+	}
+}
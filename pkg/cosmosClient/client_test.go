@@ -0,0 +1,120 @@
+package cosmosClient
+
+import (
+	"encoding/base64"
+	"testing"
+
+	distIBE "github.com/FairBlock/DistributedIBE"
+	dcrdSecp256k1 "github.com/decred/dcrd/dcrec/secp256k1"
+)
+
+func newTestShareClient(tb testing.TB) (*CosmosClient, *dcrdSecp256k1.PublicKey) {
+	tb.Helper()
+
+	privKey, err := dcrdSecp256k1.GeneratePrivateKey()
+	if err != nil {
+		tb.Fatalf("error generating private key: %s", err.Error())
+	}
+	return &CosmosClient{dcrdPrivKey: *privKey}, privKey.PubKey()
+}
+
+// encryptShare encrypts the share the same way the keyshare module does for each validator
+func encryptShare(tb testing.TB, pubKey *dcrdSecp256k1.PublicKey, share distIBE.Share) string {
+	tb.Helper()
+
+	shareBytes, err := share.Value.MarshalBinary()
+	if err != nil {
+		tb.Fatalf("error marshaling share: %s", err.Error())
+	}
+	cipher, err := dcrdSecp256k1.Encrypt(pubKey, shareBytes)
+	if err != nil {
+		tb.Fatalf("error encrypting share: %s", err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(cipher)
+}
+
+func TestDecryptAndParseShare(t *testing.T) {
+	c, pubKey := newTestShareClient(t)
+
+	shares, _, _, err := distIBE.GenerateShares(4, 3)
+	if err != nil {
+		t.Fatalf("error generating shares: %s", err.Error())
+	}
+
+	for i, share := range shares {
+		decrypted, err := c.decryptShare(encryptShare(t, pubKey, share))
+		if err != nil {
+			t.Fatalf("share %d: error decrypting: %s", i+1, err.Error())
+		}
+
+		parsed, err := c.parseShare(decrypted, int64(i+1))
+		if err != nil {
+			t.Fatalf("share %d: error parsing: %s", i+1, err.Error())
+		}
+		if !parsed.Value.Equal(share.Value) {
+			t.Fatalf("share %d: parsed value does not match the original share", i+1)
+		}
+		if !parsed.Index.Equal(share.Index) {
+			t.Fatalf("share %d: parsed index does not match the original share", i+1)
+		}
+	}
+}
+
+func TestDecryptShareWrongKey(t *testing.T) {
+	_, pubKey := newTestShareClient(t)
+	other, _ := newTestShareClient(t)
+
+	shares, _, _, err := distIBE.GenerateShares(4, 3)
+	if err != nil {
+		t.Fatalf("error generating shares: %s", err.Error())
+	}
+
+	if _, err = other.decryptShare(encryptShare(t, pubKey, shares[0])); err == nil {
+		t.Fatal("expected error decrypting share encrypted for another key")
+	}
+	if _, err = other.decryptShare("invalid base64"); err == nil {
+		t.Fatal("expected error for invalid base64 cipher")
+	}
+}
+
+func TestParseShareInvalid(t *testing.T) {
+	c, _ := newTestShareClient(t)
+	if _, err := c.parseShare([]byte("invalid"), 1); err == nil {
+		t.Fatal("expected error parsing invalid share bytes")
+	}
+}
+
+func BenchmarkDecryptShare(b *testing.B) {
+	c, pubKey := newTestShareClient(b)
+	shares, _, _, err := distIBE.GenerateShares(4, 3)
+	if err != nil {
+		b.Fatal(err)
+	}
+	cipher := encryptShare(b, pubKey, shares[0])
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.decryptShare(cipher); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseShare(b *testing.B) {
+	c, _ := newTestShareClient(b)
+	shares, _, _, err := distIBE.GenerateShares(4, 3)
+	if err != nil {
+		b.Fatal(err)
+	}
+	shareBytes, err := shares[0].Value.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.parseShare(shareBytes, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
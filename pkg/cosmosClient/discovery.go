@@ -0,0 +1,61 @@
+package cosmosClient
+
+import (
+	"context"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	"github.com/cosmos/cosmos-sdk/client/grpc/node"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+const discoveryTimeout = 5 * time.Second
+
+// ChainInfo is the chain the node runs, the fields other than ChainID are empty if the node does not expose them
+type ChainInfo struct {
+	ChainID string
+	// Denom is the bond denom of the chain
+	Denom        string
+	Bech32Prefix string
+	// MinGasPrices are the minimum gas prices the node accepts txs with, empty if the node accepts txs without fee
+	MinGasPrices cosmostypes.DecCoins
+}
+
+// DiscoverChain queries the chain ID, bond denom, address prefix & minimum gas prices from the gRPC endpoint of the node
+func DiscoverChain(endpoint string, dialOpts ...grpc.DialOption) (*ChainInfo, error) {
+	conn, err := grpc.Dial(endpoint, append([]grpc.DialOption{grpc.WithInsecure()}, dialOpts...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	nodeInfo, err := cmtservice.NewServiceClient(conn).GetNodeInfo(ctx, &cmtservice.GetNodeInfoRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error querying node info")
+	}
+	info := &ChainInfo{ChainID: nodeInfo.GetDefaultNodeInfo().GetNetwork()}
+
+	if params, err := stakingtypes.NewQueryClient(conn).Params(ctx, &stakingtypes.QueryParamsRequest{}); err == nil {
+		info.Denom = params.Params.BondDenom
+	}
+
+	if prefix, err := authtypes.NewQueryClient(conn).Bech32Prefix(ctx, &authtypes.Bech32PrefixRequest{}); err == nil {
+		info.Bech32Prefix = prefix.Bech32Prefix
+	}
+
+	nodeConfig, err := node.NewServiceClient(conn).Config(ctx, &node.ConfigRequest{})
+	if err == nil && len(nodeConfig.MinimumGasPrice) > 0 {
+		if info.MinGasPrices, err = cosmostypes.ParseDecCoins(nodeConfig.MinimumGasPrice); err != nil {
+			return nil, errors.Wrapf(err, "invalid minimum gas price of node %s", nodeConfig.MinimumGasPrice)
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,56 @@
+package cosmosClient
+
+import (
+	"strings"
+	"testing"
+
+	"cosmossdk.io/math"
+	"fairyringclient/pkg/cosmosClient/fakechain"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestDiscoverChain(t *testing.T) {
+	chain := fakechain.New()
+	t.Cleanup(chain.Close)
+	chain.SetChainInfo(testChainID, testDenom, "fairy", "0.025ufairy,0.1uatom")
+
+	info, err := DiscoverChain(fakechain.Endpoint, chain.DialOption())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if info.ChainID != testChainID || info.Denom != testDenom || info.Bech32Prefix != "fairy" {
+		t.Fatalf("unexpected chain info: %+v", info)
+	}
+	if !info.MinGasPrices.AmountOf(testDenom).Equal(math.LegacyMustNewDecFromStr("0.025")) || len(info.MinGasPrices) != 2 {
+		t.Fatalf("unexpected minimum gas prices: %s", info.MinGasPrices.String())
+	}
+
+	// Denom & prefix are left empty when the node does not expose them
+	chain.SetChainInfo(testChainID, "", "", "")
+	if info, err = DiscoverChain(fakechain.Endpoint, chain.DialOption()); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(info.Denom) != 0 || len(info.Bech32Prefix) != 0 || !info.MinGasPrices.IsZero() {
+		t.Fatalf("expected only the chain ID discovered, got: %+v", info)
+	}
+}
+
+func TestSetBech32Prefix(t *testing.T) {
+	t.Cleanup(func() { SetBech32Prefix("") })
+
+	key := fakechain.NewValidator().PrivateKeyHex
+
+	SetBech32Prefix("cosmos")
+	address, err := AddressFromPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !strings.HasPrefix(address, "cosmos1") || cosmostypes.GetConfig().GetBech32ValidatorAddrPrefix() != "cosmosvaloper" {
+		t.Fatalf("expected cosmos prefixes, got: %s, %s", address, cosmostypes.GetConfig().GetBech32ValidatorAddrPrefix())
+	}
+
+	SetBech32Prefix("")
+	if address, _ = AddressFromPrivateKey(key); !strings.HasPrefix(address, DefaultBech32Prefix+"1") {
+		t.Fatalf("expected default prefix, got: %s", address)
+	}
+}
@@ -0,0 +1,283 @@
+// Package fakechain provides an in-process FairyRing gRPC backend for hermetic tests.
+// It implements the auth, bank, tx, pep, keyshare, staking and node info services used by the cosmos client
+// over a bufconn listener, with state that can be scripted by the test.
+package fakechain
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"cosmossdk.io/math"
+	keysharetypes "github.com/Fairblock/fairyring/x/keyshare/types"
+	peptypes "github.com/Fairblock/fairyring/x/pep/types"
+	abciTypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	"github.com/cosmos/cosmos-sdk/client/grpc/node"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/skip-mev/block-sdk/v2/testutils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Endpoint is the endpoint to pass to the cosmos client together with DialOption
+const Endpoint = "passthrough:///fakechain"
+
+const (
+	bufSize = 1024 * 1024
+
+	DefaultGasUsed = 100000
+)
+
+// TxResult is the result of a broadcast tx once it is included in a block
+type TxResult struct {
+	Code    uint32
+	RawLog  string
+	GasUsed int64
+	Events  []abciTypes.Event
+	// CheckTxCode is returned on broadcast, the tx is never included if it is not 0
+	CheckTxCode uint32
+	// PendingPolls is the number of GetTx calls responding not found before the tx is found
+	PendingPolls int
+}
+
+// BroadcastTx is a tx received by the fake chain
+type BroadcastTx struct {
+	Hash string
+	Msgs []cosmostypes.Msg
+	Fee  cosmostypes.Coins
+	// Sequence the tx is signed with
+	Sequence uint64
+}
+
+type storedTx struct {
+	resp         *tx.GetTxResponse
+	pendingPolls int
+}
+
+type FakeChain struct {
+	mu sync.Mutex
+
+	listener *bufconn.Listener
+	server   *grpc.Server
+	txConfig testutils.EncodingConfig
+
+	accounts       map[string]*authtypes.BaseAccount
+	balances       map[string]map[string]math.Int
+	activePubkey   keysharetypes.ActivePubkey
+	queuedPubkey   keysharetypes.QueuedPubkey
+	commitments    keysharetypes.QueryCommitmentsResponse
+	authorized     map[string]bool
+	decryptionKeys map[uint64]string
+	latestHeight   uint64
+	txs            map[string]*storedTx
+	broadcasts     []BroadcastTx
+	txResultFunc   func(msgs []cosmostypes.Msg) TxResult
+	chainID        string
+	bondDenom      string
+	bech32Prefix   string
+	minGasPrice    string
+}
+
+// New starts serving the fake chain, Close must be called to stop it
+func New() *FakeChain {
+	encodingCfg := testutils.CreateTestEncodingConfig()
+	keysharetypes.RegisterInterfaces(encodingCfg.InterfaceRegistry)
+
+	f := &FakeChain{
+		listener:       bufconn.Listen(bufSize),
+		server:         grpc.NewServer(),
+		txConfig:       encodingCfg,
+		accounts:       make(map[string]*authtypes.BaseAccount),
+		balances:       make(map[string]map[string]math.Int),
+		authorized:     make(map[string]bool),
+		decryptionKeys: make(map[uint64]string),
+		txs:            make(map[string]*storedTx),
+		commitments: keysharetypes.QueryCommitmentsResponse{
+			ActiveCommitments: &keysharetypes.Commitments{},
+			QueuedCommitments: &keysharetypes.Commitments{},
+		},
+		txResultFunc: func([]cosmostypes.Msg) TxResult {
+			return TxResult{}
+		},
+	}
+
+	authtypes.RegisterQueryServer(f.server, &authServer{f: f})
+	banktypes.RegisterQueryServer(f.server, &bankServer{f: f})
+	tx.RegisterServiceServer(f.server, &txServer{f: f})
+	peptypes.RegisterQueryServer(f.server, &pepServer{f: f})
+	keysharetypes.RegisterQueryServer(f.server, &keyshareServer{f: f})
+	cmtservice.RegisterServiceServer(f.server, &cmtServer{f: f})
+	stakingtypes.RegisterQueryServer(f.server, &stakingServer{f: f})
+	node.RegisterServiceServer(f.server, &nodeServer{f: f})
+
+	go func() {
+		_ = f.server.Serve(f.listener)
+	}()
+
+	return f
+}
+
+// DialOption connects the cosmos client to the fake chain instead of the network
+func (f *FakeChain) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return f.listener.DialContext(ctx)
+	})
+}
+
+func (f *FakeChain) Close() {
+	f.server.Stop()
+	_ = f.listener.Close()
+}
+
+func (f *FakeChain) AddAccount(address string, accountNumber uint64, sequence uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accounts[address] = &authtypes.BaseAccount{
+		Address:       address,
+		AccountNumber: accountNumber,
+		Sequence:      sequence,
+	}
+}
+
+// Sequence returns the current sequence of the account, it is increased on each accepted tx
+func (f *FakeChain) Sequence(address string) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	acc, found := f.accounts[address]
+	if !found {
+		return 0
+	}
+	return acc.Sequence
+}
+
+func (f *FakeChain) SetBalance(address string, denom string, amount math.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, found := f.balances[address]; !found {
+		f.balances[address] = make(map[string]math.Int)
+	}
+	f.balances[address][denom] = amount
+}
+
+func (f *FakeChain) SetActivePubkey(pubkey string, expiry uint64, encryptedKeyshares []*keysharetypes.EncryptedKeyshare, commitments []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.activePubkey = keysharetypes.ActivePubkey{
+		PublicKey:          pubkey,
+		Expiry:             expiry,
+		NumberOfValidators: uint64(len(encryptedKeyshares)),
+		EncryptedKeyshares: encryptedKeyshares,
+	}
+	f.commitments.ActiveCommitments = &keysharetypes.Commitments{Commitments: commitments}
+}
+
+func (f *FakeChain) SetQueuedPubkey(pubkey string, expiry uint64, encryptedKeyshares []*keysharetypes.EncryptedKeyshare, commitments []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queuedPubkey = keysharetypes.QueuedPubkey{
+		PublicKey:          pubkey,
+		Expiry:             expiry,
+		NumberOfValidators: uint64(len(encryptedKeyshares)),
+		EncryptedKeyshares: encryptedKeyshares,
+	}
+	f.commitments.QueuedCommitments = &keysharetypes.Commitments{Commitments: commitments}
+}
+
+// SetRound sets the round as the active or the queued one
+func (f *FakeChain) SetRound(r *Round, expiry uint64, queued bool) {
+	if queued {
+		f.SetQueuedPubkey(r.PubKey, expiry, r.EncryptedKeyshares, r.Commitments)
+		return
+	}
+	f.SetActivePubkey(r.PubKey, expiry, r.EncryptedKeyshares, r.Commitments)
+}
+
+// SetChainInfo sets the chain ID, bond denom, address prefix & minimum gas price reported by the node
+func (f *FakeChain) SetChainInfo(chainID string, bondDenom string, bech32Prefix string, minGasPrice string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chainID = chainID
+	f.bondDenom = bondDenom
+	f.bech32Prefix = bech32Prefix
+	f.minGasPrice = minGasPrice
+}
+
+func (f *FakeChain) SetAuthorized(address string, authorized bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authorized[address] = authorized
+}
+
+func (f *FakeChain) SetDecryptionKey(height uint64, data string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.decryptionKeys[height] = data
+}
+
+func (f *FakeChain) SetLatestHeight(height uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latestHeight = height
+}
+
+// SetTxResultFunc decides the result of each tx broadcast after it is called
+func (f *FakeChain) SetTxResultFunc(fn func(msgs []cosmostypes.Msg) TxResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.txResultFunc = fn
+}
+
+// Broadcasts returns all the txs accepted by the fake chain in order
+func (f *FakeChain) Broadcasts() []BroadcastTx {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]BroadcastTx{}, f.broadcasts...)
+}
+
+// SlashedEvents returns the events of a tx that got the submitter slashed
+func SlashedEvents(address string) []abciTypes.Event {
+	return []abciTypes.Event{
+		{
+			Type: "coin_spent",
+			Attributes: []abciTypes.EventAttribute{
+				{Key: "spender", Value: address},
+			},
+		},
+	}
+}
+
+// decodeTx returns the tx and the address of the first signer,
+// the signer is nil for simulated txs that are built without the signer pubkey
+func (f *FakeChain) decodeTx(txBytes []byte) (authsigning.Tx, cosmostypes.AccAddress, error) {
+	decoded, err := f.txConfig.TxConfig.TxDecoder()(txBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sigTx, ok := decoded.(authsigning.Tx)
+	if !ok {
+		return nil, nil, fmt.Errorf("tx is not signed")
+	}
+	pubKeys, err := sigTx.GetPubKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pubKeys) == 0 || pubKeys[0] == nil || len(pubKeys[0].Bytes()) == 0 {
+		return sigTx, nil, nil
+	}
+
+	return sigTx, cosmostypes.AccAddress(pubKeys[0].Address()), nil
+}
+
+func txHash(txBytes []byte) string {
+	return strings.ToUpper(fmt.Sprintf("%x", sha256.Sum256(txBytes)))
+}
@@ -0,0 +1,87 @@
+package fakechain
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+
+	distIBE "github.com/FairBlock/DistributedIBE"
+	keysharetypes "github.com/Fairblock/fairyring/x/keyshare/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	dcrdSecp256k1 "github.com/decred/dcrd/dcrec/secp256k1"
+	"github.com/drand/kyber"
+	bls "github.com/drand/kyber-bls12381"
+)
+
+// Validator is a keyshare module validator that receives an encrypted share in each round
+type Validator struct {
+	Address       string
+	PrivateKeyHex string
+	pubKey        *dcrdSecp256k1.PublicKey
+}
+
+// NewValidator returns a validator with a random private key, the address is encoded
+// with the bech32 prefixes in the global sdk config at the time it is called
+func NewValidator() Validator {
+	privKey := secp256k1.GenPrivKey()
+	_, dcrdPubKey := dcrdSecp256k1.PrivKeyFromBytes(privKey.Key)
+	return Validator{
+		Address:       cosmostypes.AccAddress(privKey.PubKey().Address()).String(),
+		PrivateKeyHex: hex.EncodeToString(privKey.Key),
+		pubKey:        dcrdPubKey,
+	}
+}
+
+// Round is a dealer setup for a set of validators, the share of the validator at index i
+// is encrypted for it the same way the keyshare module does
+type Round struct {
+	PubKey             string
+	MasterPubKey       kyber.Point
+	Shares             []distIBE.Share
+	Commitments        []string
+	EncryptedKeyshares []*keysharetypes.EncryptedKeyshare
+}
+
+func NewRound(validators []Validator, threshold uint32) (*Round, error) {
+	s := bls.NewBLS12381Suite()
+
+	shares, mpk, _, err := distIBE.GenerateShares(uint32(len(validators)), threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	mpkBytes, err := mpk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Round{
+		PubKey:       hex.EncodeToString(mpkBytes),
+		MasterPubKey: mpk,
+		Shares:       shares,
+	}
+
+	for i, share := range shares {
+		commitment := s.G1().Point().Mul(share.Value, s.G1().Point().Base())
+		commitmentBytes, err := commitment.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		r.Commitments = append(r.Commitments, hex.EncodeToString(commitmentBytes))
+
+		shareBytes, err := share.Value.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		cipher, err := dcrdSecp256k1.Encrypt(validators[i].pubKey, shareBytes)
+		if err != nil {
+			return nil, err
+		}
+		r.EncryptedKeyshares = append(r.EncryptedKeyshares, &keysharetypes.EncryptedKeyshare{
+			Data:      base64.StdEncoding.EncodeToString(cipher),
+			Validator: validators[i].Address,
+		})
+	}
+
+	return r, nil
+}
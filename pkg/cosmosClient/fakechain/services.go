@@ -0,0 +1,277 @@
+package fakechain
+
+import (
+	"context"
+	"fmt"
+
+	"cosmossdk.io/math"
+	keysharetypes "github.com/Fairblock/fairyring/x/keyshare/types"
+	peptypes "github.com/Fairblock/fairyring/x/pep/types"
+	"github.com/cometbft/cometbft/proto/tendermint/p2p"
+	"github.com/cosmos/cosmos-sdk/client/grpc/cmtservice"
+	"github.com/cosmos/cosmos-sdk/client/grpc/node"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type authServer struct {
+	authtypes.UnimplementedQueryServer
+	f *FakeChain
+}
+
+func (s *authServer) Account(_ context.Context, req *authtypes.QueryAccountRequest) (*authtypes.QueryAccountResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+
+	acc, found := s.f.accounts[req.Address]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "account %s not found", req.Address)
+	}
+
+	accAny, err := codectypes.NewAnyWithValue(acc)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &authtypes.QueryAccountResponse{Account: accAny}, nil
+}
+
+func (s *authServer) Bech32Prefix(_ context.Context, _ *authtypes.Bech32PrefixRequest) (*authtypes.Bech32PrefixResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	if len(s.f.bech32Prefix) == 0 {
+		return nil, status.Error(codes.Unimplemented, "bech32 prefix not set")
+	}
+	return &authtypes.Bech32PrefixResponse{Bech32Prefix: s.f.bech32Prefix}, nil
+}
+
+type bankServer struct {
+	banktypes.UnimplementedQueryServer
+	f *FakeChain
+}
+
+func (s *bankServer) Balance(_ context.Context, req *banktypes.QueryBalanceRequest) (*banktypes.QueryBalanceResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+
+	amount := math.ZeroInt()
+	if balances, found := s.f.balances[req.Address]; found {
+		if bal, found := balances[req.Denom]; found {
+			amount = bal
+		}
+	}
+
+	coin := cosmostypes.NewCoin(req.Denom, amount)
+	return &banktypes.QueryBalanceResponse{Balance: &coin}, nil
+}
+
+type txServer struct {
+	tx.UnimplementedServiceServer
+	f *FakeChain
+}
+
+func (s *txServer) Simulate(_ context.Context, req *tx.SimulateRequest) (*tx.SimulateResponse, error) {
+	if _, _, err := s.f.decodeTx(req.TxBytes); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &tx.SimulateResponse{
+		GasInfo: &cosmostypes.GasInfo{GasUsed: DefaultGasUsed},
+		Result:  &cosmostypes.Result{},
+	}, nil
+}
+
+func (s *txServer) BroadcastTx(_ context.Context, req *tx.BroadcastTxRequest) (*tx.BroadcastTxResponse, error) {
+	decoded, signer, err := s.f.decodeTx(req.TxBytes)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if signer == nil {
+		return nil, status.Error(codes.InvalidArgument, "tx signer not found")
+	}
+	msgs := decoded.GetMsgs()
+
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+
+	hash := txHash(req.TxBytes)
+	result := s.f.txResultFunc(msgs)
+
+	if result.CheckTxCode != 0 {
+		return &tx.BroadcastTxResponse{TxResponse: &cosmostypes.TxResponse{
+			TxHash: hash,
+			Code:   result.CheckTxCode,
+			RawLog: result.RawLog,
+		}}, nil
+	}
+
+	sigs, err := decoded.GetSignaturesV2()
+	if err != nil || len(sigs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "tx signature not found")
+	}
+
+	// The txs signed with another sequence than the one of the account are rejected, as by the ante handler
+	for _, acc := range s.f.accounts {
+		accAddr, err := cosmostypes.AccAddressFromBech32(acc.Address)
+		if err != nil || !accAddr.Equals(signer) {
+			continue
+		}
+		if sigs[0].Sequence != acc.Sequence {
+			return &tx.BroadcastTxResponse{TxResponse: &cosmostypes.TxResponse{
+				TxHash: hash,
+				Code:   32,
+				RawLog: fmt.Sprintf("account sequence mismatch, expected %d, got %d: incorrect account sequence", acc.Sequence, sigs[0].Sequence),
+			}}, nil
+		}
+		acc.Sequence++
+	}
+
+	s.f.broadcasts = append(s.f.broadcasts, BroadcastTx{Hash: hash, Msgs: msgs, Fee: decoded.GetFee(), Sequence: sigs[0].Sequence})
+
+	gasUsed := result.GasUsed
+	if gasUsed == 0 {
+		gasUsed = DefaultGasUsed
+	}
+
+	s.f.latestHeight++
+	s.f.txs[hash] = &storedTx{
+		pendingPolls: result.PendingPolls,
+		resp: &tx.GetTxResponse{
+			TxResponse: &cosmostypes.TxResponse{
+				Height:    int64(s.f.latestHeight),
+				TxHash:    hash,
+				Code:      result.Code,
+				RawLog:    result.RawLog,
+				GasWanted: gasUsed,
+				GasUsed:   gasUsed,
+				Events:    result.Events,
+			},
+		},
+	}
+
+	return &tx.BroadcastTxResponse{TxResponse: &cosmostypes.TxResponse{TxHash: hash}}, nil
+}
+
+func (s *txServer) GetTx(_ context.Context, req *tx.GetTxRequest) (*tx.GetTxResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+
+	stored, found := s.f.txs[req.Hash]
+	if !found || stored.pendingPolls > 0 {
+		if found {
+			stored.pendingPolls--
+		}
+		return nil, status.Errorf(codes.NotFound, "tx not found: %s", req.Hash)
+	}
+	return stored.resp, nil
+}
+
+type pepServer struct {
+	peptypes.UnimplementedQueryServer
+	f *FakeChain
+}
+
+func (s *pepServer) LatestHeight(_ context.Context, _ *peptypes.QueryLatestHeightRequest) (*peptypes.QueryLatestHeightResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	return &peptypes.QueryLatestHeightResponse{Height: s.f.latestHeight}, nil
+}
+
+func (s *pepServer) Pubkey(_ context.Context, _ *peptypes.QueryPubkeyRequest) (*peptypes.QueryPubkeyResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+
+	resp := &peptypes.QueryPubkeyResponse{}
+	resp.ActivePubkey.PublicKey = s.f.activePubkey.PublicKey
+	resp.ActivePubkey.Expiry = s.f.activePubkey.Expiry
+	resp.QueuedPubkey.PublicKey = s.f.queuedPubkey.PublicKey
+	resp.QueuedPubkey.Expiry = s.f.queuedPubkey.Expiry
+	return resp, nil
+}
+
+type keyshareServer struct {
+	keysharetypes.UnimplementedQueryServer
+	f *FakeChain
+}
+
+func (s *keyshareServer) Pubkey(_ context.Context, _ *keysharetypes.QueryPubkeyRequest) (*keysharetypes.QueryPubkeyResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	return &keysharetypes.QueryPubkeyResponse{
+		ActivePubkey: s.f.activePubkey,
+		QueuedPubkey: s.f.queuedPubkey,
+	}, nil
+}
+
+func (s *keyshareServer) Commitments(_ context.Context, _ *keysharetypes.QueryCommitmentsRequest) (*keysharetypes.QueryCommitmentsResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	resp := s.f.commitments
+	return &resp, nil
+}
+
+func (s *keyshareServer) AuthorizedAddress(_ context.Context, req *keysharetypes.QueryAuthorizedAddressRequest) (*keysharetypes.QueryAuthorizedAddressResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+
+	authorized, found := s.f.authorized[req.Target]
+	if !found {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &keysharetypes.QueryAuthorizedAddressResponse{
+		AuthorizedAddress: keysharetypes.AuthorizedAddress{Target: req.Target, IsAuthorized: authorized},
+	}, nil
+}
+
+func (s *keyshareServer) DecryptionKey(_ context.Context, req *keysharetypes.QueryDecryptionKeyRequest) (*keysharetypes.QueryDecryptionKeyResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+
+	data, found := s.f.decryptionKeys[req.Height]
+	if !found {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &keysharetypes.QueryDecryptionKeyResponse{
+		DecryptionKey: keysharetypes.DecryptionKey{Height: req.Height, Data: data},
+	}, nil
+}
+
+type cmtServer struct {
+	cmtservice.UnimplementedServiceServer
+	f *FakeChain
+}
+
+func (s *cmtServer) GetNodeInfo(_ context.Context, _ *cmtservice.GetNodeInfoRequest) (*cmtservice.GetNodeInfoResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	return &cmtservice.GetNodeInfoResponse{DefaultNodeInfo: &p2p.DefaultNodeInfo{Network: s.f.chainID}}, nil
+}
+
+type stakingServer struct {
+	stakingtypes.UnimplementedQueryServer
+	f *FakeChain
+}
+
+func (s *stakingServer) Params(_ context.Context, _ *stakingtypes.QueryParamsRequest) (*stakingtypes.QueryParamsResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	if len(s.f.bondDenom) == 0 {
+		return nil, status.Error(codes.Unimplemented, "bond denom not set")
+	}
+	return &stakingtypes.QueryParamsResponse{Params: stakingtypes.Params{BondDenom: s.f.bondDenom}}, nil
+}
+
+type nodeServer struct {
+	node.UnimplementedServiceServer
+	f *FakeChain
+}
+
+func (s *nodeServer) Config(_ context.Context, _ *node.ConfigRequest) (*node.ConfigResponse, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	return &node.ConfigResponse{MinimumGasPrice: s.f.minGasPrice}, nil
+}